@@ -0,0 +1,65 @@
+// Emite ou revoga as credenciais dos apps do idoso e do cuidador.
+//
+// Uso:
+//
+//	app-credential -tipo cuidador -id 12 -descricao "celular da Ana"
+//	app-credential -tipo idoso -id 7 -revogar
+//
+// O token impresso vai no app (Authorization: Bearer <token>) e não pode ser
+// recuperado depois: o banco guarda apenas o hash.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/database"
+	"eva-mind/internal/devices"
+)
+
+func main() {
+	tipo := flag.String("tipo", "", "idoso ou cuidador (obrigatório)")
+	id := flag.Int64("id", 0, "id da pessoa (obrigatório)")
+	descricao := flag.String("descricao", "", "identificação da credencial (ex.: aparelho)")
+	revogar := flag.Bool("revogar", false, "revoga todas as credenciais da pessoa")
+	flag.Parse()
+
+	if !devices.ValidTipo(*tipo) || *id <= 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Config error: %v", err)
+	}
+
+	db, err := database.NewDB(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("❌ DB error: %v", err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	service := devices.NewService(db.GetConnection(), nil)
+	p := devices.Principal{Tipo: *tipo, PessoaID: *id}
+
+	if *revogar {
+		n, err := service.RevokeCredentials(ctx, p)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ %d credencial(is) revogada(s) de %s %d", n, *tipo, *id)
+		return
+	}
+
+	token, err := service.IssueCredential(ctx, p, *descricao)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+	fmt.Println(token)
+}
@@ -0,0 +1,141 @@
+// Comando administrativo de reanálise em massa das ligações históricas.
+//
+// Uso típico após ajustar o prompt de análise:
+//
+//	reanalyze -versao v2 -de 2026-01-01 -ate 2026-02-01 -entidade "Lar Esperança"
+//	reanalyze -versao v2 -somente-relatorio -relatorio diff.json
+//	reanalyze -versao v2 -aplicar
+//
+// Os resultados são gravados em historico_analises e só substituem a análise
+// vigente (analise_gemini/urgencia/sentimento) com -aplicar.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+
+	"eva-mind/internal/analysis"
+	"eva-mind/internal/config"
+	"eva-mind/internal/database"
+)
+
+func main() {
+	versao := flag.String("versao", "", "identificador da nova versão da análise (obrigatório)")
+	de := flag.String("de", "", "data inicial das ligações (AAAA-MM-DD)")
+	ate := flag.String("ate", "", "data final das ligações, exclusiva (AAAA-MM-DD)")
+	idosoID := flag.Int64("idoso", 0, "restringe a um idoso")
+	entidade := flag.String("entidade", "", "restringe aos idosos de uma entidade")
+	porMinuto := flag.Int("por-minuto", 30, "limite de chamadas ao Gemini por minuto")
+	somenteRelatorio := flag.Bool("somente-relatorio", false, "não analisa, apenas gera o relatório da versão")
+	aplicar := flag.Bool("aplicar", false, "promove a versão a análise vigente das ligações filtradas")
+	relatorio := flag.String("relatorio", "", "arquivo para gravar o relatório completo em JSON")
+	flag.Parse()
+
+	if *versao == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	filter := analysis.Filter{IdosoID: *idosoID, Entidade: *entidade}
+	var err error
+	if filter.From, err = parseDate(*de); err != nil {
+		log.Fatalf("❌ Data inicial inválida: %v", err)
+	}
+	if filter.To, err = parseDate(*ate); err != nil {
+		log.Fatalf("❌ Data final inválida: %v", err)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("❌ Config error: %v", err)
+	}
+
+	db, err := database.NewDB(cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("❌ DB error: %v", err)
+	}
+	defer db.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	reanalyzer := analysis.NewReanalyzer(cfg, db.GetConnection())
+
+	if *aplicar {
+		n, err := reanalyzer.Apply(ctx, filter, *versao)
+		if err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		log.Printf("✅ Versão '%s' aplicada em %d ligação(ões)", *versao, n)
+		return
+	}
+
+	if !*somenteRelatorio {
+		stats, err := reanalyzer.Run(ctx, filter, *versao, *porMinuto)
+		if err != nil {
+			log.Printf("⚠️ Reanálise interrompida: %v", err)
+		}
+		if stats != nil {
+			log.Printf("✅ Reanálise: %d analisada(s), %d falha(s) de %d candidata(s)",
+				stats.Analisadas, stats.Falhas, stats.Candidatas)
+		}
+	}
+
+	report, err := reanalyzer.BuildReport(context.Background(), filter, *versao)
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	printReport(report)
+
+	if *relatorio != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*relatorio, data, 0o644); err != nil {
+			log.Fatalf("❌ Erro ao gravar relatório: %v", err)
+		}
+		log.Printf("📄 Relatório gravado em %s", *relatorio)
+	}
+}
+
+func parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
+
+func printReport(r *analysis.Report) {
+	fmt.Printf("\n📊 Relatório da versão '%s'\n", r.Versao)
+	fmt.Printf("   Ligações reanalisadas: %d\n", r.Total)
+	fmt.Printf("   Urgência alterada:     %d\n", r.UrgenciaAlterada)
+	fmt.Printf("   Humor alterado:        %d\n", r.SentimentoAlterado)
+
+	printTransitions("Transições de urgência", r.TransicoesUrgencia)
+	printTransitions("Transições de humor", r.TransicoesSentimento)
+
+	fmt.Printf("\nPara aplicar: reanalyze -versao %s -aplicar (com os mesmos filtros)\n", r.Versao)
+}
+
+func printTransitions(title string, transitions map[string]int) {
+	if len(transitions) == 0 {
+		return
+	}
+
+	keys := make([]string, 0, len(transitions))
+	for k := range transitions {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return transitions[keys[i]] > transitions[keys[j]] })
+
+	fmt.Printf("\n   %s:\n", title)
+	for _, k := range keys {
+		fmt.Printf("     %-30s %d\n", k, transitions[k])
+	}
+}
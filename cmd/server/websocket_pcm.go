@@ -14,18 +14,18 @@ import (
 	"eva-mind/internal/config"
 	"eva-mind/internal/database"
 	"eva-mind/internal/gemini"
-	"eva-mind/internal/push"
+	"eva-mind/internal/notify"
 
 	"github.com/gorilla/websocket"
 )
 
 type PCMWebSocketHandler struct {
-	upgrader    websocket.Upgrader
-	clients     map[string]*PCMClient
-	mu          sync.RWMutex
-	cfg         *config.Config
-	pushService *push.FirebaseService
-	db          *database.DB
+	upgrader   websocket.Upgrader
+	clients    map[string]*PCMClient
+	mu         sync.RWMutex
+	cfg        *config.Config
+	dispatcher *notify.Dispatcher
+	db         *database.DB
 }
 
 type PCMClient struct {
@@ -40,7 +40,7 @@ type PCMClient struct {
 	cancel       context.CancelFunc
 }
 
-func NewPCMWebSocketHandler(cfg *config.Config, pushService *push.FirebaseService, db *database.DB) *PCMWebSocketHandler {
+func NewPCMWebSocketHandler(cfg *config.Config, dispatcher *notify.Dispatcher, db *database.DB) *PCMWebSocketHandler {
 	return &PCMWebSocketHandler{
 		upgrader: websocket.Upgrader{
 			CheckOrigin: func(r *http.Request) bool {
@@ -49,10 +49,10 @@ func NewPCMWebSocketHandler(cfg *config.Config, pushService *push.FirebaseServic
 			ReadBufferSize:  8192,
 			WriteBufferSize: 8192,
 		},
-		clients:     make(map[string]*PCMClient),
-		cfg:         cfg,
-		pushService: pushService,
-		db:          db,
+		clients:    make(map[string]*PCMClient),
+		cfg:        cfg,
+		dispatcher: dispatcher,
+		db:         db,
 	}
 }
 
@@ -630,7 +630,7 @@ func (h *PCMWebSocketHandler) executeTool(client *PCMClient, fnCall map[string]i
 		reason, _ := args["reason"].(string)
 		log.Printf("🚨 Alerta de emergência! Razão: %s", reason)
 
-		if err := gemini.AlertFamily(h.db.GetConnection(), h.pushService, client.IdosoID, reason); err != nil {
+		if err := gemini.AlertFamily(h.cfg, h.db.GetConnection(), h.dispatcher, client.IdosoID, reason); err != nil {
 			log.Printf("❌ Erro ao disparar alerta para família: %v", err)
 		}
 
@@ -638,7 +638,7 @@ func (h *PCMWebSocketHandler) executeTool(client *PCMClient, fnCall map[string]i
 		medication, _ := args["medication_name"].(string)
 		log.Printf("💊 Confirmação de remédio: %s", medication)
 
-		if err := gemini.ConfirmMedication(h.db.GetConnection(), h.pushService, client.IdosoID, medication); err != nil {
+		if err := gemini.ConfirmMedication(h.cfg, h.db.GetConnection(), h.dispatcher, client.IdosoID, medication); err != nil {
 			log.Printf("❌ Erro ao registrar medicamento no DB: %v", err)
 		}
 	}
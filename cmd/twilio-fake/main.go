@@ -0,0 +1,42 @@
+// Servidor falso da API do Twilio para testar o envio de SMS sem rede nem custo.
+//
+// Uso:
+//
+//	twilio-fake -addr :8089
+//	TWILIO_API_URL=http://localhost:8089 SERVICE_DOMAIN=http://localhost:8080 ENABLE_SMS_FALLBACK=true ./eva-mind
+//
+// Usa TWILIO_ACCOUNT_SID / TWILIO_AUTH_TOKEN do ambiente, então os callbacks de
+// entrega chegam com assinatura válida. Números terminados em 9999 simulam SMS
+// não entregue. As mensagens recebidas ficam em GET /messages.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"eva-mind/internal/sms"
+)
+
+func main() {
+	addr := flag.String("addr", ":8089", "endereço de escuta")
+	sid := flag.String("sid", envOr("TWILIO_ACCOUNT_SID", "ACfake"), "account SID aceito")
+	token := flag.String("token", envOr("TWILIO_AUTH_TOKEN", "fake-token"), "auth token aceito e usado nas assinaturas")
+	atraso := flag.Duration("atraso", 2*time.Second, "atraso entre os callbacks de status")
+	flag.Parse()
+
+	fake := sms.NewFakeServer(*sid, *token)
+	fake.CallbackDelay = *atraso
+
+	log.Printf("📨 Twilio falso em %s (account %s)", *addr, *sid)
+	log.Fatal(http.ListenAndServe(*addr, fake))
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
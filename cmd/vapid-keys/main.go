@@ -0,0 +1,26 @@
+// Gera o par de chaves VAPID do Web Push do painel web.
+//
+// Uso:
+//
+//	vapid-keys
+//
+// A chave privada vai em VAPID_PRIVATE_KEY; a pública é servida ao painel em
+// GET /api/dispositivos/webpush. Trocar as chaves invalida todas as assinaturas.
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"eva-mind/internal/push"
+)
+
+func main() {
+	privateKey, publicKey, err := push.GenerateVAPIDKeys()
+	if err != nil {
+		log.Fatalf("❌ %v", err)
+	}
+
+	fmt.Printf("VAPID_PRIVATE_KEY=%s\n", privateKey)
+	fmt.Printf("# chave pública: %s\n", publicKey)
+}
@@ -0,0 +1,47 @@
+// Stub da WhatsApp Business Cloud API para testar o canal sem rede.
+//
+// Uso:
+//
+//	whatsapp-stub -addr :8090 -webhook http://localhost:8080/api/whatsapp/webhook
+//	WHATSAPP_API_URL=http://localhost:8090/v19.0 ENABLE_WHATSAPP=true ./eva-mind
+//
+// Usa WHATSAPP_TOKEN / WHATSAPP_APP_SECRET do ambiente, então os webhooks chegam
+// com assinatura válida. Para simular o toque no botão "Vi":
+//
+//	curl localhost:8090/messages
+//	curl -X POST localhost:8090/reply -d '{"id": "<wamid>", "payload": "vi"}'
+//
+// Números terminados em 9999 simulam mensagem não entregue.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"eva-mind/internal/whatsapp"
+)
+
+func main() {
+	addr := flag.String("addr", ":8090", "endereço de escuta")
+	token := flag.String("token", envOr("WHATSAPP_TOKEN", "stub-token"), "token Bearer aceito")
+	secret := flag.String("secret", envOr("WHATSAPP_APP_SECRET", "stub-secret"), "app secret usado para assinar os webhooks")
+	webhook := flag.String("webhook", "http://localhost:8080/api/whatsapp/webhook", "URL do webhook do EVA-Mind (vazio desliga)")
+	atraso := flag.Duration("atraso", 2*time.Second, "atraso entre os webhooks de status")
+	flag.Parse()
+
+	stub := whatsapp.NewStub(*token, *secret, *webhook)
+	stub.Delay = *atraso
+
+	log.Printf("💬 WhatsApp stub em %s (webhook %s)", *addr, *webhook)
+	log.Fatal(http.ListenAndServe(*addr, stub))
+}
+
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,187 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ListFilter filtros da listagem de alertas
+type ListFilter struct {
+	CuidadorID int64    // alertas dos idosos deste cuidador
+	IdosoID    int64    // alertas deste idoso
+	Statuses   []string // vazio = todos
+	Limit      int
+}
+
+// List retorna os alertas mais recentes que atendem ao filtro
+func (s *Service) List(ctx context.Context, f ListFilter) ([]*Alert, error) {
+	var conds []string
+	var args []interface{}
+
+	if f.CuidadorID != 0 {
+		args = append(args, f.CuidadorID)
+		conds = append(conds, fmt.Sprintf(
+			"EXISTS (SELECT 1 FROM cuidadores c WHERE c.idoso_id = a.idoso_id AND c.id = $%d AND c.ativo = true)", len(args)))
+	}
+	if f.IdosoID != 0 {
+		args = append(args, f.IdosoID)
+		conds = append(conds, fmt.Sprintf("a.idoso_id = $%d", len(args)))
+	}
+	if len(f.Statuses) > 0 {
+		placeholders := make([]string, len(f.Statuses))
+		for i, st := range f.Statuses {
+			args = append(args, st)
+			placeholders[i] = fmt.Sprintf("$%d", len(args))
+		}
+		conds = append(conds, "a.status IN ("+strings.Join(placeholders, ", ")+")")
+	}
+
+	query := selectAlert
+	if len(conds) > 0 {
+		query += " WHERE " + strings.Join(conds, " AND ")
+	}
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY a.criado_em DESC LIMIT $%d", len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	list := []*Alert{}
+	for rows.Next() {
+		a, err := scanAlert(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan alert: %w", err)
+		}
+		list = append(list, a)
+	}
+
+	return list, rows.Err()
+}
+
+// Pending retorna os alertas ainda abertos visíveis para o cuidador
+func (s *Service) Pending(ctx context.Context, cuidadorID int64) ([]*Alert, error) {
+	return s.List(ctx, ListFilter{CuidadorID: cuidadorID, Statuses: OpenStatuses})
+}
+
+// Acknowledge registra que o cuidador viu o alerta e interrompe o escalonamento
+func (s *Service) Acknowledge(ctx context.Context, alertID, cuidadorID int64, seenAt time.Time, canal string) error {
+	if err := s.CheckCaregiver(ctx, alertID, cuidadorID); err != nil {
+		return err
+	}
+	if seenAt.IsZero() || seenAt.After(time.Now()) {
+		seenAt = time.Now()
+	}
+
+	ev := Event{
+		Canal:      canal,
+		Sucesso:    true,
+		CuidadorID: &cuidadorID,
+		Detalhes:   fmt.Sprintf("visualizado em %s", seenAt.Format(time.RFC3339)),
+	}
+
+	return s.transition(ctx, alertID, StatusReconhecido, ev, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE alertas SET reconhecido_por = $2, data_visualizacao = $3 WHERE id = $1
+		`, alertID, cuidadorID, seenAt)
+		return err
+	})
+}
+
+// Respostas rápidas do cuidador (botões do push)
+const (
+	RespostaVisto    = "visto"
+	RespostaACaminho = "a_caminho"
+)
+
+// Respond trata a resposta rápida do cuidador: "visto" reconhece o alerta e
+// "a_caminho" também registra a nota na trilha. Alerta já reconhecido por
+// outro caminho não é erro: a resposta só confirma o que já aconteceu.
+func (s *Service) Respond(ctx context.Context, alertID, cuidadorID int64, resposta, canal string) error {
+	if resposta != RespostaVisto && resposta != RespostaACaminho {
+		return fmt.Errorf("resposta inválida: %s", resposta)
+	}
+
+	err := s.Acknowledge(ctx, alertID, cuidadorID, time.Now(), canal)
+	var te *TransitionError
+	if err != nil && !errors.As(err, &te) {
+		return err
+	}
+
+	if resposta == RespostaACaminho {
+		return s.AddNote(ctx, alertID, cuidadorID, fmt.Sprintf("Estou a caminho (%s)", canal))
+	}
+	return nil
+}
+
+// Resolve encerra o alerta com uma nota opcional do cuidador
+func (s *Service) Resolve(ctx context.Context, alertID, cuidadorID int64, nota string) error {
+	if err := s.CheckCaregiver(ctx, alertID, cuidadorID); err != nil {
+		return err
+	}
+
+	ev := Event{
+		Sucesso:    true,
+		CuidadorID: &cuidadorID,
+		Detalhes:   nota,
+	}
+
+	return s.transition(ctx, alertID, StatusResolvido, ev, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE alertas SET resolvido_por = $2 WHERE id = $1
+		`, alertID, cuidadorID)
+		return err
+	})
+}
+
+// AddNote anexa uma nota do cuidador à trilha do alerta
+func (s *Service) AddNote(ctx context.Context, alertID, cuidadorID int64, nota string) error {
+	if err := s.CheckCaregiver(ctx, alertID, cuidadorID); err != nil {
+		return err
+	}
+
+	var status string
+	if err := s.db.QueryRowContext(ctx, `SELECT status FROM alertas WHERE id = $1`, alertID).Scan(&status); err != nil {
+		return err
+	}
+
+	return insertEvent(ctx, s.db, alertID, Event{
+		Acao:           AcaoNota,
+		EstadoAnterior: status,
+		EstadoNovo:     status,
+		Sucesso:        true,
+		CuidadorID:     &cuidadorID,
+		Detalhes:       nota,
+	})
+}
+
+// CheckCaregiver garante que o alerta existe e pertence a um idoso do cuidador
+func (s *Service) CheckCaregiver(ctx context.Context, alertID, cuidadorID int64) error {
+	var linked bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM cuidadores c
+			WHERE c.idoso_id = a.idoso_id AND c.id = $2 AND c.ativo = true
+		)
+		FROM alertas a
+		WHERE a.id = $1
+	`, alertID, cuidadorID).Scan(&linked)
+	if err != nil {
+		return err
+	}
+	if !linked {
+		return ErrNotCaregiver
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package alerts
+
+import (
+	"context"
+	"crypto/sha1"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"unicode"
+
+	"eva-mind/internal/webhooks"
+)
+
+// AcaoRepetido e AcaoSuprimido registram repetições agrupadas e envios barrados
+// pelo limite ou pelas preferências; AcaoAdiado, envios guardados para o resumo
+const (
+	AcaoRepetido  = "repetido"
+	AcaoSuprimido = "suprimido"
+	AcaoAdiado    = "adiado"
+)
+
+var severityRank = map[string]int{"aviso": 0, "baixa": 1, "media": 2, "alta": 3, "critica": 4}
+
+// SeverityAtLeast informa se a severidade é igual ou mais grave que min
+func SeverityAtLeast(severidade, min string) bool {
+	return severityRank[severidade] >= severityRank[min]
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "õ", "o", "ö", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// NormalizeReason reduz o motivo a palavras minúsculas sem acentos, números ou pontuação,
+// para que "Dor no peito!" e "dor no peito às 15:04" gerem a mesma chave
+func NormalizeReason(reason string) string {
+	reason = accents.Replace(strings.ToLower(reason))
+
+	fields := strings.FieldsFunc(reason, func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+
+	return strings.Join(fields, " ")
+}
+
+// DedupKey identifica alertas equivalentes do mesmo idoso
+func DedupKey(idosoID int64, tipo, reason string) string {
+	sum := sha1.Sum([]byte(fmt.Sprintf("%d|%s|%s", idosoID, tipo, NormalizeReason(reason))))
+	return hex.EncodeToString(sum[:])
+}
+
+// Raise cria o alerta ou, se já houver um equivalente aberto dentro da janela de
+// deduplicação, agrupa a repetição nele. notify indica se os cuidadores devem
+// ser (re)notificados: falso para repetições, verdadeiro se a severidade subiu.
+func (s *Service) Raise(ctx context.Context, a *Alert) (notify bool, err error) {
+	key := DedupKey(a.IdosoID, a.Tipo, a.Mensagem)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return true, err
+	}
+	defer tx.Rollback()
+
+	// Serializa alertas com a mesma chave (chamadas de ferramenta simultâneas)
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+		return true, err
+	}
+
+	var existingID int64
+	var severidade, status string
+	var ocorrencias int
+
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, COALESCE(severidade, ''), status, COALESCE(ocorrencias, 1)
+		FROM alertas
+		WHERE chave_dedup = $1
+		  AND status IN ('criado', 'despachado', 'entregue', 'escalado')
+		  AND COALESCE(ultima_ocorrencia, criado_em) > NOW() - $2 * INTERVAL '1 second'
+		ORDER BY criado_em DESC
+		LIMIT 1
+		FOR UPDATE
+	`, key, s.dedupWindow.Seconds()).Scan(&existingID, &severidade, &status, &ocorrencias)
+
+	if err == sql.ErrNoRows {
+		if err := insertAlert(ctx, tx, a); err != nil {
+			return true, err
+		}
+		if err := tx.Commit(); err != nil {
+			return true, err
+		}
+		log.Printf("📝 Alerta %d criado (%s, %s)", a.ID, a.Tipo, a.Severidade)
+		s.publish(ctx, webhooks.EventoAlertaCriado, a.ID)
+		return true, nil
+	}
+	if err != nil {
+		return true, fmt.Errorf("failed to look up duplicate alert: %w", err)
+	}
+
+	upgraded := severityRank[a.Severidade] > severityRank[severidade]
+	if !upgraded {
+		a.Severidade = severidade
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE alertas
+		SET ocorrencias = COALESCE(ocorrencias, 1) + 1,
+		    ultima_ocorrencia = NOW(),
+		    severidade = $2
+		WHERE id = $1
+	`, existingID, a.Severidade)
+	if err != nil {
+		return true, fmt.Errorf("failed to merge duplicate alert: %w", err)
+	}
+
+	detalhes := fmt.Sprintf("ocorrência %d: %s", ocorrencias+1, a.Mensagem)
+	if upgraded {
+		detalhes += fmt.Sprintf(" (severidade %s → %s)", severidade, a.Severidade)
+	}
+
+	if err := insertEvent(ctx, tx, existingID, Event{
+		Acao:           AcaoRepetido,
+		EstadoAnterior: status,
+		EstadoNovo:     status,
+		Sucesso:        true,
+		Detalhes:       detalhes,
+	}); err != nil {
+		return true, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return true, err
+	}
+
+	a.ID = existingID
+	a.Status = status
+	a.Ocorrencias = ocorrencias + 1
+
+	log.Printf("🔁 Alerta %d repetido (%d ocorrências)", existingID, a.Ocorrencias)
+	return upgraded, nil
+}
+
+// Throttled informa se o destinatário já atingiu o limite horário de notificações
+// não críticas. Alertas críticos nunca são barrados.
+func (s *Service) Throttled(ctx context.Context, r Recipient, severidade string) (bool, error) {
+	if severidade == "critica" || s.rateLimit <= 0 {
+		return false, nil
+	}
+
+	var sent int
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COUNT(*)
+		FROM historico_alertas h
+		JOIN alertas a ON a.id = h.alerta_id
+		WHERE h.destinatario = $1
+		  AND h.acao = $2
+		  AND h.sucesso = true
+		  AND h.criado_em > NOW() - INTERVAL '1 hour'
+		  AND a.severidade <> 'critica'
+	`, r.Label(), AcaoTentativa).Scan(&sent)
+	if err != nil {
+		return false, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	return sent >= s.rateLimit, nil
+}
+
+// RecordSuppressed registra no histórico que o envio foi barrado pelo limite
+func (s *Service) RecordSuppressed(ctx context.Context, alertID int64, r Recipient, canal string) error {
+	return s.RecordAttempt(ctx, alertID, Event{
+		Acao:         AcaoSuprimido,
+		Canal:        canal,
+		Destinatario: r.Label(),
+		Sucesso:      false,
+		Erro:         fmt.Sprintf("limite de %d notificações por hora atingido", s.rateLimit),
+	})
+}
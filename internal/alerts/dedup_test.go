@@ -0,0 +1,50 @@
+package alerts
+
+import "testing"
+
+func TestNormalizeReason(t *testing.T) {
+	tests := []struct {
+		reason string
+		want   string
+	}{
+		{"Dor no peito!", "dor no peito"},
+		{"dor no peito às 15:04", "dor no peito as"},
+		{"  QUEDA   no banheiro ", "queda no banheiro"},
+		{"Confusão, agitação e tontura", "confusao agitacao e tontura"},
+		{"123 !!", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeReason(tt.reason); got != tt.want {
+			t.Errorf("NormalizeReason(%q) = %q, want %q", tt.reason, got, tt.want)
+		}
+	}
+}
+
+func TestDedupKey(t *testing.T) {
+	base := DedupKey(1, "familia", "Dor no peito!")
+
+	tests := []struct {
+		name    string
+		idosoID int64
+		tipo    string
+		reason  string
+		same    bool
+	}{
+		{"mesmo motivo com outra pontuação", 1, "familia", "dor no peito", true},
+		{"mesmo motivo com acento e horário", 1, "familia", "DOR NO PEITO 10:32", true},
+		{"outro idoso", 2, "familia", "Dor no peito!", false},
+		{"outro tipo", 1, "nao_atende_telefone", "Dor no peito!", false},
+		{"outro motivo", 1, "familia", "Queda no banheiro", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DedupKey(tt.idosoID, tt.tipo, tt.reason)
+			if (got == base) != tt.same {
+				t.Errorf("DedupKey(%d, %q, %q) igual à base = %v, want %v", tt.idosoID, tt.tipo, tt.reason, got == base, tt.same)
+			}
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"eva-mind/internal/config"
+)
+
+// Sender entrega um alerta a um destinatário pelo canal indicado (com fallback,
+// se a implementação suportar) e registra as tentativas no histórico do alerta
+type Sender interface {
+	Send(ctx context.Context, alert *Alert, r Recipient, canal string) error
+}
+
+// ErrDeferred o destinatário está em horário de silêncio ou no modo resumo: o
+// alerta foi para o resumo dele e não conta como entrega
+var ErrDeferred = errors.New("notificação adiada para o resumo do cuidador")
+
+// ChannelUnavailableError o canal pedido não está disponível no sender
+type ChannelUnavailableError struct {
+	Canal string
+}
+
+func (e *ChannelUnavailableError) Error() string {
+	return fmt.Sprintf("canal %s indisponível", e.Canal)
+}
+
+// Escalator executa as políticas de escalonamento dos alertas sem confirmação
+type Escalator struct {
+	cfg    *config.Config
+	db     *sql.DB
+	alerts *Service
+	sender Sender
+}
+
+// NewEscalator cria o executor de políticas de escalonamento
+func NewEscalator(cfg *config.Config, db *sql.DB, sender Sender) *Escalator {
+	return &Escalator{
+		cfg:    cfg,
+		db:     db,
+		alerts: NewService(cfg, db),
+		sender: sender,
+	}
+}
+
+type dueAlert struct {
+	id         int64
+	idosoID    int64
+	severidade string
+	etapa      int
+	agendado   bool
+	base       time.Time
+}
+
+// Run executa a próxima etapa de cada alerta aberto cujo prazo venceu
+func (e *Escalator) Run(ctx context.Context) error {
+	rows, err := e.db.QueryContext(ctx, `
+		SELECT id, idoso_id, COALESCE(severidade, ''), etapa_escalamento,
+		       tempo_escalamento IS NOT NULL, COALESCE(status_atualizado_em, criado_em)
+		FROM alertas
+		WHERE status IN ('criado', 'despachado', 'entregue', 'escalado')
+		  AND necessita_escalamento = true
+		  AND (tempo_escalamento IS NULL OR tempo_escalamento <= NOW())
+		ORDER BY criado_em
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to query alerts for escalation: %w", err)
+	}
+
+	var due []dueAlert
+	for rows.Next() {
+		var d dueAlert
+		if err := rows.Scan(&d.id, &d.idosoID, &d.severidade, &d.etapa, &d.agendado, &d.base); err != nil {
+			log.Printf("❌ Error scanning alert: %v", err)
+			continue
+		}
+		due = append(due, d)
+	}
+	rows.Close()
+
+	for _, d := range due {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err := e.escalate(ctx, d); err != nil {
+			log.Printf("⚠️ Falha ao escalar alerta %d: %v", d.id, err)
+		}
+	}
+
+	return nil
+}
+
+// PolicyFor retorna a política cadastrada ou a padrão da configuração
+func (e *Escalator) PolicyFor(ctx context.Context, idosoID int64, severidade string) (*Policy, error) {
+	p, err := e.alerts.LoadPolicy(ctx, idosoID, severidade)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		p = DefaultPolicy(e.cfg, severidade)
+	}
+	return p, nil
+}
+
+func (e *Escalator) escalate(ctx context.Context, d dueAlert) error {
+	policy, err := e.PolicyFor(ctx, d.idosoID, d.severidade)
+	if err != nil {
+		return err
+	}
+
+	if d.etapa >= len(policy.Etapas) {
+		if len(policy.Etapas) > 0 {
+			log.Printf("⚠️ Alerta %d: política '%s' esgotada sem confirmação", d.id, policy.Nome)
+		}
+		return e.alerts.Disarm(ctx, d.id)
+	}
+
+	step := policy.Etapas[d.etapa]
+
+	// Sem horário explícito, a espera conta a partir da última mudança de estado
+	if !d.agendado && time.Now().Before(d.base.Add(step.Espera)) {
+		return nil
+	}
+
+	alert, err := e.alerts.Get(ctx, d.id)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("🚨 ESCALANDO alerta %d (%s) - etapa %d/%d: %s", alert.ID, alert.NomeIdoso, d.etapa+1, len(policy.Etapas), step)
+
+	recipients, err := e.recipients(ctx, alert, step)
+	if err != nil {
+		return err
+	}
+
+	delivered, deferred := 0, 0
+	for _, r := range recipients {
+		if throttled, err := e.alerts.Throttled(ctx, r, alert.Severidade); err == nil && throttled {
+			if err := e.alerts.RecordSuppressed(ctx, alert.ID, r, step.Canal); err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+			continue
+		}
+
+		err := e.sender.Send(ctx, alert, r, step.Canal)
+		switch {
+		case errors.Is(err, ErrDeferred):
+			deferred++
+			continue
+		case err != nil:
+			log.Printf("❌ Alerta %d: falha ao notificar %s: %v", alert.ID, r.Label(), err)
+			continue
+		}
+		delivered++
+	}
+
+	var next *time.Time
+	if d.etapa+1 < len(policy.Etapas) {
+		t := time.Now().Add(policy.Etapas[d.etapa+1].Espera)
+		next = &t
+	}
+
+	ev := Event{
+		Canal:    step.Canal,
+		Sucesso:  delivered > 0,
+		Detalhes: fmt.Sprintf("política '%s', etapa %d/%d: %s (%d de %d destinatário(s))", policy.Nome, d.etapa+1, len(policy.Etapas), step, delivered, len(recipients)),
+	}
+	if deferred > 0 {
+		ev.Detalhes += fmt.Sprintf(", %d adiado(s) para o resumo", deferred)
+	}
+	if len(recipients) == 0 {
+		ev.Erro = "nenhum destinatário para a etapa"
+	}
+
+	return e.alerts.advance(ctx, alert.ID, d.etapa+1, policy.ID, next, ev)
+}
+
+// recipients resolve o alvo da etapa em destinatários concretos
+func (e *Escalator) recipients(ctx context.Context, alert *Alert, step Step) ([]Recipient, error) {
+	switch step.Alvo {
+	case AlvoCentral:
+		r := Recipient{Nome: "central de monitoramento"}
+		switch {
+		case strings.HasPrefix(step.Destino, "http"):
+			r.URL = step.Destino
+		case strings.Contains(step.Destino, "@"):
+			r.Email = step.Destino
+		default:
+			r.Telefone = step.Destino
+		}
+		return []Recipient{r}, nil
+
+	case AlvoContatoEmergencia:
+		return e.alerts.EmergencyContacts(ctx, alert.IdosoID)
+
+	case AlvoCuidadorPrioridade:
+		return e.alerts.Caregivers(ctx, alert.IdosoID, step.Prioridade)
+
+	default:
+		return e.alerts.Caregivers(ctx, alert.IdosoID, 0)
+	}
+}
@@ -0,0 +1,72 @@
+package alerts
+
+import (
+	"fmt"
+	"time"
+)
+
+// Estados do ciclo de vida de um alerta
+const (
+	StatusCriado      = "criado"
+	StatusDespachado  = "despachado"
+	StatusEntregue    = "entregue"
+	StatusReconhecido = "reconhecido"
+	StatusEscalado    = "escalado"
+	StatusResolvido   = "resolvido"
+	StatusExpirado    = "expirado"
+)
+
+// transitions estados de destino permitidos a partir de cada estado
+var transitions = map[string][]string{
+	StatusCriado:      {StatusDespachado, StatusEscalado, StatusReconhecido, StatusResolvido, StatusExpirado},
+	StatusDespachado:  {StatusEntregue, StatusEscalado, StatusReconhecido, StatusResolvido, StatusExpirado},
+	StatusEntregue:    {StatusEscalado, StatusReconhecido, StatusResolvido, StatusExpirado},
+	StatusEscalado:    {StatusEscalado, StatusEntregue, StatusReconhecido, StatusResolvido, StatusExpirado},
+	StatusReconhecido: {StatusResolvido},
+}
+
+// OpenStatuses estados em que o alerta ainda aguarda alguém da família
+var OpenStatuses = []string{StatusCriado, StatusDespachado, StatusEntregue, StatusEscalado}
+
+// TransitionError transição inválida entre dois estados
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("transição de alerta inválida: %s → %s", e.From, e.To)
+}
+
+// CanTransition informa se o alerta pode passar de from para to
+func CanTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsOpen informa se o alerta ainda não foi reconhecido nem encerrado
+func IsOpen(status string) bool {
+	for _, s := range OpenStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// legacyColumns mantém as flags antigas de alertas coerentes com o estado
+var legacyColumns = map[string]string{
+	StatusDespachado:  "enviado = true, data_envio = COALESCE(data_envio, NOW())",
+	StatusEntregue:    "enviado = true, data_envio = COALESCE(data_envio, NOW())",
+	StatusEscalado:    "tentativas_envio = COALESCE(tentativas_envio, 0) + 1, ultima_tentativa = NOW()",
+	StatusReconhecido: "visualizado = true, data_visualizacao = COALESCE(data_visualizacao, NOW()), necessita_escalamento = false",
+	StatusResolvido:   "necessita_escalamento = false, resolvido_em = NOW()",
+	StatusExpirado:    "necessita_escalamento = false",
+}
+
+// DefaultExpiry tempo máximo que um alerta fica aberto sem confirmação
+const DefaultExpiry = 24 * time.Hour
@@ -0,0 +1,62 @@
+package alerts
+
+import "testing"
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		want     bool
+	}{
+		{StatusCriado, StatusDespachado, true},
+		{StatusCriado, StatusReconhecido, true},
+		{StatusDespachado, StatusEntregue, true},
+		{StatusEntregue, StatusEscalado, true},
+		{StatusEscalado, StatusEscalado, true},
+		{StatusEscalado, StatusEntregue, true},
+		{StatusReconhecido, StatusResolvido, true},
+		{StatusCriado, StatusEntregue, false},
+		{StatusEntregue, StatusDespachado, false},
+		{StatusReconhecido, StatusEscalado, false},
+		{StatusResolvido, StatusCriado, false},
+		{StatusExpirado, StatusResolvido, false},
+		{"desconhecido", StatusResolvido, false},
+	}
+
+	for _, tt := range tests {
+		if got := CanTransition(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransition(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestTransitionsMap(t *testing.T) {
+	known := map[string]bool{
+		StatusCriado: true, StatusDespachado: true, StatusEntregue: true, StatusReconhecido: true,
+		StatusEscalado: true, StatusResolvido: true, StatusExpirado: true,
+	}
+
+	for from, targets := range transitions {
+		if !known[from] {
+			t.Errorf("estado de origem desconhecido: %s", from)
+		}
+		for _, to := range targets {
+			if !known[to] {
+				t.Errorf("%s → %s: estado de destino desconhecido", from, to)
+			}
+		}
+	}
+
+	// Estados finais não saem para lugar nenhum
+	for _, s := range []string{StatusResolvido, StatusExpirado} {
+		if len(transitions[s]) != 0 {
+			t.Errorf("%s deveria ser final, tem %v", s, transitions[s])
+		}
+	}
+
+	// Todo estado aberto pode ser reconhecido e resolvido
+	for _, s := range OpenStatuses {
+		if !CanTransition(s, StatusReconhecido) || !CanTransition(s, StatusResolvido) {
+			t.Errorf("estado aberto %s deveria aceitar reconhecido e resolvido", s)
+		}
+	}
+}
@@ -0,0 +1,235 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"eva-mind/internal/config"
+)
+
+// Alvos de uma etapa de escalonamento
+const (
+	AlvoCuidadorPrioridade = "cuidador_prioridade"
+	AlvoTodosCuidadores    = "todos_cuidadores"
+	AlvoContatoEmergencia  = "contato_emergencia"
+	AlvoCentral            = "central_monitoramento"
+)
+
+// Canais de envio
+const (
+	CanalPush     = "push"
+	CanalSMS      = "sms"
+	CanalEmail    = "email"
+	CanalLigacao  = "ligacao"
+	CanalWhatsApp = "whatsapp"
+	CanalWebhook  = "webhook"
+)
+
+var (
+	validTargets    = map[string]bool{AlvoCuidadorPrioridade: true, AlvoTodosCuidadores: true, AlvoContatoEmergencia: true, AlvoCentral: true}
+	validChannels   = map[string]bool{CanalPush: true, CanalSMS: true, CanalEmail: true, CanalLigacao: true, CanalWhatsApp: true, CanalWebhook: true}
+	validSeverities = map[string]bool{"critica": true, "alta": true, "media": true, "baixa": true, "aviso": true}
+)
+
+// Step etapa de uma política de escalonamento
+type Step struct {
+	Ordem      int           `json:"ordem"`
+	Alvo       string        `json:"alvo"`
+	Prioridade int           `json:"prioridade,omitempty"`
+	Destino    string        `json:"destino,omitempty"`
+	Canal      string        `json:"canal"`
+	Espera     time.Duration `json:"-"`
+	EsperaMin  int           `json:"espera_minutos"`
+}
+
+// String descreve a etapa para logs e histórico
+func (st Step) String() string {
+	switch st.Alvo {
+	case AlvoCuidadorPrioridade:
+		return fmt.Sprintf("cuidador prioridade %d via %s", st.Prioridade, st.Canal)
+	case AlvoCentral:
+		return fmt.Sprintf("central de monitoramento via %s", st.Canal)
+	default:
+		return fmt.Sprintf("%s via %s", st.Alvo, st.Canal)
+	}
+}
+
+// Policy política de escalonamento de uma severidade
+type Policy struct {
+	ID         int64  `json:"id"` // 0 = política padrão da configuração
+	Nome       string `json:"nome"`
+	IdosoID    *int64 `json:"idoso_id,omitempty"`
+	Entidade   string `json:"entidade_nome,omitempty"`
+	Severidade string `json:"severidade"`
+	Etapas     []Step `json:"etapas"`
+}
+
+// Validate verifica severidade, alvos, canais e esperas da política
+func (p *Policy) Validate() error {
+	if !validSeverities[p.Severidade] {
+		return fmt.Errorf("severidade inválida: %s", p.Severidade)
+	}
+	if p.IdosoID != nil && p.Entidade != "" {
+		return fmt.Errorf("a política vale para um idoso ou para uma entidade, não ambos")
+	}
+
+	for i, st := range p.Etapas {
+		if !validTargets[st.Alvo] {
+			return fmt.Errorf("etapa %d: alvo inválido: %s", i+1, st.Alvo)
+		}
+		if !validChannels[st.Canal] {
+			return fmt.Errorf("etapa %d: canal inválido: %s", i+1, st.Canal)
+		}
+		if st.Alvo == AlvoCuidadorPrioridade && st.Prioridade <= 0 {
+			return fmt.Errorf("etapa %d: prioridade do cuidador obrigatória", i+1)
+		}
+		if st.Alvo == AlvoCentral && st.Destino == "" {
+			return fmt.Errorf("etapa %d: destino da central obrigatório", i+1)
+		}
+		if st.EsperaMin < 0 {
+			return fmt.Errorf("etapa %d: espera negativa", i+1)
+		}
+	}
+
+	return nil
+}
+
+// DefaultPolicy política usada quando não há nenhuma cadastrada
+func DefaultPolicy(cfg *config.Config, severidade string) *Policy {
+	p := &Policy{Nome: "padrão", Severidade: severidade}
+
+	switch severidade {
+	case "critica":
+		p.Etapas = []Step{
+			{Alvo: AlvoTodosCuidadores, Canal: CanalPush, EsperaMin: cfg.AlertEscalationTime},
+			{Alvo: AlvoCuidadorPrioridade, Prioridade: 1, Canal: CanalLigacao, EsperaMin: cfg.CriticalAlertTimeout},
+			{Alvo: AlvoContatoEmergencia, Canal: CanalLigacao, EsperaMin: cfg.CriticalAlertTimeout},
+		}
+	case "alta":
+		p.Etapas = []Step{
+			{Alvo: AlvoTodosCuidadores, Canal: CanalPush, EsperaMin: cfg.AlertEscalationTime},
+			{Alvo: AlvoCuidadorPrioridade, Prioridade: 1, Canal: CanalSMS, EsperaMin: 2 * cfg.AlertEscalationTime},
+		}
+	}
+
+	for i := range p.Etapas {
+		p.Etapas[i].Ordem = i + 1
+		p.Etapas[i].Espera = time.Duration(p.Etapas[i].EsperaMin) * time.Minute
+	}
+
+	return p
+}
+
+// LoadPolicy retorna a política cadastrada mais específica (idoso > entidade > global)
+// para a severidade, ou nil se não houver nenhuma
+func (s *Service) LoadPolicy(ctx context.Context, idosoID int64, severidade string) (*Policy, error) {
+	var p Policy
+	var policyIdoso sql.NullInt64
+	var entidade sql.NullString
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT p.id, p.nome, p.idoso_id, p.entidade_nome, p.severidade
+		FROM politicas_escalonamento p
+		JOIN idosos i ON i.id = $1
+		WHERE p.ativa = true
+		  AND p.severidade = $2
+		  AND (
+			p.idoso_id = i.id
+			OR (p.idoso_id IS NULL AND p.entidade_nome = i.entidade_nome)
+			OR (p.idoso_id IS NULL AND p.entidade_nome IS NULL)
+		  )
+		ORDER BY (p.idoso_id IS NOT NULL) DESC, (p.entidade_nome IS NOT NULL) DESC
+		LIMIT 1
+	`, idosoID, severidade).Scan(&p.ID, &p.Nome, &policyIdoso, &entidade, &p.Severidade)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load escalation policy: %w", err)
+	}
+
+	p.IdosoID = nullInt64(policyIdoso)
+	p.Entidade = entidade.String
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT ordem, alvo, COALESCE(prioridade, 0), COALESCE(destino, ''), canal, espera_minutos
+		FROM politica_etapas
+		WHERE politica_id = $1
+		ORDER BY ordem
+	`, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load escalation steps: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var st Step
+		if err := rows.Scan(&st.Ordem, &st.Alvo, &st.Prioridade, &st.Destino, &st.Canal, &st.EsperaMin); err != nil {
+			return nil, fmt.Errorf("failed to scan escalation step: %w", err)
+		}
+		st.Espera = time.Duration(st.EsperaMin) * time.Minute
+		p.Etapas = append(p.Etapas, st)
+	}
+
+	return &p, rows.Err()
+}
+
+// SavePolicy cadastra a política, substituindo a ativa do mesmo escopo e severidade
+func (s *Service) SavePolicy(ctx context.Context, p *Policy) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var entidade interface{}
+	if p.Entidade != "" {
+		entidade = p.Entidade
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE politicas_escalonamento SET ativa = false
+		WHERE ativa = true
+		  AND severidade = $1
+		  AND COALESCE(idoso_id, 0) = COALESCE($2::int, 0)
+		  AND COALESCE(entidade_nome, '') = COALESCE($3::text, '')
+	`, p.Severidade, p.IdosoID, entidade)
+	if err != nil {
+		return fmt.Errorf("failed to replace escalation policy: %w", err)
+	}
+
+	if p.Nome == "" {
+		p.Nome = "política " + p.Severidade
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO politicas_escalonamento (nome, idoso_id, entidade_nome, severidade)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, p.Nome, p.IdosoID, entidade, p.Severidade).Scan(&p.ID)
+	if err != nil {
+		return fmt.Errorf("failed to save escalation policy: %w", err)
+	}
+
+	for i := range p.Etapas {
+		st := &p.Etapas[i]
+		st.Ordem = i + 1
+		st.Espera = time.Duration(st.EsperaMin) * time.Minute
+
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO politica_etapas (politica_id, ordem, alvo, prioridade, destino, canal, espera_minutos)
+			VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, ''), $6, $7)
+		`, p.ID, st.Ordem, st.Alvo, st.Prioridade, st.Destino, st.Canal, st.EsperaMin)
+		if err != nil {
+			return fmt.Errorf("failed to save escalation step: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
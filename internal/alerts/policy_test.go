@@ -0,0 +1,79 @@
+package alerts
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyValidate(t *testing.T) {
+	idoso := int64(7)
+
+	tests := []struct {
+		name    string
+		policy  Policy
+		wantErr string
+	}{
+		{
+			name: "válida",
+			policy: Policy{Severidade: "critica", Etapas: []Step{
+				{Alvo: AlvoTodosCuidadores, Canal: CanalPush, EsperaMin: 5},
+				{Alvo: AlvoCuidadorPrioridade, Prioridade: 1, Canal: CanalLigacao, EsperaMin: 10},
+				{Alvo: AlvoCentral, Destino: "https://central.exemplo/alertas", Canal: CanalWebhook},
+			}},
+		},
+		{
+			name:   "sem etapas",
+			policy: Policy{Severidade: "alta"},
+		},
+		{
+			name:    "severidade inválida",
+			policy:  Policy{Severidade: "urgente"},
+			wantErr: "severidade inválida",
+		},
+		{
+			name:    "idoso e entidade",
+			policy:  Policy{Severidade: "alta", IdosoID: &idoso, Entidade: "Casa de Repouso"},
+			wantErr: "não ambos",
+		},
+		{
+			name:    "alvo inválido",
+			policy:  Policy{Severidade: "alta", Etapas: []Step{{Alvo: "vizinho", Canal: CanalSMS}}},
+			wantErr: "etapa 1: alvo inválido",
+		},
+		{
+			name: "canal inválido",
+			policy: Policy{Severidade: "alta", Etapas: []Step{
+				{Alvo: AlvoTodosCuidadores, Canal: CanalPush},
+				{Alvo: AlvoTodosCuidadores, Canal: "pombo"},
+			}},
+			wantErr: "etapa 2: canal inválido",
+		},
+		{
+			name:    "cuidador sem prioridade",
+			policy:  Policy{Severidade: "alta", Etapas: []Step{{Alvo: AlvoCuidadorPrioridade, Canal: CanalSMS}}},
+			wantErr: "prioridade do cuidador obrigatória",
+		},
+		{
+			name:    "central sem destino",
+			policy:  Policy{Severidade: "alta", Etapas: []Step{{Alvo: AlvoCentral, Canal: CanalLigacao}}},
+			wantErr: "destino da central obrigatório",
+		},
+		{
+			name:    "espera negativa",
+			policy:  Policy{Severidade: "alta", Etapas: []Step{{Alvo: AlvoContatoEmergencia, Canal: CanalSMS, EsperaMin: -1}}},
+			wantErr: "espera negativa",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("Validate() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("Validate() = %v, want erro com %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,130 @@
+package alerts
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Recipient destinatário de um alerta (cuidador, contato de emergência ou central)
+type Recipient struct {
+	CuidadorID      int64
+	ContatoID       int64
+	Nome            string
+	Telefone        string
+	Email           string
+	DeviceToken     string
+	DeviceTokens    []string // tokens FCM de todos os aparelhos válidos (Android/iOS)
+	WebPush         []string // assinaturas Web Push do painel web
+	URL             string
+	MetodoPreferido string
+}
+
+// Label identifica o destinatário no histórico do alerta
+func (r Recipient) Label() string {
+	switch {
+	case r.CuidadorID != 0:
+		return fmt.Sprintf("cuidador %d", r.CuidadorID)
+	case r.ContatoID != 0:
+		return fmt.Sprintf("contato %d", r.ContatoID)
+	default:
+		return r.Nome
+	}
+}
+
+// Tokens tokens de push do destinatário: todos os dispositivos ou, sem eles, o device_token
+func (r Recipient) Tokens() []string {
+	if len(r.DeviceTokens) > 0 {
+		return r.DeviceTokens
+	}
+	if r.DeviceToken != "" {
+		return []string{r.DeviceToken}
+	}
+	return nil
+}
+
+// caregiverDevices tokens FCM válidos dos aparelhos do cuidador c
+const caregiverDevices = `ARRAY(
+	SELECT d.token FROM dispositivos d
+	WHERE d.tipo = 'cuidador' AND d.pessoa_id = c.id AND d.valido AND d.plataforma <> 'web'
+	ORDER BY d.ultimo_registro_em DESC
+)`
+
+// caregiverWebPush assinaturas Web Push válidas do cuidador c
+const caregiverWebPush = `ARRAY(
+	SELECT d.token FROM dispositivos d
+	WHERE d.tipo = 'cuidador' AND d.pessoa_id = c.id AND d.valido AND d.plataforma = 'web'
+	ORDER BY d.ultimo_registro_em DESC
+)`
+
+// Caregivers retorna os cuidadores ativos do idoso (prioridade 0 = todos). O método
+// preferido vem do contato de emergência com o mesmo telefone ou email. Tokens
+// marcados como inválidos pelo FCM voltam vazios.
+func (s *Service) Caregivers(ctx context.Context, idosoID int64, prioridade int) ([]Recipient, error) {
+	return s.queryRecipients(ctx, `
+		SELECT c.id, 0, '', COALESCE(c.telefone, ''), COALESCE(c.email, ''),
+		       CASE WHEN COALESCE(c.device_token_valido, true) THEN COALESCE(c.device_token, '') ELSE '' END,
+		       `+caregiverDevices+`, `+caregiverWebPush+`, COALESCE(pref.metodo_preferido, '')
+		FROM cuidadores c
+		LEFT JOIN LATERAL (
+			SELECT ce.metodo_preferido
+			FROM contatos_emergencia ce
+			WHERE ce.idoso_id = c.idoso_id
+			  AND ((ce.telefone <> '' AND ce.telefone = c.telefone) OR (ce.email <> '' AND ce.email = c.email))
+			ORDER BY ce.prioridade ASC
+			LIMIT 1
+		) pref ON true
+		WHERE c.idoso_id = $1 AND c.ativo = true
+		  AND ($2 = 0 OR c.prioridade = $2)
+		ORDER BY c.prioridade ASC
+	`, idosoID, prioridade)
+}
+
+// Caregiver retorna um cuidador pelo id (ativo ou não)
+func (s *Service) Caregiver(ctx context.Context, cuidadorID int64) (*Recipient, error) {
+	list, err := s.queryRecipients(ctx, `
+		SELECT c.id, 0, '', COALESCE(c.telefone, ''), COALESCE(c.email, ''),
+		       CASE WHEN COALESCE(c.device_token_valido, true) THEN COALESCE(c.device_token, '') ELSE '' END,
+		       `+caregiverDevices+`, `+caregiverWebPush+`, ''
+		FROM cuidadores c
+		WHERE c.id = $1
+	`, cuidadorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("caregiver %d not found", cuidadorID)
+	}
+	return &list[0], nil
+}
+
+// EmergencyContacts retorna os contatos de emergência do idoso por prioridade
+func (s *Service) EmergencyContacts(ctx context.Context, idosoID int64) ([]Recipient, error) {
+	return s.queryRecipients(ctx, `
+		SELECT 0, id, COALESCE(nome, ''), COALESCE(telefone, ''), COALESCE(email, ''),
+		       COALESCE(device_token, ''), '{}'::text[], '{}'::text[], COALESCE(metodo_preferido, '')
+		FROM contatos_emergencia
+		WHERE idoso_id = $1
+		ORDER BY prioridade ASC
+	`, idosoID)
+}
+
+func (s *Service) queryRecipients(ctx context.Context, query string, args ...interface{}) ([]Recipient, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Recipient
+	for rows.Next() {
+		var r Recipient
+		if err := rows.Scan(&r.CuidadorID, &r.ContatoID, &r.Nome, &r.Telefone, &r.Email, &r.DeviceToken, pq.Array(&r.DeviceTokens), pq.Array(&r.WebPush), &r.MetodoPreferido); err != nil {
+			return nil, fmt.Errorf("failed to scan recipient: %w", err)
+		}
+		list = append(list, r)
+	}
+
+	return list, rows.Err()
+}
@@ -0,0 +1,417 @@
+package alerts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/webhooks"
+)
+
+// Ações registradas em historico_alertas além das transições de estado
+const (
+	AcaoTentativa = "tentativa_envio"
+	AcaoNota      = "nota"
+)
+
+// ErrNotCaregiver o cuidador não está vinculado ao idoso do alerta
+var ErrNotCaregiver = errors.New("cuidador não vinculado ao idoso do alerta")
+
+// Alert alerta registrado em alertas
+type Alert struct {
+	ID                 int64      `json:"id"`
+	IdosoID            int64      `json:"idoso_id"`
+	NomeIdoso          string     `json:"nome_idoso,omitempty"`
+	TelefoneIdoso      string     `json:"telefone_idoso,omitempty"`
+	LigacaoID          *int64     `json:"ligacao_id,omitempty"`
+	Tipo               string     `json:"tipo"`
+	Severidade         string     `json:"severidade"`
+	Mensagem           string     `json:"mensagem"`
+	Ocorrencias        int        `json:"ocorrencias"`
+	Destinatarios      string     `json:"-"`
+	Status             string     `json:"status"`
+	TentativasEnvio    int        `json:"tentativas_envio"`
+	CriadoEm           time.Time  `json:"criado_em"`
+	StatusAtualizadoEm *time.Time `json:"status_atualizado_em,omitempty"`
+	DataVisualizacao   *time.Time `json:"visualizado_em,omitempty"`
+	ReconhecidoPor     *int64     `json:"reconhecido_por,omitempty"`
+	ResolvidoEm        *time.Time `json:"resolvido_em,omitempty"`
+	ResolvidoPor       *int64     `json:"resolvido_por,omitempty"`
+}
+
+// Event linha da trilha de auditoria (transição ou tentativa de envio)
+type Event struct {
+	ID             int64     `json:"id"`
+	AlertaID       int64     `json:"alerta_id"`
+	Acao           string    `json:"acao"`
+	EstadoAnterior string    `json:"estado_anterior,omitempty"`
+	EstadoNovo     string    `json:"estado_novo,omitempty"`
+	Canal          string    `json:"canal,omitempty"`
+	Destinatario   string    `json:"destinatario,omitempty"`
+	Sucesso        bool      `json:"sucesso"`
+	Erro           string    `json:"erro,omitempty"`
+	Detalhes       string    `json:"detalhes,omitempty"`
+	CuidadorID     *int64    `json:"cuidador_id,omitempty"`
+	CriadoEm       time.Time `json:"criado_em"`
+}
+
+// Service centraliza as mudanças de estado dos alertas
+type Service struct {
+	db          *sql.DB
+	hooks       *webhooks.Service
+	dedupWindow time.Duration
+	rateLimit   int
+}
+
+// NewService cria o serviço de alertas. cfg pode ser nil (limites padrão).
+func NewService(cfg *config.Config, db *sql.DB) *Service {
+	s := &Service{
+		db:          db,
+		hooks:       webhooks.NewService(db),
+		dedupWindow: 30 * time.Minute,
+		rateLimit:   5,
+	}
+	if cfg != nil {
+		s.dedupWindow = time.Duration(cfg.AlertDedupWindow) * time.Minute
+		s.rateLimit = cfg.AlertRateLimit
+	}
+	return s
+}
+
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// Create registra um novo alerta no estado criado
+func (s *Service) Create(ctx context.Context, a *Alert) (int64, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if err := insertAlert(ctx, tx, a); err != nil {
+		return 0, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	log.Printf("📝 Alerta %d criado (%s, %s)", a.ID, a.Tipo, a.Severidade)
+	s.publish(ctx, webhooks.EventoAlertaCriado, a.ID)
+	return a.ID, nil
+}
+
+// publish envia o alerta às integrações da entidade; falhas não afetam o alerta
+func (s *Service) publish(ctx context.Context, evento string, alertID int64) {
+	alert, err := s.Get(ctx, alertID)
+	if err == nil {
+		err = s.hooks.Publish(ctx, alert.IdosoID, evento, alert)
+	}
+	if err != nil {
+		log.Printf("⚠️ Webhook %s do alerta %d: %v", evento, alertID, err)
+	}
+}
+
+func insertAlert(ctx context.Context, tx *sql.Tx, a *Alert) error {
+	if a.Destinatarios == "" {
+		a.Destinatarios = `["cuidador"]`
+	}
+
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO alertas (
+			idoso_id, ligacao_id, tipo, severidade, mensagem, destinatarios,
+			enviado, visualizado, status, status_atualizado_em,
+			chave_dedup, ocorrencias, ultima_ocorrencia, criado_em
+		) VALUES ($1, $2, $3, $4, $5, $6, false, false, $7, NOW(), $8, 1, NOW(), NOW())
+		RETURNING id, criado_em
+	`, a.IdosoID, a.LigacaoID, a.Tipo, a.Severidade, a.Mensagem, a.Destinatarios, StatusCriado,
+		DedupKey(a.IdosoID, a.Tipo, a.Mensagem)).Scan(&a.ID, &a.CriadoEm)
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+	a.Status = StatusCriado
+	a.Ocorrencias = 1
+
+	return insertEvent(ctx, tx, a.ID, Event{
+		Acao:       StatusCriado,
+		EstadoNovo: StatusCriado,
+		Sucesso:    true,
+		Detalhes:   fmt.Sprintf("%s (%s)", a.Tipo, a.Severidade),
+	})
+}
+
+// Transition muda o estado do alerta validando a transição e registra no histórico
+func (s *Service) Transition(ctx context.Context, alertID int64, to string, ev Event) error {
+	return s.transition(ctx, alertID, to, ev, nil)
+}
+
+// transition executa a transição; apply roda na mesma transação (colunas extras do estado)
+func (s *Service) transition(ctx context.Context, alertID int64, to string, ev Event, apply func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var from string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM alertas WHERE id = $1 FOR UPDATE`, alertID).Scan(&from)
+	if err != nil {
+		return fmt.Errorf("failed to load alert %d: %w", alertID, err)
+	}
+
+	if !CanTransition(from, to) {
+		return &TransitionError{From: from, To: to}
+	}
+
+	set := "status = $2, status_atualizado_em = NOW()"
+	if extra, ok := legacyColumns[to]; ok {
+		set += ", " + extra
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE alertas SET `+set+` WHERE id = $1`, alertID, to); err != nil {
+		return fmt.Errorf("failed to update alert %d: %w", alertID, err)
+	}
+
+	if apply != nil {
+		if err := apply(tx); err != nil {
+			return err
+		}
+	}
+
+	if ev.Acao == "" {
+		ev.Acao = to
+	}
+	ev.EstadoAnterior = from
+	ev.EstadoNovo = to
+	if err := insertEvent(ctx, tx, alertID, ev); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	if to == StatusReconhecido {
+		s.publish(ctx, webhooks.EventoAlertaReconhecido, alertID)
+	}
+	return nil
+}
+
+// RecordAttempt registra uma tentativa de envio sem mudar o estado
+func (s *Service) RecordAttempt(ctx context.Context, alertID int64, ev Event) error {
+	if ev.Acao == "" {
+		ev.Acao = AcaoTentativa
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE alertas SET ultima_tentativa = NOW() WHERE id = $1 RETURNING status
+	`, alertID).Scan(&status)
+	if err != nil {
+		return fmt.Errorf("failed to load alert %d: %w", alertID, err)
+	}
+
+	ev.EstadoAnterior = status
+	ev.EstadoNovo = status
+	if err := insertEvent(ctx, tx, alertID, ev); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ScheduleEscalation agenda a próxima verificação de escalonamento
+func (s *Service) ScheduleEscalation(ctx context.Context, alertID int64, at time.Time) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE alertas
+		SET necessita_escalamento = true, tempo_escalamento = $2
+		WHERE id = $1
+	`, alertID, at)
+	if err != nil {
+		return fmt.Errorf("failed to schedule escalation for alert %d: %w", alertID, err)
+	}
+	return nil
+}
+
+// Arm liga o escalonamento do alerta; os prazos vêm da política da severidade
+func (s *Service) Arm(ctx context.Context, alertID int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE alertas
+		SET necessita_escalamento = true, tempo_escalamento = NULL
+		WHERE id = $1
+	`, alertID)
+	if err != nil {
+		return fmt.Errorf("failed to arm escalation for alert %d: %w", alertID, err)
+	}
+	return nil
+}
+
+// Disarm encerra o escalonamento (política esgotada ou sem etapas)
+func (s *Service) Disarm(ctx context.Context, alertID int64) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE alertas SET necessita_escalamento = false WHERE id = $1
+	`, alertID)
+	return err
+}
+
+// advance registra a etapa executada e agenda a próxima (next nil = última etapa)
+func (s *Service) advance(ctx context.Context, alertID int64, etapa int, politicaID int64, next *time.Time, ev Event) error {
+	return s.transition(ctx, alertID, StatusEscalado, ev, func(tx *sql.Tx) error {
+		var politica interface{}
+		if politicaID != 0 {
+			politica = politicaID
+		}
+		_, err := tx.ExecContext(ctx, `
+			UPDATE alertas
+			SET etapa_escalamento = $2,
+			    politica_id = $3,
+			    tempo_escalamento = $4,
+			    necessita_escalamento = $5
+			WHERE id = $1
+		`, alertID, etapa, politica, next, next != nil)
+		return err
+	})
+}
+
+// ExpireStale expira alertas abertos criados há mais de maxAge
+func (s *Service) ExpireStale(ctx context.Context, maxAge time.Duration) (int, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM alertas
+		WHERE status IN ('criado', 'despachado', 'entregue', 'escalado')
+		  AND criado_em < $1
+	`, time.Now().Add(-maxAge))
+	if err != nil {
+		return 0, fmt.Errorf("failed to query stale alerts: %w", err)
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	expired := 0
+	for _, id := range ids {
+		err := s.Transition(ctx, id, StatusExpirado, Event{
+			Sucesso:  false,
+			Detalhes: fmt.Sprintf("sem confirmação após %s", maxAge),
+		})
+		if err != nil {
+			log.Printf("⚠️ Falha ao expirar alerta %d: %v", id, err)
+			continue
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// Get retorna um alerta pelo id
+func (s *Service) Get(ctx context.Context, alertID int64) (*Alert, error) {
+	return scanAlert(s.db.QueryRowContext(ctx, selectAlert+` WHERE a.id = $1`, alertID))
+}
+
+const selectAlert = `
+	SELECT a.id, a.idoso_id, i.nome, COALESCE(i.telefone, ''), a.ligacao_id, COALESCE(a.tipo, ''), COALESCE(a.severidade, ''),
+	       COALESCE(a.mensagem, ''), COALESCE(a.ocorrencias, 1), a.status, COALESCE(a.tentativas_envio, 0), a.criado_em,
+	       a.status_atualizado_em, a.data_visualizacao, a.reconhecido_por, a.resolvido_em, a.resolvido_por
+	FROM alertas a
+	JOIN idosos i ON i.id = a.idoso_id`
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanAlert(row scanner) (*Alert, error) {
+	var a Alert
+	var ligacaoID, reconhecidoPor, resolvidoPor sql.NullInt64
+	var statusEm, visualizadoEm, resolvidoEm sql.NullTime
+
+	err := row.Scan(&a.ID, &a.IdosoID, &a.NomeIdoso, &a.TelefoneIdoso, &ligacaoID, &a.Tipo, &a.Severidade, &a.Mensagem,
+		&a.Ocorrencias, &a.Status, &a.TentativasEnvio, &a.CriadoEm, &statusEm, &visualizadoEm,
+		&reconhecidoPor, &resolvidoEm, &resolvidoPor)
+	if err != nil {
+		return nil, err
+	}
+
+	a.LigacaoID = nullInt64(ligacaoID)
+	a.ReconhecidoPor = nullInt64(reconhecidoPor)
+	a.ResolvidoPor = nullInt64(resolvidoPor)
+	a.StatusAtualizadoEm = nullTime(statusEm)
+	a.DataVisualizacao = nullTime(visualizadoEm)
+	a.ResolvidoEm = nullTime(resolvidoEm)
+
+	return &a, nil
+}
+
+func nullInt64(v sql.NullInt64) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+func nullTime(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Time
+}
+
+// History retorna a trilha de auditoria do alerta em ordem cronológica
+func (s *Service) History(ctx context.Context, alertID int64) ([]Event, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, alerta_id, acao, COALESCE(estado_anterior, ''), COALESCE(estado_novo, ''),
+		       COALESCE(metodo, ''), COALESCE(destinatario, ''), sucesso,
+		       COALESCE(erro, ''), COALESCE(detalhes, ''), cuidador_id, criado_em
+		FROM historico_alertas
+		WHERE alerta_id = $1
+		ORDER BY criado_em, id
+	`, alertID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert history: %w", err)
+	}
+	defer rows.Close()
+
+	var events []Event
+	for rows.Next() {
+		var ev Event
+		var cuidadorID sql.NullInt64
+		if err := rows.Scan(&ev.ID, &ev.AlertaID, &ev.Acao, &ev.EstadoAnterior, &ev.EstadoNovo,
+			&ev.Canal, &ev.Destinatario, &ev.Sucesso, &ev.Erro, &ev.Detalhes, &cuidadorID, &ev.CriadoEm); err != nil {
+			return nil, fmt.Errorf("failed to scan alert history: %w", err)
+		}
+		ev.CuidadorID = nullInt64(cuidadorID)
+		events = append(events, ev)
+	}
+
+	return events, rows.Err()
+}
+
+func insertEvent(ctx context.Context, db execer, alertID int64, ev Event) error {
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO historico_alertas (
+			alerta_id, acao, estado_anterior, estado_novo, metodo,
+			destinatario, sucesso, erro, detalhes, cuidador_id, criado_em
+		) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, NULLIF($8, ''), NULLIF($9, ''), $10, NOW())
+	`, alertID, ev.Acao, ev.EstadoAnterior, ev.EstadoNovo, ev.Canal, ev.Destinatario, ev.Sucesso, ev.Erro, ev.Detalhes, ev.CuidadorID)
+	if err != nil {
+		return fmt.Errorf("failed to record alert history: %w", err)
+	}
+	return nil
+}
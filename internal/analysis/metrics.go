@@ -0,0 +1,195 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"eva-mind/internal/gemini"
+)
+
+// Categorias de humor normalizadas gravadas em sentimento_geral
+const (
+	HumorFeliz    = "feliz"
+	HumorNeutro   = "neutro"
+	HumorTriste   = "triste"
+	HumorApatico  = "apatico"
+	HumorAnsioso  = "ansioso"
+	HumorConfuso  = "confuso"
+	HumorIrritado = "irritado"
+)
+
+// moodAliases mapeia variações devolvidas pelo modelo para a categoria normalizada
+var moodAliases = map[string]string{
+	"feliz":      HumorFeliz,
+	"alegre":     HumorFeliz,
+	"contente":   HumorFeliz,
+	"neutro":     HumorNeutro,
+	"estavel":    HumorNeutro,
+	"estável":    HumorNeutro,
+	"triste":     HumorTriste,
+	"deprimido":  HumorTriste,
+	"apatico":    HumorApatico,
+	"apático":    HumorApatico,
+	"desanimado": HumorApatico,
+	"ansioso":    HumorAnsioso,
+	"preocupado": HumorAnsioso,
+	"nervoso":    HumorAnsioso,
+	"confuso":    HumorConfuso,
+	"irritado":   HumorIrritado,
+	"agitado":    HumorIrritado,
+}
+
+// CallMetrics métricas normalizadas de uma ligação
+type CallMetrics struct {
+	Humor             string // sentimento_geral
+	Intensidade       int    // sentimento_intensidade (1-10, quanto maior, pior)
+	Dor               int    // dor_intensidade (0-10)
+	Confusao          bool
+	Solidao           bool
+	MedicamentoTomado bool
+}
+
+// Normalize converte a análise do Gemini no conjunto de métricas da ligação
+func Normalize(a *gemini.ConversationAnalysis) CallMetrics {
+	humor, ok := moodAliases[strings.ToLower(strings.TrimSpace(a.MoodState))]
+	if !ok {
+		humor = HumorNeutro
+	}
+
+	// Depressão sinalizada sem humor negativo explícito é tratada como apatia
+	if a.Depression && (humor == HumorNeutro || humor == HumorFeliz) {
+		humor = HumorApatico
+	}
+
+	dor := 0
+	if a.ReportedPain {
+		dor = clamp(a.PainIntensity, 0, 10)
+	}
+
+	return CallMetrics{
+		Humor:             humor,
+		Intensidade:       SentimentIntensity(a, humor),
+		Dor:               dor,
+		Confusao:          a.Confusion || humor == HumorConfuso,
+		Solidao:           a.Loneliness,
+		MedicamentoTomado: a.MedicationTaken,
+	}
+}
+
+// moodIntensity intensidade base (1-10) de cada humor normalizado
+var moodIntensity = map[string]int{
+	HumorFeliz:    3,
+	HumorNeutro:   5,
+	HumorAnsioso:  6,
+	HumorConfuso:  6,
+	HumorIrritado: 6,
+	HumorTriste:   7,
+	HumorApatico:  7,
+}
+
+// SentimentIntensity converte a análise em escala 1-10 a partir do humor já
+// normalizado por Normalize, para que humor e intensidade nunca divirjam
+func SentimentIntensity(a *gemini.ConversationAnalysis, humor string) int {
+	if a.EmergencySymptoms {
+		return 10
+	}
+
+	intensity, ok := moodIntensity[humor]
+	if !ok {
+		intensity = moodIntensity[HumorNeutro]
+	}
+	if a.Depression {
+		intensity = 8
+	}
+
+	if a.ReportedPain {
+		intensity += a.PainIntensity / 3
+	}
+
+	return clamp(intensity, 1, 10)
+}
+
+// metricsUpdate atualiza as colunas de métricas de uma ligação
+const metricsUpdate = `
+	UPDATE historico_ligacoes
+	SET sentimento_geral = $2,
+	    sentimento_intensidade = $3,
+	    dor_intensidade = $4,
+	    confusao = $5,
+	    solidao = $6,
+	    medicamento_tomado = $7
+	WHERE id = $1
+`
+
+// SaveMetrics grava as métricas normalizadas da ligação
+func SaveMetrics(ctx context.Context, db *sql.DB, historicoID int64, m CallMetrics) error {
+	_, err := db.ExecContext(ctx, metricsUpdate,
+		historicoID, m.Humor, m.Intensidade, m.Dor, m.Confusao, m.Solidao, m.MedicamentoTomado)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar métricas: %w", err)
+	}
+	return nil
+}
+
+// BackfillMetrics preenche as métricas de até limit ligações que já têm
+// analise_gemini mas ainda não foram normalizadas. Retorna quantas foram atualizadas.
+func BackfillMetrics(ctx context.Context, db *sql.DB, limit int) (int, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, analise_gemini
+		FROM historico_ligacoes
+		WHERE analise_gemini IS NOT NULL
+		  AND sentimento_geral IS NULL
+		ORDER BY id
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query calls without metrics: %w", err)
+	}
+
+	type pending struct {
+		id  int64
+		raw []byte
+	}
+
+	var calls []pending
+	for rows.Next() {
+		var p pending
+		if err := rows.Scan(&p.id, &p.raw); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan call: %w", err)
+		}
+		calls = append(calls, p)
+	}
+	rows.Close()
+
+	updated := 0
+	for _, c := range calls {
+		var a gemini.ConversationAnalysis
+		if err := json.Unmarshal(c.raw, &a); err != nil {
+			log.Printf("⚠️ analise_gemini inválida no histórico %d: %v", c.id, err)
+			// Marca como neutro para não voltar a ser selecionada
+			a = gemini.ConversationAnalysis{MoodState: HumorNeutro}
+		}
+
+		if err := SaveMetrics(ctx, db, c.id, Normalize(&a)); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	return updated, nil
+}
+
+func clamp(v, min, max int) int {
+	if v < min {
+		return min
+	}
+	if v > max {
+		return max
+	}
+	return v
+}
@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"testing"
+
+	"eva-mind/internal/gemini"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		analysis gemini.ConversationAnalysis
+		want     CallMetrics
+	}{
+		{
+			name:     "alias com acento e caixa",
+			analysis: gemini.ConversationAnalysis{MoodState: "  Estável "},
+			want:     CallMetrics{Humor: HumorNeutro, Intensidade: 5},
+		},
+		{
+			name:     "feliz",
+			analysis: gemini.ConversationAnalysis{MoodState: "alegre", MedicationTaken: true},
+			want:     CallMetrics{Humor: HumorFeliz, Intensidade: 3, MedicamentoTomado: true},
+		},
+		{
+			name:     "humor desconhecido vira neutro",
+			analysis: gemini.ConversationAnalysis{MoodState: "eufórico"},
+			want:     CallMetrics{Humor: HumorNeutro, Intensidade: 5},
+		},
+		{
+			name:     "depressão com humor bom vira apatia",
+			analysis: gemini.ConversationAnalysis{MoodState: "feliz", Depression: true},
+			want:     CallMetrics{Humor: HumorApatico, Intensidade: 8},
+		},
+		{
+			name:     "depressão mantém humor negativo",
+			analysis: gemini.ConversationAnalysis{MoodState: "preocupado", Depression: true},
+			want:     CallMetrics{Humor: HumorAnsioso, Intensidade: 8},
+		},
+		{
+			name:     "humor confuso marca confusão",
+			analysis: gemini.ConversationAnalysis{MoodState: "confuso", Loneliness: true},
+			want:     CallMetrics{Humor: HumorConfuso, Intensidade: 6, Confusao: true, Solidao: true},
+		},
+		{
+			name:     "dor soma à intensidade e é limitada a 10",
+			analysis: gemini.ConversationAnalysis{MoodState: "triste", ReportedPain: true, PainIntensity: 14},
+			want:     CallMetrics{Humor: HumorTriste, Intensidade: 10, Dor: 10},
+		},
+		{
+			name:     "dor sem relato é ignorada",
+			analysis: gemini.ConversationAnalysis{MoodState: "neutro", PainIntensity: 8},
+			want:     CallMetrics{Humor: HumorNeutro, Intensidade: 5},
+		},
+		{
+			name:     "emergência é sempre 10",
+			analysis: gemini.ConversationAnalysis{MoodState: "feliz", EmergencySymptoms: true},
+			want:     CallMetrics{Humor: HumorFeliz, Intensidade: 10},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(&tt.analysis); got != tt.want {
+				t.Errorf("Normalize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/gemini"
+	"eva-mind/internal/notify"
+	"eva-mind/internal/webhooks"
+)
+
+// minTranscriptLength tamanho mínimo de transcrição para valer uma análise
+const minTranscriptLength = 50
+
+// Processor executa a análise de uma ligação e persiste o resultado
+type Processor struct {
+	cfg        *config.Config
+	db         *sql.DB
+	dispatcher *notify.Dispatcher
+	queue      *Queue
+	hooks      *webhooks.Service
+}
+
+// NewProcessor cria um novo processador de análises
+func NewProcessor(cfg *config.Config, db *sql.DB, dispatcher *notify.Dispatcher, queue *Queue) *Processor {
+	return &Processor{
+		cfg:        cfg,
+		db:         db,
+		dispatcher: dispatcher,
+		queue:      queue,
+		hooks:      webhooks.NewService(db),
+	}
+}
+
+// Process analisa a ligação do job. É idempotente: reprocessar o mesmo
+// historico_id sobrescreve a análise e nunca alerta a família duas vezes.
+func (p *Processor) Process(ctx context.Context, job Job) error {
+	var idosoID int64
+	var transcript sql.NullString
+
+	err := p.db.QueryRowContext(ctx, `
+		SELECT idoso_id, transcricao_completa
+		FROM historico_ligacoes
+		WHERE id = $1
+	`, job.HistoricoID).Scan(&idosoID, &transcript)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("historico %d não encontrado", job.HistoricoID)
+	}
+	if err != nil {
+		return fmt.Errorf("erro ao buscar transcrição: %w", err)
+	}
+
+	if !transcript.Valid || len(transcript.String) <= minTranscriptLength {
+		log.Printf("⚠️ [ANÁLISE] Histórico %d sem transcrição suficiente, encerrando sem análise", job.HistoricoID)
+		_, err := p.db.ExecContext(ctx, `
+			UPDATE historico_ligacoes
+			SET fim_chamada = COALESCE(fim_chamada, CURRENT_TIMESTAMP)
+			WHERE id = $1
+		`, job.HistoricoID)
+		return err
+	}
+
+	log.Printf("🧠 [ANÁLISE] Histórico %d (tentativa %d/%d): %d caracteres",
+		job.HistoricoID, job.Tentativas, job.MaxTentativas, len(transcript.String))
+
+	analysis, err := gemini.AnalyzeConversation(p.cfg, transcript.String)
+	if err != nil {
+		return fmt.Errorf("erro no Gemini: %w", err)
+	}
+
+	log.Printf("✅ [ANÁLISE] Histórico %d - Urgência: %s, Humor: %s", job.HistoricoID, analysis.UrgencyLevel, analysis.MoodState)
+
+	if err := p.save(ctx, job.HistoricoID, analysis); err != nil {
+		return err
+	}
+
+	if err := p.hooks.Publish(ctx, idosoID, webhooks.EventoChamadaFinalizada, map[string]interface{}{
+		"historico_id":   job.HistoricoID,
+		"analise_versao": gemini.AnalysisVersion,
+		"analise":        analysis,
+	}); err != nil {
+		log.Printf("⚠️ [ANÁLISE] Webhook do histórico %d: %v", job.HistoricoID, err)
+	}
+
+	if isUrgent(analysis) && !job.AlertaDisparado {
+		p.alertFamily(ctx, job, idosoID, analysis)
+	}
+
+	return nil
+}
+
+// save grava a análise e as métricas normalizadas na ligação.
+// fim_chamada só é preenchido se ainda estiver vazio.
+func (p *Processor) save(ctx context.Context, historicoID int64, analysis *gemini.ConversationAnalysis) error {
+	analysisJSON, err := json.Marshal(analysis)
+	if err != nil {
+		return fmt.Errorf("erro ao serializar análise: %w", err)
+	}
+
+	_, err = p.db.ExecContext(ctx, `
+		UPDATE historico_ligacoes
+		SET
+			fim_chamada = COALESCE(fim_chamada, CURRENT_TIMESTAMP),
+			analise_gemini = $2::jsonb,
+			urgencia = $3,
+			sentimento = $4,
+			transcricao_resumo = $5,
+			analise_versao = $6
+		WHERE id = $1
+	`,
+		historicoID,
+		string(analysisJSON),
+		analysis.UrgencyLevel,
+		analysis.MoodState,
+		analysis.Summary,
+		gemini.AnalysisVersion,
+	)
+	if err != nil {
+		return fmt.Errorf("erro ao salvar análise: %w", err)
+	}
+
+	return SaveMetrics(ctx, p.db, historicoID, Normalize(analysis))
+}
+
+// alertFamily dispara o alerta de urgência e registra no job para não repetir
+func (p *Processor) alertFamily(ctx context.Context, job Job, idosoID int64, analysis *gemini.ConversationAnalysis) {
+	if p.dispatcher == nil {
+		log.Printf("⚠️ [ANÁLISE] Notificações indisponíveis, alerta do histórico %d não enviado", job.HistoricoID)
+		return
+	}
+
+	log.Printf("🚨 ALERTA DE URGÊNCIA: %s (histórico %d)", analysis.UrgencyLevel, job.HistoricoID)
+
+	alertMsg := fmt.Sprintf(
+		"URGÊNCIA %s: %s. %s",
+		analysis.UrgencyLevel,
+		strings.Join(analysis.KeyConcerns, ", "),
+		analysis.RecommendedAction,
+	)
+
+	if err := gemini.AlertFamily(p.cfg, p.db, p.dispatcher, idosoID, alertMsg); err != nil {
+		log.Printf("❌ [ANÁLISE] Erro ao alertar família: %v", err)
+	}
+
+	// O alerta já foi registrado em alertas (e escalonado se falhou),
+	// então não deve ser disparado de novo numa próxima tentativa
+	if err := p.queue.MarkAlerted(ctx, job); err != nil {
+		log.Printf("⚠️ [ANÁLISE] %v", err)
+	}
+}
+
+func isUrgent(analysis *gemini.ConversationAnalysis) bool {
+	return analysis.UrgencyLevel == "CRITICO" || analysis.UrgencyLevel == "ALTO"
+}
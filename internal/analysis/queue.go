@@ -0,0 +1,276 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Status possíveis de um job na fila_analise
+const (
+	StatusPendente        = "pendente"
+	StatusProcessando     = "processando"
+	StatusConcluido       = "concluido"
+	StatusErro            = "erro"
+	StatusFalhaDefinitiva = "falha_definitiva"
+)
+
+const (
+	baseBackoff = 1 * time.Minute
+	maxBackoff  = 6 * time.Hour
+
+	// Jobs presos em "processando" por mais tempo que isso são considerados
+	// abandonados (ex: processo reiniciado no meio da análise)
+	staleTimeout = 10 * time.Minute
+
+	// abandonedCallAge ligação nunca encerrada (processo reiniciado durante a
+	// conversa) é dada como terminada depois disso; nenhuma conversa dura tanto
+	abandonedCallAge = 6 * time.Hour
+)
+
+// Job representa uma análise pendente de uma ligação
+type Job struct {
+	ID              int64
+	HistoricoID     int64
+	Tentativas      int
+	MaxTentativas   int
+	AlertaDisparado bool
+}
+
+// Queue gerencia a fila persistente de análises
+type Queue struct {
+	db          *sql.DB
+	maxAttempts int
+}
+
+// NewQueue cria uma nova fila de análise
+func NewQueue(db *sql.DB, maxAttempts int) *Queue {
+	if maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	return &Queue{db: db, maxAttempts: maxAttempts}
+}
+
+// Enqueue agenda a análise de uma ligação. Chamadas repetidas para o mesmo
+// historico_id não criam jobs duplicados.
+func (q *Queue) Enqueue(ctx context.Context, historicoID int64) error {
+	query := `
+		INSERT INTO fila_analise (historico_id, max_tentativas)
+		VALUES ($1, $2)
+		ON CONFLICT (historico_id) DO NOTHING
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, historicoID, q.maxAttempts); err != nil {
+		return fmt.Errorf("failed to enqueue analysis: %w", err)
+	}
+	return nil
+}
+
+// EnqueueClosed encerra a ligação aberta mais recente do idoso (fim_chamada)
+// e agenda a análise. Chamado quando a sessão de voz termina; retorna o
+// historico_id, ou 0 se o idoso não tinha ligação aberta com transcrição.
+func (q *Queue) EnqueueClosed(ctx context.Context, idosoID int64) (int64, error) {
+	query := `
+		WITH encerrada AS (
+			UPDATE historico_ligacoes
+			SET fim_chamada = NOW()
+			WHERE id = (
+				SELECT id FROM historico_ligacoes
+				WHERE idoso_id = $1
+				  AND fim_chamada IS NULL
+				  AND transcricao_completa IS NOT NULL
+				ORDER BY inicio_chamada DESC
+				LIMIT 1
+			)
+			RETURNING id
+		), fila AS (
+			INSERT INTO fila_analise (historico_id, max_tentativas)
+			SELECT id, $2 FROM encerrada
+			ON CONFLICT (historico_id) DO NOTHING
+		)
+		SELECT id FROM encerrada
+	`
+
+	var historicoID int64
+	err := q.db.QueryRowContext(ctx, query, idosoID, q.maxAttempts).Scan(&historicoID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue closed call: %w", err)
+	}
+	return historicoID, nil
+}
+
+// EnqueueOrphans agenda ligações encerradas que ficaram sem análise e sem job
+// (ex: processo reiniciado entre o fim da sessão e o enfileiramento). Só entram
+// ligações encerradas há mais de grace, ou abertas há mais de abandonedCallAge
+// (sessão perdida num restart); conversas em andamento nunca são analisadas.
+// Registros de chamada não atendida (motivo_falha) não têm conversa a analisar.
+func (q *Queue) EnqueueOrphans(ctx context.Context, grace time.Duration) (int, error) {
+	query := `
+		INSERT INTO fila_analise (historico_id, max_tentativas)
+		SELECT h.id, $2
+		FROM historico_ligacoes h
+		WHERE h.analise_gemini IS NULL
+		  AND h.transcricao_completa IS NOT NULL
+		  AND h.motivo_falha IS NULL
+		  AND (h.fim_chamada < NOW() - make_interval(secs => $1)
+		    OR (h.fim_chamada IS NULL AND h.inicio_chamada < NOW() - make_interval(secs => $3)))
+		  AND NOT EXISTS (SELECT 1 FROM fila_analise f WHERE f.historico_id = h.id)
+		ON CONFLICT (historico_id) DO NOTHING
+	`
+
+	result, err := q.db.ExecContext(ctx, query, grace.Seconds(), q.maxAttempts, abandonedCallAge.Seconds())
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue orphans: %w", err)
+	}
+
+	n, _ := result.RowsAffected()
+	return int(n), nil
+}
+
+// Claim reserva até limit jobs prontos para processamento
+func (q *Queue) Claim(ctx context.Context, limit int) ([]Job, error) {
+	query := `
+		UPDATE fila_analise
+		SET status = 'processando',
+		    tentativas = tentativas + 1,
+		    iniciado_em = NOW(),
+		    atualizado_em = NOW()
+		WHERE id IN (
+			SELECT id FROM fila_analise
+			WHERE (status IN ('pendente', 'erro') AND proxima_tentativa <= NOW())
+			   OR (status = 'processando' AND iniciado_em < NOW() - make_interval(secs => $2))
+			ORDER BY proxima_tentativa ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, historico_id, tentativas, max_tentativas, alerta_disparado
+	`
+
+	rows, err := q.db.QueryContext(ctx, query, limit, staleTimeout.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []Job
+	for rows.Next() {
+		var j Job
+		if err := rows.Scan(&j.ID, &j.HistoricoID, &j.Tentativas, &j.MaxTentativas, &j.AlertaDisparado); err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, j)
+	}
+
+	return jobs, rows.Err()
+}
+
+// Complete marca o job como concluído
+func (q *Queue) Complete(ctx context.Context, job Job) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE fila_analise
+		SET status = 'concluido',
+		    ultimo_erro = NULL,
+		    concluido_em = NOW(),
+		    atualizado_em = NOW()
+		WHERE id = $1
+	`, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to complete job: %w", err)
+	}
+	return nil
+}
+
+// Fail registra a falha do job. Se ainda houver tentativas, reagenda com
+// backoff exponencial; caso contrário, move para falha_definitiva.
+func (q *Queue) Fail(ctx context.Context, job Job, cause error) (string, error) {
+	status := StatusErro
+	if job.Tentativas >= job.MaxTentativas {
+		status = StatusFalhaDefinitiva
+	}
+
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE fila_analise
+		SET status = $2,
+		    ultimo_erro = $3,
+		    proxima_tentativa = NOW() + make_interval(secs => $4),
+		    atualizado_em = NOW()
+		WHERE id = $1
+	`, job.ID, status, cause.Error(), Backoff(job.Tentativas).Seconds())
+	if err != nil {
+		return status, fmt.Errorf("failed to record job failure: %w", err)
+	}
+	return status, nil
+}
+
+// MarkAlerted registra que a família já foi alertada por esta ligação
+func (q *Queue) MarkAlerted(ctx context.Context, job Job) error {
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE fila_analise SET alerta_disparado = true, atualizado_em = NOW() WHERE id = $1
+	`, job.ID)
+	if err != nil {
+		return fmt.Errorf("failed to mark job alerted: %w", err)
+	}
+	return nil
+}
+
+// Requeue devolve uma ligação à fila, zerando as tentativas.
+// Usado para reprocessar jobs em falha_definitiva.
+func (q *Queue) Requeue(ctx context.Context, historicoID int64) error {
+	query := `
+		INSERT INTO fila_analise (historico_id, max_tentativas)
+		VALUES ($1, $2)
+		ON CONFLICT (historico_id) DO UPDATE SET
+			status = 'pendente',
+			tentativas = 0,
+			proxima_tentativa = NOW(),
+			ultimo_erro = NULL,
+			atualizado_em = NOW()
+		WHERE fila_analise.status <> 'processando'
+	`
+
+	if _, err := q.db.ExecContext(ctx, query, historicoID, q.maxAttempts); err != nil {
+		return fmt.Errorf("failed to requeue analysis: %w", err)
+	}
+	return nil
+}
+
+// Stats retorna a quantidade de jobs por status
+func (q *Queue) Stats(ctx context.Context) (map[string]int, error) {
+	rows, err := q.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM fila_analise GROUP BY status`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query queue stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var total int
+		if err := rows.Scan(&status, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan queue stats: %w", err)
+		}
+		stats[status] = total
+	}
+
+	return stats, rows.Err()
+}
+
+// Backoff calcula a espera antes da próxima tentativa (1min, 2min, 4min... até 6h)
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
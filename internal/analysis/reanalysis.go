@@ -0,0 +1,295 @@
+package analysis
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/gemini"
+)
+
+// Filter restringe o conjunto de ligações de uma reanálise
+type Filter struct {
+	From     time.Time
+	To       time.Time
+	IdosoID  int64
+	Entidade string
+}
+
+// where monta a cláusula WHERE sobre historico_ligacoes (alias h) e idosos (alias i)
+func (f Filter) where(args []interface{}) (string, []interface{}) {
+	conds := []string{"h.transcricao_completa IS NOT NULL"}
+
+	if !f.From.IsZero() {
+		args = append(args, f.From)
+		conds = append(conds, fmt.Sprintf("h.inicio_chamada >= $%d", len(args)))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To)
+		conds = append(conds, fmt.Sprintf("h.inicio_chamada < $%d", len(args)))
+	}
+	if f.IdosoID > 0 {
+		args = append(args, f.IdosoID)
+		conds = append(conds, fmt.Sprintf("h.idoso_id = $%d", len(args)))
+	}
+	if f.Entidade != "" {
+		args = append(args, f.Entidade)
+		conds = append(conds, fmt.Sprintf("i.entidade_nome = $%d", len(args)))
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// Change mudança de urgência/humor de uma ligação entre a análise vigente e a nova versão
+type Change struct {
+	HistoricoID        int64  `json:"historico_id"`
+	IdosoID            int64  `json:"idoso_id"`
+	UrgenciaAnterior   string `json:"urgencia_anterior"`
+	UrgenciaNova       string `json:"urgencia_nova"`
+	SentimentoAnterior string `json:"sentimento_anterior"`
+	SentimentoNovo     string `json:"sentimento_novo"`
+}
+
+// Report relatório de diferenças de uma versão de análise
+type Report struct {
+	Versao               string         `json:"versao"`
+	Total                int            `json:"total"`
+	UrgenciaAlterada     int            `json:"urgencia_alterada"`
+	SentimentoAlterado   int            `json:"sentimento_alterado"`
+	TransicoesUrgencia   map[string]int `json:"transicoes_urgencia"`
+	TransicoesSentimento map[string]int `json:"transicoes_sentimento"`
+	Mudancas             []Change       `json:"mudancas"`
+}
+
+// Reanalyzer reexecuta a análise sobre ligações históricas, gravando os
+// resultados em historico_analises sem tocar na análise vigente
+type Reanalyzer struct {
+	cfg *config.Config
+	db  *sql.DB
+}
+
+// NewReanalyzer cria um novo reanalisador
+func NewReanalyzer(cfg *config.Config, db *sql.DB) *Reanalyzer {
+	return &Reanalyzer{cfg: cfg, db: db}
+}
+
+// RunStats resultado de uma execução de reanálise
+type RunStats struct {
+	Candidatas int
+	Analisadas int
+	Falhas     int
+}
+
+// Run analisa as ligações do filtro que ainda não possuem a versão informada.
+// ratePerMinute limita as chamadas ao Gemini; execuções interrompidas podem
+// ser retomadas, pois ligações já analisadas na versão são ignoradas.
+func (r *Reanalyzer) Run(ctx context.Context, filter Filter, versao string, ratePerMinute int) (*RunStats, error) {
+	if versao == "" {
+		return nil, fmt.Errorf("versão da análise é obrigatória")
+	}
+	if ratePerMinute <= 0 {
+		ratePerMinute = 30
+	}
+
+	where, args := filter.where([]interface{}{versao})
+	query := fmt.Sprintf(`
+		SELECT h.id, h.transcricao_completa, h.urgencia, h.sentimento
+		FROM historico_ligacoes h
+		JOIN idosos i ON i.id = h.idoso_id
+		WHERE %s
+		  AND NOT EXISTS (
+			SELECT 1 FROM historico_analises ha
+			WHERE ha.historico_id = h.id AND ha.versao = $1
+		  )
+		ORDER BY h.inicio_chamada ASC
+	`, where)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query calls: %w", err)
+	}
+
+	type candidate struct {
+		id                   int64
+		transcript           string
+		urgencia, sentimento sql.NullString
+	}
+
+	var candidates []candidate
+	for rows.Next() {
+		var c candidate
+		if err := rows.Scan(&c.id, &c.transcript, &c.urgencia, &c.sentimento); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan call: %w", err)
+		}
+		if len(c.transcript) > minTranscriptLength {
+			candidates = append(candidates, c)
+		}
+	}
+	rows.Close()
+
+	stats := &RunStats{Candidatas: len(candidates)}
+	log.Printf("🔁 Reanálise '%s': %d ligação(ões) a analisar (%d/min)", versao, len(candidates), ratePerMinute)
+
+	limiter := time.NewTicker(time.Minute / time.Duration(ratePerMinute))
+	defer limiter.Stop()
+
+	for i, c := range candidates {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return stats, ctx.Err()
+			case <-limiter.C:
+			}
+		}
+
+		analysis, err := gemini.AnalyzeConversation(r.cfg, c.transcript)
+		if err != nil {
+			stats.Falhas++
+			log.Printf("⚠️ Reanálise do histórico %d falhou: %v", c.id, err)
+			continue
+		}
+
+		analysisJSON, err := json.Marshal(analysis)
+		if err != nil {
+			stats.Falhas++
+			continue
+		}
+
+		_, err = r.db.ExecContext(ctx, `
+			INSERT INTO historico_analises (
+				historico_id, versao, analise, urgencia, sentimento,
+				urgencia_anterior, sentimento_anterior
+			) VALUES ($1, $2, $3::jsonb, $4, $5, $6, $7)
+			ON CONFLICT (historico_id, versao) DO NOTHING
+		`, c.id, versao, string(analysisJSON), analysis.UrgencyLevel, analysis.MoodState, c.urgencia, c.sentimento)
+		if err != nil {
+			stats.Falhas++
+			log.Printf("❌ Erro ao salvar reanálise do histórico %d: %v", c.id, err)
+			continue
+		}
+
+		stats.Analisadas++
+		if stats.Analisadas%25 == 0 {
+			log.Printf("📊 Reanálise '%s': %d/%d", versao, stats.Analisadas, len(candidates))
+		}
+	}
+
+	return stats, nil
+}
+
+// BuildReport compara a versão informada com a análise que estava vigente
+// quando cada ligação foi reanalisada
+func (r *Reanalyzer) BuildReport(ctx context.Context, filter Filter, versao string) (*Report, error) {
+	where, args := filter.where([]interface{}{versao})
+	query := fmt.Sprintf(`
+		SELECT h.id, h.idoso_id,
+		       COALESCE(ha.urgencia_anterior, ''), COALESCE(ha.urgencia, ''),
+		       COALESCE(ha.sentimento_anterior, ''), COALESCE(ha.sentimento, '')
+		FROM historico_analises ha
+		JOIN historico_ligacoes h ON h.id = ha.historico_id
+		JOIN idosos i ON i.id = h.idoso_id
+		WHERE ha.versao = $1 AND %s
+		ORDER BY h.inicio_chamada ASC
+	`, where)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query analyses: %w", err)
+	}
+	defer rows.Close()
+
+	report := &Report{
+		Versao:               versao,
+		TransicoesUrgencia:   make(map[string]int),
+		TransicoesSentimento: make(map[string]int),
+	}
+
+	for rows.Next() {
+		var c Change
+		if err := rows.Scan(&c.HistoricoID, &c.IdosoID, &c.UrgenciaAnterior, &c.UrgenciaNova,
+			&c.SentimentoAnterior, &c.SentimentoNovo); err != nil {
+			return nil, fmt.Errorf("failed to scan analysis: %w", err)
+		}
+
+		report.Total++
+		changed := false
+
+		if c.UrgenciaAnterior != c.UrgenciaNova {
+			report.UrgenciaAlterada++
+			report.TransicoesUrgencia[transition(c.UrgenciaAnterior, c.UrgenciaNova)]++
+			changed = true
+		}
+		if c.SentimentoAnterior != c.SentimentoNovo {
+			report.SentimentoAlterado++
+			report.TransicoesSentimento[transition(c.SentimentoAnterior, c.SentimentoNovo)]++
+			changed = true
+		}
+
+		if changed {
+			report.Mudancas = append(report.Mudancas, c)
+		}
+	}
+
+	return report, rows.Err()
+}
+
+// Apply promove a versão informada a análise vigente das ligações do filtro
+func (r *Reanalyzer) Apply(ctx context.Context, filter Filter, versao string) (int, error) {
+	where, args := filter.where([]interface{}{versao})
+	query := fmt.Sprintf(`
+		WITH promovidas AS (
+			UPDATE historico_ligacoes h
+			SET analise_gemini = ha.analise,
+			    urgencia = ha.urgencia,
+			    sentimento = ha.sentimento,
+			    transcricao_resumo = ha.analise->>'summary',
+			    analise_versao = ha.versao,
+			    sentimento_geral = NULL
+			FROM historico_analises ha, idosos i
+			WHERE ha.historico_id = h.id
+			  AND i.id = h.idoso_id
+			  AND ha.versao = $1
+			  AND %s
+			RETURNING ha.id
+		)
+		UPDATE historico_analises
+		SET aplicada = true, aplicada_em = NOW()
+		WHERE id IN (SELECT id FROM promovidas)
+	`, where)
+
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to apply analyses: %w", err)
+	}
+
+	n, _ := result.RowsAffected()
+
+	// Recalcular as métricas normalizadas das ligações promovidas
+	for {
+		updated, err := BackfillMetrics(ctx, r.db, 500)
+		if err != nil {
+			return int(n), err
+		}
+		if updated == 0 {
+			break
+		}
+	}
+
+	return int(n), nil
+}
+
+func transition(from, to string) string {
+	if from == "" {
+		from = "(vazio)"
+	}
+	if to == "" {
+		to = "(vazio)"
+	}
+	return from + " → " + to
+}
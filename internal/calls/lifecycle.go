@@ -0,0 +1,53 @@
+package calls
+
+import "fmt"
+
+// Estados do ciclo de vida de uma chamada da EVA
+const (
+	StatusTocando   = "tocando"
+	StatusEntregue  = "entregue"
+	StatusRecusada  = "recusada"
+	StatusAtendida  = "atendida"
+	StatusConcluida = "concluida"
+	StatusFalhou    = "falhou"
+	StatusPerdida   = "perdida"
+)
+
+// transitions estados de destino permitidos a partir de cada estado
+var transitions = map[string][]string{
+	StatusTocando:  {StatusEntregue, StatusRecusada, StatusAtendida, StatusFalhou, StatusPerdida},
+	StatusEntregue: {StatusRecusada, StatusAtendida, StatusFalhou, StatusPerdida},
+	StatusAtendida: {StatusConcluida, StatusFalhou},
+	// O idoso pode retornar a ligação logo depois do prazo de 45s
+	StatusPerdida: {StatusAtendida},
+}
+
+// timestampColumns coluna de horário preenchida ao entrar em cada estado
+var timestampColumns = map[string]string{
+	StatusEntregue:  "entregue_em = COALESCE(entregue_em, NOW())",
+	StatusAtendida:  "atendida_em = NOW(), encerrada_em = NULL",
+	StatusRecusada:  "encerrada_em = NOW()",
+	StatusConcluida: "encerrada_em = NOW()",
+	StatusFalhou:    "encerrada_em = NOW()",
+	StatusPerdida:   "encerrada_em = NOW()",
+}
+
+// TransitionError transição inválida entre dois estados
+type TransitionError struct {
+	From string
+	To   string
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("transição de chamada inválida: %s → %s", e.From, e.To)
+}
+
+// CanTransition informa se a chamada pode passar de from para to
+func CanTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
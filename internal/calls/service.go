@@ -0,0 +1,250 @@
+package calls
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// RingTimeout tempo que a chamada toca antes de ser considerada perdida
+const RingTimeout = 45 * time.Second
+
+// answerWindow uma conexão sem session_id ainda atende a última chamada tocada nesse prazo
+const answerWindow = 10 * time.Minute
+
+// ErrNotFound chamada inexistente (ou de outro idoso)
+var ErrNotFound = errors.New("chamada não encontrada")
+
+// Call chamada da EVA para um idoso
+type Call struct {
+	ID            int64      `json:"id"`
+	SessaoID      string     `json:"sessao_id"`
+	AgendamentoID int64      `json:"agendamento_id,omitempty"`
+	IdosoID       int64      `json:"idoso_id"`
+	NomeIdoso     string     `json:"nome_idoso,omitempty"`
+	Status        string     `json:"status"`
+	Motivo        string     `json:"motivo,omitempty"`
+	TocandoEm     time.Time  `json:"tocando_em"`
+	EntregueEm    *time.Time `json:"entregue_em,omitempty"`
+	AtendidaEm    *time.Time `json:"atendida_em,omitempty"`
+	EncerradaEm   *time.Time `json:"encerrada_em,omitempty"`
+}
+
+// Service ciclo de vida das chamadas
+type Service struct {
+	db *sql.DB
+}
+
+// NewService cria o serviço de chamadas
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Ring registra a chamada do agendamento antes do push; o sessao_id vai nos dados do FCM
+func (s *Service) Ring(ctx context.Context, agendamentoID, idosoID int64) (*Call, error) {
+	c := &Call{
+		SessaoID:      fmt.Sprintf("call-%d-%d", agendamentoID, time.Now().Unix()),
+		AgendamentoID: agendamentoID,
+		IdosoID:       idosoID,
+		Status:        StatusTocando,
+	}
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO chamadas (sessao_id, agendamento_id, idoso_id)
+		VALUES ($1, $2, $3)
+		RETURNING id, tocando_em
+	`, c.SessaoID, agendamentoID, idosoID).Scan(&c.ID, &c.TocandoEm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create call: %w", err)
+	}
+	return c, nil
+}
+
+// Delivered o app do idoso recebeu o push e está tocando
+func (s *Service) Delivered(ctx context.Context, idosoID int64, sessaoID string) (*Call, error) {
+	return s.transition(ctx, idosoID, sessaoID, StatusEntregue, "")
+}
+
+// Declined o idoso recusou a chamada no app
+func (s *Service) Declined(ctx context.Context, idosoID int64, sessaoID string) (*Call, error) {
+	return s.transition(ctx, idosoID, sessaoID, StatusRecusada, "recusada pelo idoso")
+}
+
+// Fail encerra a chamada que não chegou a acontecer (push não entregue, erro da IA)
+func (s *Service) Fail(ctx context.Context, idosoID int64, sessaoID, motivo string) error {
+	_, err := s.transition(ctx, idosoID, sessaoID, StatusFalhou, motivo)
+	return err
+}
+
+// Complete encerra a conversa atendida e conclui o agendamento
+func (s *Service) Complete(ctx context.Context, idosoID int64, sessaoID string) error {
+	c, err := s.transition(ctx, idosoID, sessaoID, StatusConcluida, "")
+	if err != nil {
+		return err
+	}
+	if c.AgendamentoID != 0 {
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE agendamentos SET status = 'concluido', atualizado_em = NOW()
+			WHERE id = $1 AND status = 'em_andamento'
+		`, c.AgendamentoID); err != nil {
+			return fmt.Errorf("failed to complete schedule: %w", err)
+		}
+	}
+	return nil
+}
+
+// Answer liga a sessão WebSocket à chamada. Sem sessaoID, usa a última
+// chamada tocada para o idoso nos últimos 10 minutos. Retorna ErrNotFound
+// quando não há chamada a atender (conversa iniciada pelo idoso).
+func (s *Service) Answer(ctx context.Context, idosoID int64, sessaoID string) (*Call, error) {
+	if sessaoID == "" {
+		err := s.db.QueryRowContext(ctx, `
+			SELECT sessao_id FROM chamadas
+			WHERE idoso_id = $1
+			  AND status IN ('tocando', 'entregue', 'perdida')
+			  AND tocando_em > NOW() - make_interval(secs => $2)
+			ORDER BY tocando_em DESC
+			LIMIT 1
+		`, idosoID, answerWindow.Seconds()).Scan(&sessaoID)
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to find ringing call: %w", err)
+		}
+	}
+
+	c, err := s.transition(ctx, idosoID, sessaoID, StatusAtendida, "")
+	if err != nil {
+		return nil, err
+	}
+	if c.AgendamentoID != 0 {
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE agendamentos SET status = 'em_andamento', atualizado_em = NOW()
+			WHERE id = $1 AND status IN ('em_andamento', 'aguardando_push', 'nao_atendido')
+		`, c.AgendamentoID); err != nil {
+			log.Printf("⚠️ Erro ao atualizar agendamento %d: %v", c.AgendamentoID, err)
+		}
+	}
+
+	log.Printf("📞 Chamada %s atendida pelo idoso %d", sessaoID, idosoID)
+	return c, nil
+}
+
+// Unanswered marca como perdidas as chamadas que tocaram por mais de
+// RingTimeout e devolve, uma única vez, as perdidas e recusadas ainda não
+// processadas. O prazo conta do push de fato enviado: chamadas na fila de push
+// não expiram aqui, e as que a fila não entregou viram falhou.
+func (s *Service) Unanswered(ctx context.Context) ([]Call, error) {
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE chamadas c
+		SET status = 'falhou', motivo = 'push não entregue', encerrada_em = NOW(), atualizado_em = NOW()
+		FROM agendamentos a
+		WHERE a.id = c.agendamento_id
+		  AND c.status = 'tocando'
+		  AND a.status IN ('falha_envio', 'falha_token_invalido')
+	`); err != nil {
+		return nil, fmt.Errorf("failed to fail undelivered calls: %w", err)
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE chamadas c
+		SET status = 'perdida', motivo = 'sem atendimento', encerrada_em = NOW(), atualizado_em = NOW()
+		FROM agendamentos a
+		WHERE a.id = c.agendamento_id
+		  AND c.status IN ('tocando', 'entregue')
+		  AND a.status = 'em_andamento'
+		  AND GREATEST(c.tocando_em, COALESCE(c.entregue_em, c.tocando_em), COALESCE(a.ultima_tentativa, c.tocando_em))
+		      < NOW() - make_interval(secs => $1)
+	`, RingTimeout.Seconds()); err != nil {
+		return nil, fmt.Errorf("failed to expire calls: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE chamadas c
+		SET processada = true, atualizado_em = NOW()
+		FROM idosos i
+		WHERE i.id = c.idoso_id
+		  AND NOT c.processada
+		  AND c.status IN ('perdida', 'recusada')
+		RETURNING c.id, c.sessao_id, COALESCE(c.agendamento_id, 0), c.idoso_id, i.nome, c.status,
+		          COALESCE(c.motivo, ''), c.tocando_em, c.entregue_em, c.atendida_em, c.encerrada_em
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim unanswered calls: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Call
+	for rows.Next() {
+		var c Call
+		if err := rows.Scan(&c.ID, &c.SessaoID, &c.AgendamentoID, &c.IdosoID, &c.NomeIdoso, &c.Status,
+			&c.Motivo, &c.TocandoEm, &c.EntregueEm, &c.AtendidaEm, &c.EncerradaEm); err != nil {
+			return nil, fmt.Errorf("failed to scan call: %w", err)
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+// Get chamada pelo sessao_id (idosoID 0 = qualquer idoso)
+func (s *Service) Get(ctx context.Context, idosoID int64, sessaoID string) (*Call, error) {
+	var c Call
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, sessao_id, COALESCE(agendamento_id, 0), idoso_id, status, COALESCE(motivo, ''),
+		       tocando_em, entregue_em, atendida_em, encerrada_em
+		FROM chamadas
+		WHERE sessao_id = $1 AND ($2 = 0 OR idoso_id = $2)
+	`, sessaoID, idosoID).Scan(&c.ID, &c.SessaoID, &c.AgendamentoID, &c.IdosoID, &c.Status, &c.Motivo,
+		&c.TocandoEm, &c.EntregueEm, &c.AtendidaEm, &c.EncerradaEm)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load call: %w", err)
+	}
+	return &c, nil
+}
+
+// transition muda o estado da chamada do idoso. Repetir o estado atual não é erro
+// (o app pode reenviar "entregue").
+func (s *Service) transition(ctx context.Context, idosoID int64, sessaoID, to, motivo string) (*Call, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var from string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status FROM chamadas WHERE sessao_id = $1 AND idoso_id = $2 FOR UPDATE
+	`, sessaoID, idosoID).Scan(&from)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load call %s: %w", sessaoID, err)
+	}
+
+	if from != to {
+		if !CanTransition(from, to) {
+			return nil, &TransitionError{From: from, To: to}
+		}
+
+		set := "status = $2, motivo = COALESCE(NULLIF($3, ''), motivo), atualizado_em = NOW()"
+		if extra, ok := timestampColumns[to]; ok {
+			set += ", " + extra
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE chamadas SET `+set+` WHERE sessao_id = $1`, sessaoID, to, motivo); err != nil {
+			return nil, fmt.Errorf("failed to update call %s: %w", sessaoID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, idosoID, sessaoID)
+}
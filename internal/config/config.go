@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -22,6 +23,17 @@ type Config struct {
 	TwilioAccountSID  string
 	TwilioAuthToken   string
 	TwilioPhoneNumber string
+	TwilioAPIURL      string // sobrescrito pelo servidor falso em testes locais
+	TwilioVoice       string // voz TTS das ligações de alerta
+
+	// WhatsApp Business Cloud API
+	EnableWhatsApp           bool
+	WhatsAppToken            string
+	WhatsAppPhoneNumberID    string
+	WhatsAppAPIURL           string // sobrescrito pelo stub local em testes
+	WhatsAppVerifyToken      string // token da verificação do webhook (hub.verify_token)
+	WhatsAppAppSecret        string // assinatura X-Hub-Signature-256 dos webhooks
+	WhatsAppTemplateLanguage string
 
 	// Google/Gemini
 	GoogleAPIKey        string
@@ -32,18 +44,41 @@ type Config struct {
 	SchedulerInterval int
 	MaxRetries        int
 
+	// Fila de análise pós-chamada
+	AnalysisMaxAttempts int
+	AnalysisBatchSize   int
+
 	// Firebase
 	FirebaseCredentialsPath string
 
+	// Web Push (painel web)
+	VAPIDPrivateKey string
+	VAPIDSubject    string
+
+	// Token das rotas de operação (políticas da entidade, reprocessamento)
+	AdminAPIToken string
+
 	// Alert System
 	AlertRetryInterval   int  // Intervalo entre tentativas de reenvio (minutos)
 	AlertEscalationTime  int  // Tempo até escalonamento (minutos)
 	EnableSMSFallback    bool // Habilitar SMS como fallback
 	EnableEmailFallback  bool // Habilitar Email como fallback
 	EnableCallFallback   bool // Habilitar ligação como fallback
+	CallMaxAttempts      int  // Tentativas de ligação por destinatário antes de passar ao próximo
 	CriticalAlertTimeout int  // Timeout para alertas críticos (minutos)
+	AlertDedupWindow     int  // Janela para agrupar alertas repetidos (minutos)
+	AlertRateLimit       int  // Máximo de notificações não críticas por cuidador por hora
+
+	// Email: transporte smtp, http (API transacional) ou arquivo (Maildir local).
+	// Fora de produção o padrão é arquivo e os transportes reais só entregam
+	// para EmailAllowlist.
+	EmailTransport string
+	EmailDir       string
+	EmailAPIURL    string
+	EmailAPIKey    string
+	EmailAllowlist []string // endereços ou @dominio
 
-	// SMTP Configuration
+	// SMTP Configuration (remetente vale para todos os transportes)
 	SMTPHost      string
 	SMTPPort      int
 	SMTPUsername  string
@@ -57,19 +92,37 @@ func Load() (*Config, error) {
 		log.Println("ℹ️  Info: Ficheiro .env não encontrado ou não pôde ser carregado. Lendo variáveis de ambiente do sistema.")
 	}
 
+	environment := getEnvWithDefault("ENVIRONMENT", "development")
+	emailTransport := "arquivo"
+	if environment == "production" {
+		emailTransport = "smtp"
+	}
+
 	return &Config{
 		// Server
 		Port:        getEnvWithDefault("PORT", "8080"),
-		Environment: getEnvWithDefault("ENVIRONMENT", "development"),
+		Environment: environment,
 		MetricsPort: getEnvWithDefault("METRICS_PORT", "9090"),
 
 		// Database
 		DatabaseURL: os.Getenv("DATABASE_URL"),
 
 		// Twilio
+		ServiceDomain:     os.Getenv("SERVICE_DOMAIN"),
 		TwilioAccountSID:  os.Getenv("TWILIO_ACCOUNT_SID"),
 		TwilioAuthToken:   os.Getenv("TWILIO_AUTH_TOKEN"),
 		TwilioPhoneNumber: os.Getenv("TWILIO_PHONE_NUMBER"),
+		TwilioAPIURL:      getEnvWithDefault("TWILIO_API_URL", "https://api.twilio.com"),
+		TwilioVoice:       getEnvWithDefault("TWILIO_VOICE", "Polly.Camila"),
+
+		// WhatsApp
+		EnableWhatsApp:           getEnvBool("ENABLE_WHATSAPP", false),
+		WhatsAppToken:            os.Getenv("WHATSAPP_TOKEN"),
+		WhatsAppPhoneNumberID:    os.Getenv("WHATSAPP_PHONE_NUMBER_ID"),
+		WhatsAppAPIURL:           getEnvWithDefault("WHATSAPP_API_URL", "https://graph.facebook.com/v19.0"),
+		WhatsAppVerifyToken:      os.Getenv("WHATSAPP_VERIFY_TOKEN"),
+		WhatsAppAppSecret:        os.Getenv("WHATSAPP_APP_SECRET"),
+		WhatsAppTemplateLanguage: getEnvWithDefault("WHATSAPP_TEMPLATE_LANGUAGE", "pt_BR"),
 
 		// Google/Gemini
 		GoogleAPIKey:        os.Getenv("GOOGLE_API_KEY"),
@@ -80,27 +133,57 @@ func Load() (*Config, error) {
 		SchedulerInterval: getEnvInt("SCHEDULER_INTERVAL", 1),
 		MaxRetries:        getEnvInt("MAX_RETRIES", 3),
 
+		// Fila de análise
+		AnalysisMaxAttempts: getEnvInt("ANALYSIS_MAX_ATTEMPTS", 5),
+		AnalysisBatchSize:   getEnvInt("ANALYSIS_BATCH_SIZE", 10),
+
 		// Firebase
 		FirebaseCredentialsPath: os.Getenv("FIREBASE_CREDENTIALS_PATH"),
 
+		// Web Push
+		VAPIDPrivateKey: os.Getenv("VAPID_PRIVATE_KEY"),
+		VAPIDSubject:    os.Getenv("VAPID_SUBJECT"),
+
+		// API de operação
+		AdminAPIToken: os.Getenv("ADMIN_API_TOKEN"),
+
 		// Alert System
 		AlertRetryInterval:   getEnvInt("ALERT_RETRY_INTERVAL", 5),
 		AlertEscalationTime:  getEnvInt("ALERT_ESCALATION_TIME", 5),
 		EnableSMSFallback:    getEnvBool("ENABLE_SMS_FALLBACK", false),
 		EnableEmailFallback:  getEnvBool("ENABLE_EMAIL_FALLBACK", true),
 		EnableCallFallback:   getEnvBool("ENABLE_CALL_FALLBACK", false),
+		CallMaxAttempts:      getEnvInt("CALL_MAX_ATTEMPTS", 2),
 		CriticalAlertTimeout: getEnvInt("CRITICAL_ALERT_TIMEOUT", 5),
+		AlertDedupWindow:     getEnvInt("ALERT_DEDUP_WINDOW", 30),
+		AlertRateLimit:       getEnvInt("ALERT_RATE_LIMIT", 5),
+
+		// Email
+		EmailTransport: getEnvWithDefault("EMAIL_TRANSPORT", emailTransport),
+		EmailDir:       getEnvWithDefault("EMAIL_DIR", "tmp/emails"),
+		EmailAPIURL:    os.Getenv("EMAIL_API_URL"),
+		EmailAPIKey:    os.Getenv("EMAIL_API_KEY"),
+		EmailAllowlist: getEnvList("EMAIL_ALLOWLIST"),
 
 		// SMTP
-		SMTPHost:      getEnvWithDefault("SMTP_HOST", "smtp.gmail.com"),
+		SMTPHost:      os.Getenv("SMTP_HOST"),
 		SMTPPort:      getEnvInt("SMTP_PORT", 587),
 		SMTPUsername:  os.Getenv("SMTP_USERNAME"),
 		SMTPPassword:  os.Getenv("SMTP_PASSWORD"),
 		SMTPFromName:  getEnvWithDefault("SMTP_FROM_NAME", "EVA - Assistente Virtual"),
-		SMTPFromEmail: getEnvWithDefault("SMTP_FROM_EMAIL", "web2ajax@gmail.com"),
+		SMTPFromEmail: os.Getenv("SMTP_FROM_EMAIL"),
 	}, nil
 }
 
+// PublicURL monta a URL pública do serviço para callbacks de provedores externos
+func (c *Config) PublicURL(path string) string {
+	base := strings.TrimRight(c.ServiceDomain, "/")
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "https://" + base
+	}
+	return base + path
+}
+
 func getEnvWithDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -125,6 +208,17 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvList lista separada por vírgulas (itens vazios são ignorados)
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // Validate valida se todas as configurações obrigatórias estão presentes
 func (c *Config) Validate() error {
 	if c.DatabaseURL == "" {
@@ -144,8 +238,19 @@ func (c *Config) Validate() error {
 		log.Println("⚠️  SMS fallback habilitado mas credenciais Twilio não configuradas")
 	}
 
-	if c.EnableEmailFallback && (c.SMTPUsername == "" || c.SMTPPassword == "") {
-		log.Println("⚠️  Email fallback habilitado mas credenciais SMTP não configuradas")
+	if c.EnableEmailFallback {
+		switch c.EmailTransport {
+		case "smtp":
+			if c.SMTPHost == "" || c.SMTPUsername == "" || c.SMTPPassword == "" || c.SMTPFromEmail == "" {
+				log.Println("⚠️  Email fallback habilitado mas SMTP_HOST, credenciais ou SMTP_FROM_EMAIL não configurados")
+			}
+		case "http":
+			if c.EmailAPIKey == "" || c.SMTPFromEmail == "" {
+				log.Println("⚠️  Email fallback habilitado mas EMAIL_API_KEY ou SMTP_FROM_EMAIL não configurados")
+			}
+		case "arquivo":
+			log.Printf("ℹ️  Emails gravados em %s (EMAIL_TRANSPORT=arquivo), nada é enviado", c.EmailDir)
+		}
 	}
 
 	return nil
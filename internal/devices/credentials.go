@@ -0,0 +1,132 @@
+package devices
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// Tipos de pessoa que usam os apps
+const (
+	TipoIdoso    = "idoso"
+	TipoCuidador = "cuidador"
+)
+
+// ErrUnauthorized credencial ausente, desconhecida ou revogada
+var ErrUnauthorized = errors.New("credencial inválida")
+
+// Principal pessoa autenticada pela credencial do app
+type Principal struct {
+	Tipo     string
+	PessoaID int64
+}
+
+// ValidTipo informa se o tipo de pessoa é conhecido
+func ValidTipo(tipo string) bool {
+	return tipo == TipoIdoso || tipo == TipoCuidador
+}
+
+// IssueCredential gera uma credencial para o app da pessoa. O token só é
+// retornado aqui; o banco guarda apenas o hash.
+func (s *Service) IssueCredential(ctx context.Context, p Principal, descricao string) (string, error) {
+	if !ValidTipo(p.Tipo) {
+		return "", fmt.Errorf("tipo inválido: %s", p.Tipo)
+	}
+	if err := s.checkPerson(ctx, p); err != nil {
+		return "", err
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token := "evapp_" + hex.EncodeToString(b)
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO credenciais_app (tipo, pessoa_id, token_hash, descricao)
+		VALUES ($1, $2, $3, NULLIF($4, ''))
+	`, p.Tipo, p.PessoaID, hashToken(token), descricao)
+	if err != nil {
+		return "", fmt.Errorf("failed to issue app credential: %w", err)
+	}
+	return token, nil
+}
+
+// Authenticate resolve o token Bearer do app para a pessoa
+func (s *Service) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrUnauthorized
+	}
+
+	var p Principal
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE credenciais_app SET ultimo_uso_em = NOW()
+		WHERE token_hash = $1 AND revogado_em IS NULL
+		RETURNING tipo, pessoa_id
+	`, hashToken(token)).Scan(&p.Tipo, &p.PessoaID)
+	if err == sql.ErrNoRows {
+		return nil, ErrUnauthorized
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to authenticate app: %w", err)
+	}
+	return &p, nil
+}
+
+// RevokeCredentials revoga todas as credenciais da pessoa
+func (s *Service) RevokeCredentials(ctx context.Context, p Principal) (int, error) {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE credenciais_app SET revogado_em = NOW()
+		WHERE tipo = $1 AND pessoa_id = $2 AND revogado_em IS NULL
+	`, p.Tipo, p.PessoaID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to revoke app credentials: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// CanAccessElder informa se a pessoa autenticada pode ver os dados do idoso:
+// o próprio idoso ou um cuidador ativo vinculado a ele
+func (s *Service) CanAccessElder(ctx context.Context, p *Principal, idosoID int64) (bool, error) {
+	if p == nil {
+		return false, nil
+	}
+	if p.Tipo == TipoIdoso {
+		return p.PessoaID == idosoID, nil
+	}
+
+	var linked bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM cuidadores WHERE id = $1 AND idoso_id = $2 AND ativo = true)
+	`, p.PessoaID, idosoID).Scan(&linked)
+	if err != nil {
+		return false, fmt.Errorf("failed to check caregiver %d of elder %d: %w", p.PessoaID, idosoID, err)
+	}
+	return linked, nil
+}
+
+func (s *Service) checkPerson(ctx context.Context, p Principal) error {
+	table := "idosos"
+	if p.Tipo == TipoCuidador {
+		table = "cuidadores"
+	}
+
+	var exists bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM `+table+` WHERE id = $1)`, p.PessoaID).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to look up %s %d: %w", p.Tipo, p.PessoaID, err)
+	}
+	if !exists {
+		return fmt.Errorf("%s %d não encontrado", p.Tipo, p.PessoaID)
+	}
+	return nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,253 @@
+package devices
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"eva-mind/internal/push"
+)
+
+// Plataformas aceitas no registro
+const (
+	PlataformaAndroid = "android"
+	PlataformaIOS     = "ios"
+	PlataformaWeb     = "web"
+)
+
+// ErrInvalidToken o FCM rejeitou o token na revalidação
+var ErrInvalidToken = errors.New("device token rejeitado pelo FCM")
+
+// ErrNotFound dispositivo inexistente (ou de outra pessoa)
+var ErrNotFound = errors.New("dispositivo não encontrado")
+
+// TokenChecker revalida um token antes do registro (push.FirebaseService).
+// Retorna invalid=true quando o provedor recusa o token.
+type TokenChecker interface {
+	CheckToken(token string) (invalid bool, err error)
+}
+
+// Device dispositivo registrado por um app
+type Device struct {
+	ID               int64     `json:"id"`
+	Tipo             string    `json:"tipo"`
+	PessoaID         int64     `json:"pessoa_id"`
+	Token            string    `json:"token"`
+	Plataforma       string    `json:"plataforma"`
+	VersaoApp        string    `json:"versao_app,omitempty"`
+	Locale           string    `json:"locale,omitempty"`
+	Valido           bool      `json:"valido"`
+	UltimoRegistroEm time.Time `json:"ultimo_registro_em"`
+	CriadoEm         time.Time `json:"criado_em"`
+}
+
+// Registration corpo do registro/atualização de token
+type Registration struct {
+	Token         string `json:"token"`
+	TokenAnterior string `json:"token_anterior,omitempty"`
+	Plataforma    string `json:"plataforma"`
+	VersaoApp     string `json:"versao_app"`
+	Locale        string `json:"locale"`
+}
+
+// Validate verifica token e plataforma
+func (r *Registration) Validate() error {
+	if r.Token == "" || len(r.Token) > 4096 {
+		return fmt.Errorf("token inválido")
+	}
+	switch r.Plataforma {
+	case PlataformaAndroid, PlataformaIOS:
+	case PlataformaWeb:
+		// No painel web o token é a assinatura Web Push (PushSubscription.toJSON())
+		sub, err := push.ParseSubscription(r.Token)
+		if err != nil {
+			return err
+		}
+		r.Token = sub.String()
+	default:
+		return fmt.Errorf("plataforma inválida: use android, ios ou web")
+	}
+	if len(r.VersaoApp) > 30 || len(r.Locale) > 20 {
+		return fmt.Errorf("versao_app ou locale muito longo")
+	}
+	return nil
+}
+
+// Service registro de dispositivos e credenciais dos apps
+type Service struct {
+	db      *sql.DB
+	checker TokenChecker
+}
+
+// NewService cria o serviço de dispositivos. checker pode ser nil (sem revalidação).
+func NewService(db *sql.DB, checker TokenChecker) *Service {
+	return &Service{db: db, checker: checker}
+}
+
+// Register grava (ou atualiza) o dispositivo da pessoa, revalidando o token no
+// FCM (assinaturas web já foram validadas em Validate). token_anterior, se
+// informado, é substituído pelo novo. O dispositivo Android/iOS mais recente
+// vira o device_token da pessoa.
+func (s *Service) Register(ctx context.Context, p Principal, reg Registration) (*Device, error) {
+	if err := reg.Validate(); err != nil {
+		return nil, err
+	}
+
+	if s.checker != nil && reg.Plataforma != PlataformaWeb {
+		invalid, err := s.checker.CheckToken(reg.Token)
+		if invalid {
+			return nil, ErrInvalidToken
+		}
+		if err != nil {
+			// FCM indisponível não impede o registro; o envio real invalida depois
+			log.Printf("⚠️ Revalidação do token de %s %d falhou: %v", p.Tipo, p.PessoaID, err)
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// O token pode ter sido de outra pessoa (aparelho compartilhado)
+	var previous Principal
+	err = tx.QueryRowContext(ctx, `SELECT tipo, pessoa_id FROM dispositivos WHERE token = $1`, reg.Token).
+		Scan(&previous.Tipo, &previous.PessoaID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to look up device: %w", err)
+	}
+
+	if reg.TokenAnterior != "" && reg.TokenAnterior != reg.Token {
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM dispositivos WHERE token = $1 AND tipo = $2 AND pessoa_id = $3
+		`, reg.TokenAnterior, p.Tipo, p.PessoaID); err != nil {
+			return nil, fmt.Errorf("failed to replace device token: %w", err)
+		}
+	}
+
+	d := Device{Tipo: p.Tipo, PessoaID: p.PessoaID, Token: reg.Token, Plataforma: reg.Plataforma,
+		VersaoApp: reg.VersaoApp, Locale: reg.Locale, Valido: true}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO dispositivos (tipo, pessoa_id, token, plataforma, versao_app, locale)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''))
+		ON CONFLICT (token) DO UPDATE SET
+			tipo = EXCLUDED.tipo,
+			pessoa_id = EXCLUDED.pessoa_id,
+			plataforma = EXCLUDED.plataforma,
+			versao_app = EXCLUDED.versao_app,
+			locale = EXCLUDED.locale,
+			valido = true,
+			invalidado_em = NULL,
+			ultimo_registro_em = NOW()
+		RETURNING id, ultimo_registro_em, criado_em
+	`, p.Tipo, p.PessoaID, reg.Token, reg.Plataforma, reg.VersaoApp, reg.Locale).Scan(&d.ID, &d.UltimoRegistroEm, &d.CriadoEm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to register device: %w", err)
+	}
+
+	if err := mirror(ctx, tx, p, reg.TokenAnterior); err != nil {
+		return nil, err
+	}
+	if previous.PessoaID != 0 && previous != p {
+		if err := mirror(ctx, tx, previous, reg.Token); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("📱 Dispositivo %s registrado para %s %d", d.Plataforma, p.Tipo, p.PessoaID)
+	return &d, nil
+}
+
+// List dispositivos da pessoa, do mais recente para o mais antigo
+func (s *Service) List(ctx context.Context, p Principal) ([]Device, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, tipo, pessoa_id, token, plataforma, COALESCE(versao_app, ''), COALESCE(locale, ''),
+		       valido, ultimo_registro_em, criado_em
+		FROM dispositivos
+		WHERE tipo = $1 AND pessoa_id = $2
+		ORDER BY ultimo_registro_em DESC
+	`, p.Tipo, p.PessoaID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list devices: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Device{}
+	for rows.Next() {
+		var d Device
+		if err := rows.Scan(&d.ID, &d.Tipo, &d.PessoaID, &d.Token, &d.Plataforma, &d.VersaoApp, &d.Locale,
+			&d.Valido, &d.UltimoRegistroEm, &d.CriadoEm); err != nil {
+			return nil, err
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// Remove apaga o dispositivo (logout no app)
+func (s *Service) Remove(ctx context.Context, p Principal, id int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var token string
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM dispositivos WHERE id = $1 AND tipo = $2 AND pessoa_id = $3
+		RETURNING token
+	`, id, p.Tipo, p.PessoaID).Scan(&token)
+	if err == sql.ErrNoRows {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to remove device: %w", err)
+	}
+
+	if err := mirror(ctx, tx, p, token); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// mirror copia o dispositivo FCM válido mais recente para device_token da pessoa.
+// Se removed era o token espelhado e não sobrou nenhum, a coluna é limpa.
+func mirror(ctx context.Context, tx *sql.Tx, p Principal, removed string) error {
+	table := "idosos"
+	if p.Tipo == TipoCuidador {
+		table = "cuidadores"
+	}
+
+	if removed != "" {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE `+table+` SET device_token = NULL, device_token_atualizado_em = NOW()
+			WHERE id = $1 AND device_token = $2
+		`, p.PessoaID, removed); err != nil {
+			return fmt.Errorf("failed to clear %s device token: %w", p.Tipo, err)
+		}
+	}
+
+	_, err := tx.ExecContext(ctx, `
+		UPDATE `+table+` t
+		SET device_token = d.token, device_token_valido = true, device_token_atualizado_em = NOW()
+		FROM (
+			SELECT token FROM dispositivos
+			WHERE tipo = $1 AND pessoa_id = $2 AND valido AND plataforma <> 'web'
+			ORDER BY ultimo_registro_em DESC
+			LIMIT 1
+		) d
+		WHERE t.id = $2
+	`, p.Tipo, p.PessoaID)
+	if err != nil {
+		return fmt.Errorf("failed to update %s device token: %w", p.Tipo, err)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package email
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Envelope destinatário do email e de quem ele trata
+type Envelope struct {
+	To         string
+	Name       string
+	IdosoID    int64  // fuso do idoso e marca da entidade (0 = padrão)
+	CuidadorID int64  // idioma dos aparelhos do cuidador (0 = idioma da entidade)
+	Locale     string // força o idioma; vazio = do cuidador ou da entidade
+	AlertaID   int64  // alerta que originou o email (fila)
+}
+
+// audience idioma, fuso e marca resolvidos para o envelope
+type audience struct {
+	locale   string
+	location *time.Location
+	brand    Branding
+}
+
+var colorPattern = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
+
+// EnableLocalization passa a buscar no banco o fuso do idoso, o idioma do
+// cuidador e a marca da entidade. Sem isso os emails saem em pt-BR, no fuso
+// padrão e com a marca da EVA.
+func (s *EmailService) EnableLocalization(db *sql.DB) *EmailService {
+	s.db = db
+	return s
+}
+
+// audience resolve idioma, fuso e marca; falhas na consulta caem no padrão
+func (s *EmailService) audience(ctx context.Context, env Envelope) audience {
+	var fuso, idiomaCuidador, idiomaEntidade string
+	var brand Branding
+
+	if s.db != nil && env.IdosoID != 0 {
+		err := s.db.QueryRowContext(ctx, `
+			SELECT COALESCE(i.fuso_horario, ''),
+			       COALESCE(m.nome_exibicao, ''), COALESCE(m.logo_url, ''), COALESCE(m.cor_primaria, ''),
+			       COALESCE(m.cor_alerta, ''), COALESCE(m.rodape, ''), COALESCE(m.idioma, ''),
+			       COALESCE((SELECT d.locale FROM dispositivos d
+			                 WHERE d.tipo = 'cuidador' AND d.pessoa_id = $2 AND d.valido AND COALESCE(d.locale, '') <> ''
+			                 ORDER BY d.ultimo_registro_em DESC LIMIT 1), '')
+			FROM idosos i
+			LEFT JOIN email_marcas m ON m.entidade_nome = i.entidade_nome
+			WHERE i.id = $1
+		`, env.IdosoID, env.CuidadorID).Scan(&fuso, &brand.Nome, &brand.LogoURL, &brand.CorPrimaria,
+			&brand.CorAlerta, &brand.Rodape, &idiomaEntidade, &idiomaCuidador)
+		if err != nil && err != sql.ErrNoRows {
+			log.Printf("⚠️ Email sem localização para o idoso %d: %v", env.IdosoID, err)
+		}
+	}
+
+	a := audience{brand: sanitizeBrand(brand)}
+	for _, l := range []string{env.Locale, idiomaCuidador, idiomaEntidade} {
+		if a.locale = MatchLocale(l); a.locale != "" {
+			break
+		}
+	}
+	if a.locale == "" {
+		a.locale = DefaultLocale
+	}
+
+	loc, err := time.LoadLocation(fuso)
+	if fuso == "" || err != nil {
+		loc, err = time.LoadLocation(DefaultTimeZone)
+		if err != nil {
+			loc = time.UTC
+		}
+	}
+	a.location = loc
+	return a
+}
+
+// sanitizeBrand descarta cor ou logo fora do formato (o CSS e o src do HTML
+// não aceitam qualquer valor)
+func sanitizeBrand(b Branding) Branding {
+	if !colorPattern.MatchString(b.CorPrimaria) {
+		b.CorPrimaria = ""
+	}
+	if !colorPattern.MatchString(b.CorAlerta) {
+		b.CorAlerta = ""
+	}
+	if !strings.HasPrefix(b.LogoURL, "https://") {
+		b.LogoURL = ""
+	}
+	return b
+}
@@ -1,46 +1,41 @@
 package email
 
 import (
+	"context"
+	"database/sql"
 	"eva-mind/internal/config"
 	"fmt"
-
-	"gopkg.in/gomail.v2"
 )
 
 type EmailService struct {
-	cfg    *config.Config
-	dialer *gomail.Dialer
+	cfg       *config.Config
+	transport Transport
+	db        *sql.DB
+	queue     *Queue
 }
 
-// NewEmailService cria uma nova instância do serviço de email
+// NewEmailService cria o serviço de email com o transporte da config
+// (EMAIL_TRANSPORT: smtp, http ou arquivo)
 func NewEmailService(cfg *config.Config) (*EmailService, error) {
-	if cfg.SMTPUsername == "" || cfg.SMTPPassword == "" {
-		return nil, fmt.Errorf("SMTP credentials not configured")
+	transport, err := NewTransport(cfg)
+	if err != nil {
+		return nil, err
 	}
+	return NewEmailServiceWithTransport(cfg, transport), nil
+}
 
-	dialer := gomail.NewDialer(
-		cfg.SMTPHost,
-		cfg.SMTPPort,
-		cfg.SMTPUsername,
-		cfg.SMTPPassword,
-	)
-
+// NewEmailServiceWithTransport cria o serviço com um transporte já montado
+func NewEmailServiceWithTransport(cfg *config.Config, transport Transport) *EmailService {
 	return &EmailService{
-		cfg:    cfg,
-		dialer: dialer,
-	}, nil
+		cfg:       cfg,
+		transport: transport,
+	}
 }
 
-// SendEmail envia um email com HTML
-func (s *EmailService) SendEmail(to, subject, htmlBody string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("From", fmt.Sprintf("%s <%s>", s.cfg.SMTPFromName, s.cfg.SMTPFromEmail))
-	m.SetHeader("To", to)
-	m.SetHeader("Subject", subject)
-	m.SetBody("text/html", htmlBody)
-
-	if err := s.dialer.DialAndSend(m); err != nil {
-		return fmt.Errorf("failed to send email: %w", err)
+// SendEmail envia um email na hora, sem passar pela fila
+func (s *EmailService) SendEmail(ctx context.Context, to string, msg *Message) error {
+	if err := s.transport.Send(ctx, to, msg); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", s.transport.Name(), err)
 	}
 
 	return nil
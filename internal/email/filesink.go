@@ -0,0 +1,71 @@
+package email
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// FileTransport grava cada email como .eml numa pasta Maildir (tmp/, new/,
+// cur/), para desenvolvimento e testes: nada sai da máquina
+type FileTransport struct {
+	dir  string
+	from Sender
+	seq  atomic.Int64
+}
+
+// NewFileTransport cria as pastas do Maildir em dir
+func NewFileTransport(dir string, from Sender) (*FileTransport, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("EMAIL_DIR is required")
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create email dir: %w", err)
+		}
+	}
+	return &FileTransport{dir: dir, from: from}, nil
+}
+
+func (t *FileTransport) Name() string { return TransportArquivo }
+
+// Send escreve em tmp/ e move para new/, como manda o Maildir
+func (t *FileTransport) Send(ctx context.Context, to string, msg *Message) error {
+	name := fmt.Sprintf("%d.%d_%d.%s.eml", time.Now().Unix(), os.Getpid(), t.seq.Add(1), sanitizeFileName(to))
+	tmp := filepath.Join(t.dir, "tmp", name)
+
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+	if _, err := mimeMessage(t.from, to, msg).WriteTo(f); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+
+	if err := os.Rename(tmp, filepath.Join(t.dir, "new", name)); err != nil {
+		return fmt.Errorf("failed to write email file: %w", err)
+	}
+	return nil
+}
+
+func (t *FileTransport) Close() error { return nil }
+
+// sanitizeFileName mantém só caracteres seguros do endereço no nome do arquivo
+func sanitizeFileName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '@', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, s)
+}
@@ -0,0 +1,97 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultEmailAPIURL endpoint padrão do transporte HTTP (API v3 do SendGrid)
+const DefaultEmailAPIURL = "https://api.sendgrid.com/v3/mail/send"
+
+// HTTPTransport envia pela API de email transacional (formato mail/send v3)
+type HTTPTransport struct {
+	client *http.Client
+	url    string
+	apiKey string
+	from   Sender
+}
+
+// NewHTTPTransport cria o transporte HTTP
+func NewHTTPTransport(url, apiKey string, from Sender) (*HTTPTransport, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("EMAIL_API_KEY is required")
+	}
+	if from.Email == "" {
+		return nil, fmt.Errorf("SMTP_FROM_EMAIL is required")
+	}
+	if url == "" {
+		url = DefaultEmailAPIURL
+	}
+	return &HTTPTransport{
+		client: &http.Client{Timeout: 15 * time.Second},
+		url:    url,
+		apiKey: apiKey,
+		from:   from,
+	}, nil
+}
+
+func (t *HTTPTransport) Name() string { return TransportHTTP }
+
+type apiAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type apiContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Send envia a mensagem; 4xx (exceto 429) é recusa definitiva, 429/5xx é temporário
+func (t *HTTPTransport) Send(ctx context.Context, to string, msg *Message) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"personalizations": []map[string]interface{}{{"to": []apiAddress{{Email: to}}}},
+		"from":             apiAddress{Email: t.from.Email, Name: t.from.Name},
+		"subject":          msg.Subject,
+		"content": []apiContent{
+			{Type: "text/plain", Value: msg.Text},
+			{Type: "text/html", Value: msg.HTML},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode email: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error calling email API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 300 {
+		return nil
+	}
+
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("email API returned %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+	return err
+}
+
+// Close não faz nada: o http.Client já reaproveita conexões
+func (t *HTTPTransport) Close() error { return nil }
@@ -0,0 +1,217 @@
+package email
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Idiomas dos emails
+const (
+	LocalePortuguese = "pt-BR"
+	LocaleEnglish    = "en"
+	LocaleSpanish    = "es"
+)
+
+// DefaultLocale idioma usado sem preferência do cuidador ou da entidade
+const DefaultLocale = LocalePortuguese
+
+// DefaultTimeZone fuso usado quando o idoso não tem um válido
+const DefaultTimeZone = "America/Sao_Paulo"
+
+// MatchLocale escolhe o idioma suportado mais próximo ("pt_PT" → pt-BR, "en-US" → en).
+// Vazio ou desconhecido retorna "".
+func MatchLocale(locale string) string {
+	lang := strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	switch lang {
+	case "pt":
+		return LocalePortuguese
+	case "en":
+		return LocaleEnglish
+	case "es":
+		return LocaleSpanish
+	}
+	return ""
+}
+
+// dateLayouts formato de data/hora e de dia por idioma
+var dateLayouts = map[string][2]string{
+	LocalePortuguese: {"02/01/2006 15:04", "02/01/2006"},
+	LocaleEnglish:    {"Jan 2, 2006 3:04 PM", "Jan 2, 2006"},
+	LocaleSpanish:    {"02/01/2006 15:04", "02/01/2006"},
+}
+
+// messages textos dos templates por idioma; chaves ausentes caem no pt-BR
+var messages = map[string]map[string]string{
+	LocalePortuguese: {
+		"greeting":         "Olá",
+		"footer.auto":      "Este é um email automático de %s",
+		"footer.noreply":   "Não responda a este email",
+		"datetime":         "Data/Hora",
+		"elder":            "Idoso",
+		"missed.subject":   "⚠️ Chamada Não Atendida - %s",
+		"missed.title":     "⚠️ Chamada Não Atendida",
+		"missed.label":     "ALERTA:",
+		"missed.text":      "%s não atendeu a chamada programada da EVA.",
+		"missed.check":     "Por favor, verifique se está tudo bem com o idoso.",
+		"missed.actions":   "Ações recomendadas:",
+		"missed.action1":   "Ligar para o idoso para verificar se está tudo bem",
+		"missed.action2":   "Verificar se o dispositivo móvel está funcionando",
+		"missed.action3":   "Verificar se as notificações estão habilitadas no app",
+		"alert.subject":    "🚨 ALERTA CRÍTICO - %s",
+		"alert.title":      "🚨 ALERTA CRÍTICO",
+		"alert.label":      "EMERGÊNCIA DETECTADA:",
+		"alert.action":     "⚠️ AÇÃO IMEDIATA NECESSÁRIA",
+		"alert.text":       "Por favor, entre em contato com o idoso imediatamente ou acione serviços de emergência se necessário.",
+		"report.subject":   "📋 Resumo Semanal - %s (%s a %s)",
+		"report.title":     "📋 Resumo Semanal",
+		"report.period":    "%s a %s",
+		"digest.subject":   "🗒️ Avisos da EVA sobre %s (%d)",
+		"digest.title":     "🗒️ Avisos da EVA",
+		"digest.intro":     "Estes avisos foram guardados de acordo com suas preferências de notificação:",
+		"brand.name":       "EVA - Assistente Virtual para Idosos",
+		"recipient.anonym": "Cuidador",
+
+		"nodata":                 "sem dados",
+		"weekly.subject":         "🌿 A semana de %s (%s a %s)",
+		"weekly.title":           "🌿 Resumo da Semana",
+		"weekly.calm":            "Boa notícia: foi uma semana tranquila para %s. Veja como foi:",
+		"weekly.intro":           "Veja como foi a semana de %s:",
+		"weekly.calls.completed": "Conversas com a EVA",
+		"weekly.calls.missed":    "Chamadas não atendidas",
+		"weekly.adherence":       "Adesão à medicação",
+		"weekly.mood":            "Humor em relação à semana anterior",
+		"weekly.mood.melhorou":   "melhorou",
+		"weekly.mood.estavel":    "estável",
+		"weekly.mood.piorou":     "piorou",
+		"weekly.alerts":          "Alertas aguardando resposta: %d",
+		"weekly.patterns":        "Novos padrões observados",
+		"weekly.risks":           "Novos riscos identificados",
+		"weekly.risk":            "%s — risco %s (%s)",
+		"weekly.unsubscribe":     "Para não receber mais este resumo, desmarque \"resumo_semanal\" nas preferências de notificação do app.",
+	},
+	LocaleEnglish: {
+		"greeting":         "Hello",
+		"footer.auto":      "This is an automatic email from %s",
+		"footer.noreply":   "Please do not reply to this email",
+		"datetime":         "Date/Time",
+		"elder":            "Senior",
+		"missed.subject":   "⚠️ Missed Call - %s",
+		"missed.title":     "⚠️ Missed Call",
+		"missed.label":     "ALERT:",
+		"missed.text":      "%s did not answer the scheduled EVA call.",
+		"missed.check":     "Please check that everything is all right.",
+		"missed.actions":   "Recommended actions:",
+		"missed.action1":   "Call to check that everything is all right",
+		"missed.action2":   "Check that the mobile device is working",
+		"missed.action3":   "Check that notifications are enabled in the app",
+		"alert.subject":    "🚨 CRITICAL ALERT - %s",
+		"alert.title":      "🚨 CRITICAL ALERT",
+		"alert.label":      "EMERGENCY DETECTED:",
+		"alert.action":     "⚠️ IMMEDIATE ACTION REQUIRED",
+		"alert.text":       "Please contact them immediately or call emergency services if needed.",
+		"report.subject":   "📋 Weekly Summary - %s (%s to %s)",
+		"report.title":     "📋 Weekly Summary",
+		"report.period":    "%s to %s",
+		"digest.subject":   "🗒️ EVA notices about %s (%d)",
+		"digest.title":     "🗒️ EVA Notices",
+		"digest.intro":     "These notices were held according to your notification preferences:",
+		"brand.name":       "EVA - Virtual Assistant for Seniors",
+		"recipient.anonym": "Caregiver",
+
+		"nodata":                 "no data",
+		"weekly.subject":         "🌿 %s's week (%s to %s)",
+		"weekly.title":           "🌿 Weekly Digest",
+		"weekly.calm":            "Good news: it was a quiet week for %s. Here is how it went:",
+		"weekly.intro":           "Here is how %s's week went:",
+		"weekly.calls.completed": "Conversations with EVA",
+		"weekly.calls.missed":    "Missed calls",
+		"weekly.adherence":       "Medication adherence",
+		"weekly.mood":            "Mood compared to last week",
+		"weekly.mood.melhorou":   "improved",
+		"weekly.mood.estavel":    "stable",
+		"weekly.mood.piorou":     "worse",
+		"weekly.alerts":          "Alerts awaiting a response: %d",
+		"weekly.patterns":        "New patterns observed",
+		"weekly.risks":           "New risks identified",
+		"weekly.risk":            "%s — %s risk (%s)",
+		"weekly.unsubscribe":     "To stop receiving this digest, uncheck \"resumo_semanal\" in the app's notification preferences.",
+	},
+	LocaleSpanish: {
+		"greeting":         "Hola",
+		"footer.auto":      "Este es un correo automático de %s",
+		"footer.noreply":   "No responda a este correo",
+		"datetime":         "Fecha/Hora",
+		"elder":            "Adulto mayor",
+		"missed.subject":   "⚠️ Llamada No Atendida - %s",
+		"missed.title":     "⚠️ Llamada No Atendida",
+		"missed.label":     "ALERTA:",
+		"missed.text":      "%s no atendió la llamada programada de EVA.",
+		"missed.check":     "Por favor, verifique que todo esté bien.",
+		"missed.actions":   "Acciones recomendadas:",
+		"missed.action1":   "Llamar para verificar que todo esté bien",
+		"missed.action2":   "Verificar que el dispositivo móvil funcione",
+		"missed.action3":   "Verificar que las notificaciones estén habilitadas en la app",
+		"alert.subject":    "🚨 ALERTA CRÍTICA - %s",
+		"alert.title":      "🚨 ALERTA CRÍTICA",
+		"alert.label":      "EMERGENCIA DETECTADA:",
+		"alert.action":     "⚠️ SE REQUIERE ACCIÓN INMEDIATA",
+		"alert.text":       "Por favor, comuníquese de inmediato o llame a los servicios de emergencia si es necesario.",
+		"report.subject":   "📋 Resumen Semanal - %s (%s a %s)",
+		"report.title":     "📋 Resumen Semanal",
+		"report.period":    "%s a %s",
+		"digest.subject":   "🗒️ Avisos de EVA sobre %s (%d)",
+		"digest.title":     "🗒️ Avisos de EVA",
+		"digest.intro":     "Estos avisos se guardaron según sus preferencias de notificación:",
+		"brand.name":       "EVA - Asistente Virtual para Adultos Mayores",
+		"recipient.anonym": "Cuidador",
+
+		"nodata":                 "sin datos",
+		"weekly.subject":         "🌿 La semana de %s (%s a %s)",
+		"weekly.title":           "🌿 Resumen de la Semana",
+		"weekly.calm":            "Buenas noticias: fue una semana tranquila para %s. Así fue:",
+		"weekly.intro":           "Así fue la semana de %s:",
+		"weekly.calls.completed": "Conversaciones con EVA",
+		"weekly.calls.missed":    "Llamadas no atendidas",
+		"weekly.adherence":       "Adherencia a la medicación",
+		"weekly.mood":            "Ánimo respecto a la semana anterior",
+		"weekly.mood.melhorou":   "mejoró",
+		"weekly.mood.estavel":    "estable",
+		"weekly.mood.piorou":     "empeoró",
+		"weekly.alerts":          "Alertas esperando respuesta: %d",
+		"weekly.patterns":        "Nuevos patrones observados",
+		"weekly.risks":           "Nuevos riesgos identificados",
+		"weekly.risk":            "%s — riesgo %s (%s)",
+		"weekly.unsubscribe":     "Para dejar de recibir este resumen, desmarque \"resumo_semanal\" en las preferencias de notificación de la app.",
+	},
+}
+
+// translate texto da chave no idioma, com os argumentos no estilo fmt
+func translate(locale, key string, args ...interface{}) string {
+	msg, ok := messages[locale][key]
+	if !ok {
+		if msg, ok = messages[DefaultLocale][key]; !ok {
+			return key
+		}
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// formatTime data/hora no fuso e no formato do idioma. Dias (withTime false)
+// são datas de calendário e não passam pelo fuso.
+func formatTime(locale string, loc *time.Location, t time.Time, withTime bool) string {
+	layouts, ok := dateLayouts[locale]
+	if !ok {
+		layouts = dateLayouts[DefaultLocale]
+	}
+	if withTime {
+		return t.In(loc).Format(layouts[0])
+	}
+	return t.Format(layouts[1])
+}
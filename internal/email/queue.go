@@ -0,0 +1,278 @@
+package email
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Status possíveis de um email em email_outbox
+const (
+	QueuePendente        = "pendente"
+	QueueEnviando        = "enviando"
+	QueueEnviado         = "enviado"
+	QueueExpirado        = "expirado"
+	QueueFalhaDefinitiva = "falha_definitiva"
+)
+
+const (
+	queueBaseBackoff = 30 * time.Second
+	queueMaxBackoff  = 30 * time.Minute
+
+	// Emails presos em "enviando" por mais tempo que isso são retomados
+	queueStaleTimeout = 5 * time.Minute
+
+	// alertEmailTTL emails de alerta e chamada perdida perdem o sentido depois disso
+	alertEmailTTL = 6 * time.Hour
+)
+
+// QueueItem email reservado para envio
+type QueueItem struct {
+	ID            int64
+	Template      string
+	To            string
+	AlertaID      int64
+	Tentativas    int
+	MaxTentativas int
+	Message       Message
+}
+
+// QueueStats profundidade e resultado recente da fila de email
+type QueueStats struct {
+	Pendentes      int            `json:"pendentes"`
+	PorTemplate    map[string]int `json:"por_template"`
+	MaisAntigaEm   *time.Time     `json:"mais_antiga_em,omitempty"`
+	AtrasoSegundos int            `json:"atraso_segundos"`
+	Enviados24h    int            `json:"enviados_24h"`
+	Expirados24h   int            `json:"expirados_24h"`
+	FalhasDef24h   int            `json:"falhas_definitivas_24h"`
+}
+
+// Queue fila persistente de email
+type Queue struct {
+	db *sql.DB
+}
+
+// NewQueue cria a fila de email
+func NewQueue(db *sql.DB) *Queue {
+	return &Queue{db: db}
+}
+
+// EnableQueue passa a gravar os emails na fila em vez de enviar na hora; o
+// EmailQueueWorker faz o envio
+func (s *EmailService) EnableQueue(db *sql.DB) *EmailService {
+	s.queue = NewQueue(db)
+	return s
+}
+
+// enqueue guarda o email já renderizado. Alertas expiram em alertEmailTTL.
+func (q *Queue) enqueue(ctx context.Context, env Envelope, template string, msg *Message) (int64, error) {
+	var alerta, expira interface{}
+	if env.AlertaID != 0 {
+		alerta = env.AlertaID
+	}
+	if template == TemplateMissedCall || template == TemplateEmergencyAlert {
+		expira = time.Now().Add(alertEmailTTL)
+	}
+
+	var id int64
+	err := q.db.QueryRowContext(ctx, `
+		INSERT INTO email_outbox (template, destinatario, assunto, texto, html, alerta_id, expira_em)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id
+	`, template, env.To, msg.Subject, msg.Text, msg.HTML, alerta, expira).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue email: %w", err)
+	}
+	return id, nil
+}
+
+// Claim expira os emails vencidos e reserva até limit emails prontos
+func (q *Queue) Claim(ctx context.Context, limit int) ([]QueueItem, error) {
+	if err := q.expire(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := q.db.QueryContext(ctx, `
+		UPDATE email_outbox
+		SET status = 'enviando',
+		    tentativas = tentativas + 1,
+		    iniciado_em = NOW(),
+		    atualizado_em = NOW()
+		WHERE id IN (
+			SELECT id FROM email_outbox
+			WHERE (expira_em IS NULL OR expira_em > NOW())
+			  AND ((status = 'pendente' AND proxima_tentativa <= NOW())
+			    OR (status = 'enviando' AND iniciado_em < NOW() - make_interval(secs => $2)))
+			ORDER BY proxima_tentativa ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, template, destinatario, assunto, texto, html, COALESCE(alerta_id, 0),
+		          tentativas, max_tentativas
+	`, limit, queueStaleTimeout.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim queued emails: %w", err)
+	}
+	defer rows.Close()
+
+	var list []QueueItem
+	for rows.Next() {
+		var item QueueItem
+		if err := rows.Scan(&item.ID, &item.Template, &item.To, &item.Message.Subject, &item.Message.Text,
+			&item.Message.HTML, &item.AlertaID, &item.Tentativas, &item.MaxTentativas); err != nil {
+			return nil, fmt.Errorf("failed to scan queued email: %w", err)
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// expire marca como expirados os emails de alerta que passaram da validade
+func (q *Queue) expire(ctx context.Context) error {
+	res, err := q.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = 'expirado', atualizado_em = NOW()
+		WHERE status IN ('pendente', 'enviando') AND expira_em <= NOW()
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to expire queued emails: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		log.Printf("⌛ %d email(s) de alerta expiraram na fila sem ser enviados", n)
+	}
+	return nil
+}
+
+// DeliverQueued envia o lote pelo transporte, que reaproveita a conexão entre
+// os emails (SMTP). Recusa definitiva do provedor encerra o email; o resto volta à fila.
+func (s *EmailService) DeliverQueued(ctx context.Context, q *Queue, items []QueueItem) error {
+	defer s.transport.Close()
+
+	for _, item := range items {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		err := s.transport.Send(ctx, item.To, &item.Message)
+		switch {
+		case err == nil:
+			log.Printf("📧 Email %s #%d enviado para %s via %s (tentativa %d)", item.Template, item.ID, item.To, s.transport.Name(), item.Tentativas)
+			if ferr := q.finish(ctx, item, QueueEnviado, nil); ferr != nil {
+				return ferr
+			}
+		case errors.Is(err, ErrRejected):
+			if ferr := q.finish(ctx, item, QueueFalhaDefinitiva, err); ferr != nil {
+				return ferr
+			}
+		default:
+			if rerr := q.retry(ctx, item, err); rerr != nil {
+				return rerr
+			}
+		}
+	}
+	return nil
+}
+
+// retry devolve o email à fila com backoff, ou encerra sem tentativas restantes
+func (q *Queue) retry(ctx context.Context, item QueueItem, cause error) error {
+	if item.Tentativas >= item.MaxTentativas {
+		return q.finish(ctx, item, QueueFalhaDefinitiva, cause)
+	}
+
+	next := queueBackoff(item.Tentativas)
+	log.Printf("⚠️ Email %s #%d: tentativa %d falhou, nova tentativa em %v: %v", item.Template, item.ID, item.Tentativas, next, cause)
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = 'pendente', ultimo_erro = $2,
+		    proxima_tentativa = NOW() + make_interval(secs => $3), atualizado_em = NOW()
+		WHERE id = $1
+	`, item.ID, cause.Error(), next.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to reschedule queued email: %w", err)
+	}
+	return nil
+}
+
+// finish grava o status final do email
+func (q *Queue) finish(ctx context.Context, item QueueItem, status string, cause error) error {
+	erro := ""
+	if cause != nil {
+		erro = cause.Error()
+		log.Printf("❌ Email %s #%d para %s: %s após %d tentativa(s): %v", item.Template, item.ID, item.To, status, item.Tentativas, cause)
+	}
+
+	_, err := q.db.ExecContext(ctx, `
+		UPDATE email_outbox
+		SET status = $2, ultimo_erro = NULLIF($3, ''),
+		    enviado_em = CASE WHEN $2 = 'enviado' THEN NOW() END, atualizado_em = NOW()
+		WHERE id = $1
+	`, item.ID, status, erro)
+	if err != nil {
+		return fmt.Errorf("failed to finish queued email: %w", err)
+	}
+	return nil
+}
+
+// Stats profundidade da fila e resultado das últimas 24h
+func (q *Queue) Stats(ctx context.Context) (*QueueStats, error) {
+	stats := &QueueStats{PorTemplate: map[string]int{}}
+
+	rows, err := q.db.QueryContext(ctx, `
+		SELECT template, COUNT(*), MIN(criado_em)
+		FROM email_outbox
+		WHERE status IN ('pendente', 'enviando')
+		GROUP BY template
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query email outbox: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var template string
+		var n int
+		var oldest time.Time
+		if err := rows.Scan(&template, &n, &oldest); err != nil {
+			return nil, err
+		}
+		stats.PorTemplate[template] = n
+		stats.Pendentes += n
+		if stats.MaisAntigaEm == nil || oldest.Before(*stats.MaisAntigaEm) {
+			stats.MaisAntigaEm = &oldest
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if stats.MaisAntigaEm != nil {
+		stats.AtrasoSegundos = int(time.Since(*stats.MaisAntigaEm).Seconds())
+	}
+
+	err = q.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status = 'enviado'),
+		       COUNT(*) FILTER (WHERE status = 'expirado'),
+		       COUNT(*) FILTER (WHERE status = 'falha_definitiva')
+		FROM email_outbox
+		WHERE criado_em > NOW() - INTERVAL '24 hours'
+	`).Scan(&stats.Enviados24h, &stats.Expirados24h, &stats.FalhasDef24h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query email outbox: %w", err)
+	}
+	return stats, nil
+}
+
+// queueBackoff espera antes da próxima tentativa (30s, 1min, 2min... até 30min)
+func queueBackoff(attempt int) time.Duration {
+	d := queueBaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= queueMaxBackoff {
+			return queueMaxBackoff
+		}
+	}
+	return d
+}
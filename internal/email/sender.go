@@ -1,34 +1,63 @@
 package email
 
 import (
-	"fmt"
+	"context"
 	"log"
+	"time"
 )
 
-// SendMissedCallAlert envia email de chamada perdida
-func (s *EmailService) SendMissedCallAlert(caregiverEmail, caregiverName, elderName string) error {
-	subject := fmt.Sprintf("⚠️ Chamada Não Atendida - %s", elderName)
-	htmlBody := MissedCallAlertTemplate(elderName, caregiverName)
+// SendMissedCallAlert envia email de chamada perdida; at é quando a chamada
+// deveria ter sido atendida
+func (s *EmailService) SendMissedCallAlert(ctx context.Context, env Envelope, elderName string, at time.Time) error {
+	return s.send(ctx, env, TemplateMissedCall, elderName, alertData{At: at}, "chamada perdida")
+}
 
-	if err := s.SendEmail(caregiverEmail, subject, htmlBody); err != nil {
-		log.Printf("❌ Erro ao enviar email de chamada perdida: %v", err)
-		return err
-	}
+// SendEmergencyAlert envia email de emergência. reason pode vir do modelo e é
+// escapada no HTML.
+func (s *EmailService) SendEmergencyAlert(ctx context.Context, env Envelope, elderName, reason string, at time.Time) error {
+	return s.send(ctx, env, TemplateEmergencyAlert, elderName, alertData{Reason: reason, At: at}, "emergência")
+}
 
-	log.Printf("📧 Email de chamada perdida enviado para: %s", caregiverEmail)
-	return nil
+// SendWeeklyReport envia o resumo clínico semanal da semana start..end
+func (s *EmailService) SendWeeklyReport(ctx context.Context, env Envelope, elderName string, start, end time.Time, narrative string, tables []ReportTable) error {
+	data := reportData{Start: start, End: end, Narrative: narrative, Tables: tables}
+	return s.send(ctx, env, TemplateWeeklyReport, elderName, data, "resumo semanal")
+}
+
+// SendNotificationDigest envia as notificações adiadas em um único email
+func (s *EmailService) SendNotificationDigest(ctx context.Context, env Envelope, elderName string, items []DigestItem) error {
+	return s.send(ctx, env, TemplateNotificationDigest, elderName, digestData{Items: items}, "resumo de notificações")
 }
 
-// SendEmergencyAlert envia email de emergência
-func (s *EmailService) SendEmergencyAlert(caregiverEmail, caregiverName, elderName, reason string) error {
-	subject := fmt.Sprintf("🚨 ALERTA CRÍTICO - %s", elderName)
-	htmlBody := EmergencyAlertTemplate(elderName, caregiverName, reason)
+// SendCaregiverWeekly envia o resumo semanal de tranquilidade do cuidador
+func (s *EmailService) SendCaregiverWeekly(ctx context.Context, env Envelope, elderName string, weekly CaregiverWeekly) error {
+	return s.send(ctx, env, TemplateCaregiverWeekly, elderName, weekly, "resumo semanal do cuidador")
+}
+
+// send renderiza o template no idioma, fuso e marca do destinatário e envia,
+// ou grava na fila quando ela está habilitada (ver EnableQueue)
+func (s *EmailService) send(ctx context.Context, env Envelope, template, elderName string, data interface{}, label string) error {
+	msg, err := render(template, s.audience(ctx, env), env.Name, elderName, data)
+	if err != nil {
+		log.Printf("❌ Erro ao montar email de %s: %v", label, err)
+		return err
+	}
+
+	if s.queue != nil {
+		id, err := s.queue.enqueue(ctx, env, template, msg)
+		if err != nil {
+			log.Printf("❌ Erro ao enfileirar email de %s: %v", label, err)
+			return err
+		}
+		log.Printf("📥 Email de %s na fila (#%d) para: %s", label, id, env.To)
+		return nil
+	}
 
-	if err := s.SendEmail(caregiverEmail, subject, htmlBody); err != nil {
-		log.Printf("❌ Erro ao enviar email de emergência: %v", err)
+	if err := s.SendEmail(ctx, env.To, msg); err != nil {
+		log.Printf("❌ Erro ao enviar email de %s: %v", label, err)
 		return err
 	}
 
-	log.Printf("📧 Email de emergência enviado para: %s", caregiverEmail)
+	log.Printf("📧 Email de %s enviado para: %s", label, env.To)
 	return nil
 }
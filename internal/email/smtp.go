@@ -0,0 +1,74 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/textproto"
+	"sync"
+
+	"gopkg.in/gomail.v2"
+)
+
+// SMTPTransport envia por SMTP reaproveitando a conexão entre envios
+type SMTPTransport struct {
+	dialer *gomail.Dialer
+	from   Sender
+
+	mu   sync.Mutex
+	conn gomail.SendCloser
+}
+
+// NewSMTPTransport cria o transporte SMTP (STARTTLS conforme o servidor)
+func NewSMTPTransport(host string, port int, username, password string, from Sender) (*SMTPTransport, error) {
+	if host == "" || username == "" || password == "" {
+		return nil, fmt.Errorf("SMTP credentials not configured")
+	}
+	if from.Email == "" {
+		return nil, fmt.Errorf("SMTP_FROM_EMAIL is required")
+	}
+	return &SMTPTransport{dialer: gomail.NewDialer(host, port, username, password), from: from}, nil
+}
+
+func (t *SMTPTransport) Name() string { return TransportSMTP }
+
+// Send abre a conexão se preciso. Em erro que não seja 5xx a conexão é
+// descartada, já que o servidor pode tê-la encerrado.
+func (t *SMTPTransport) Send(ctx context.Context, to string, msg *Message) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == nil {
+		conn, err := t.dialer.Dial()
+		if err != nil {
+			return fmt.Errorf("failed to connect to SMTP: %w", err)
+		}
+		t.conn = conn
+	}
+
+	// gomail.Send perde o tipo do erro; Send direto mantém o textproto.Error
+	err := t.conn.Send(t.from.Email, []string{to}, mimeMessage(t.from, to, msg))
+	if err == nil {
+		return nil
+	}
+
+	var te *textproto.Error
+	if errors.As(err, &te) && te.Code >= 500 {
+		return fmt.Errorf("%w: %w", ErrRejected, err)
+	}
+	t.conn.Close()
+	t.conn = nil
+	return err
+}
+
+func (t *SMTPTransport) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.conn == nil {
+		return nil
+	}
+	err := t.conn.Close()
+	t.conn = nil
+	return err
+}
@@ -1,102 +1,221 @@
 package email
 
 import (
+	"bytes"
+	"embed"
 	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
 	"time"
 )
 
-// MissedCallAlertTemplate gera HTML para alerta de chamada perdida
-func MissedCallAlertTemplate(elderName, caregiverName string) string {
-	return fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; background-color: #f4f4f4; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background-color: #ffffff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
-        .header { background-color: #FF0000; color: white; padding: 20px; text-align: center; }
-        .header h1 { margin: 0; font-size: 24px; }
-        .content { padding: 30px; }
-        .alert-box { background-color: #FFF3CD; border-left: 4px solid #FF0000; padding: 15px; margin: 20px 0; }
-        .footer { background-color: #f8f9fa; padding: 15px; text-align: center; font-size: 12px; color: #666; }
-        .button { display: inline-block; background-color: #FF0000; color: white; padding: 12px 24px; text-decoration: none; border-radius: 4px; margin-top: 20px; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>⚠️ Chamada Não Atendida</h1>
-        </div>
-        <div class="content">
-            <p>Olá <strong>%s</strong>,</p>
-            
-            <div class="alert-box">
-                <strong>ALERTA:</strong> <strong>%s</strong> não atendeu a chamada programada da EVA.
-            </div>
-            
-            <p><strong>Data/Hora:</strong> %s</p>
-            
-            <p>Por favor, verifique se está tudo bem com o idoso. Este alerta foi enviado porque a notificação push não foi entregue.</p>
-            
-            <p><strong>Ações recomendadas:</strong></p>
-            <ul>
-                <li>Ligar para o idoso para verificar se está tudo bem</li>
-                <li>Verificar se o dispositivo móvel está funcionando</li>
-                <li>Verificar se as notificações estão habilitadas no app</li>
-            </ul>
-        </div>
-        <div class="footer">
-            <p>Este é um email automático do sistema EVA - Assistente Virtual para Idosos</p>
-            <p>Não responda a este email</p>
-        </div>
-    </div>
-</body>
-</html>
-    `, caregiverName, elderName, time.Now().Format("02/01/2006 15:04"))
-}
-
-// EmergencyAlertTemplate gera HTML para alerta de emergência
-func EmergencyAlertTemplate(elderName, caregiverName, reason string) string {
-	return fmt.Sprintf(`
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <style>
-        body { font-family: Arial, sans-serif; background-color: #f4f4f4; margin: 0; padding: 20px; }
-        .container { max-width: 600px; margin: 0 auto; background-color: #ffffff; border-radius: 8px; overflow: hidden; box-shadow: 0 2px 4px rgba(0,0,0,0.1); }
-        .header { background-color: #DC3545; color: white; padding: 20px; text-align: center; }
-        .header h1 { margin: 0; font-size: 24px; }
-        .content { padding: 30px; }
-        .critical-box { background-color: #F8D7DA; border-left: 4px solid #DC3545; padding: 15px; margin: 20px 0; }
-        .footer { background-color: #f8f9fa; padding: 15px; text-align: center; font-size: 12px; color: #666; }
-    </style>
-</head>
-<body>
-    <div class="container">
-        <div class="header">
-            <h1>🚨 ALERTA CRÍTICO</h1>
-        </div>
-        <div class="content">
-            <p>Olá <strong>%s</strong>,</p>
-            
-            <div class="critical-box">
-                <strong>EMERGÊNCIA DETECTADA:</strong> %s
-            </div>
-            
-            <p><strong>Idoso:</strong> %s</p>
-            <p><strong>Data/Hora:</strong> %s</p>
-            
-            <p><strong>⚠️ AÇÃO IMEDIATA NECESSÁRIA</strong></p>
-            <p>Por favor, entre em contato com o idoso imediatamente ou acione serviços de emergência se necessário.</p>
-        </div>
-        <div class="footer">
-            <p>Este é um email automático do sistema EVA - Assistente Virtual para Idosos</p>
-            <p>Não responda a este email</p>
-        </div>
-    </div>
-</body>
-</html>
-    `, caregiverName, reason, elderName, time.Now().Format("02/01/2006 15:04"))
+// Templates embutidos: cada email tem <nome>.html e <nome>.txt dentro do
+// layout; o .txt também define o "subject".
+const (
+	TemplateMissedCall         = "missed_call"
+	TemplateEmergencyAlert     = "emergency_alert"
+	TemplateWeeklyReport       = "weekly_report"
+	TemplateNotificationDigest = "notification_digest"
+	TemplateCaregiverWeekly    = "caregiver_weekly"
+)
+
+//go:embed templates/*.html templates/*.txt
+var templateFiles embed.FS
+
+var (
+	htmlTemplates = map[string]*htmltemplate.Template{}
+	textTemplates = map[string]*texttemplate.Template{}
+)
+
+func init() {
+	for _, name := range []string{TemplateMissedCall, TemplateEmergencyAlert, TemplateWeeklyReport, TemplateNotificationDigest, TemplateCaregiverWeekly} {
+		htmlTemplates[name] = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/layout.html", "templates/"+name+".html"))
+		textTemplates[name] = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/layout.txt", "templates/"+name+".txt"))
+	}
+}
+
+// Branding marca da entidade nos emails (email_marcas); vazio usa o padrão da EVA
+type Branding struct {
+	Nome        string
+	LogoURL     string
+	CorPrimaria string
+	CorAlerta   string
+	Rodape      string
+}
+
+// withDefaults completa a marca com o padrão
+func (b Branding) withDefaults(locale string) Branding {
+	if b.Nome == "" {
+		b.Nome = translate(locale, "brand.name")
+	}
+	if b.CorPrimaria == "" {
+		b.CorPrimaria = "#0D6EFD"
+	}
+	if b.CorAlerta == "" {
+		b.CorAlerta = "#DC3545"
+	}
+	return b
+}
+
+// Message email renderizado: assunto, texto puro e HTML
+type Message struct {
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// ReportTable tabela de estatísticas incluída no relatório semanal
+type ReportTable struct {
+	Title string
+	Rows  [][2]string
+}
+
+// DigestItem aviso adiado incluído no resumo de notificações
+type DigestItem struct {
+	At   time.Time `json:"em"`
+	Text string    `json:"texto"`
+}
+
+// Tendência do humor em relação à semana anterior
+const (
+	MoodImproved = "melhorou"
+	MoodStable   = "estavel"
+	MoodWorse    = "piorou"
+)
+
+// WeeklyRisk predição de emergência nova na semana
+type WeeklyRisk struct {
+	Type        string  `json:"tipo"`
+	Level       string  `json:"nivel_risco"`
+	Probability float64 `json:"probabilidade"` // 0..1
+}
+
+// Chance probabilidade como porcentagem
+func (r WeeklyRisk) Chance() string {
+	return fmt.Sprintf("%.0f%%", r.Probability*100)
+}
+
+// CaregiverWeekly conteúdo do resumo semanal do cuidador
+type CaregiverWeekly struct {
+	Start          time.Time    `json:"inicio"`
+	End            time.Time    `json:"fim"`
+	CallsCompleted int          `json:"chamadas_concluidas"`
+	CallsMissed    int          `json:"chamadas_nao_atendidas"`
+	Adherence      *float64     `json:"adesao_medicacao"` // 0..1; nil sem lembretes na semana
+	Mood           string       `json:"humor"`            // MoodImproved, MoodStable, MoodWorse ou "" sem dados
+	OpenAlerts     []DigestItem `json:"alertas_abertos"`
+	Patterns       []string     `json:"padroes"`
+	Risks          []WeeklyRisk `json:"predicoes"`
+}
+
+// Calm semana com conversas, sem alertas em aberto nem chamadas perdidas
+func (w CaregiverWeekly) Calm() bool {
+	return w.CallsCompleted > 0 && w.CallsMissed == 0 && len(w.OpenAlerts) == 0
+}
+
+// view dados dos templates. T e Date usam o idioma e o fuso do destinatário.
+type view struct {
+	Locale    string
+	Brand     Branding
+	Accent    string
+	Recipient string
+	Elder     string
+	Data      interface{}
+
+	location *time.Location
+}
+
+// T texto traduzido (escapado pelo html/template como qualquer outro valor)
+func (v view) T(key string, args ...interface{}) string {
+	return translate(v.Locale, key, args...)
+}
+
+// Date data e hora no fuso do idoso
+func (v view) Date(t time.Time) string {
+	return formatTime(v.Locale, v.location, t, true)
+}
+
+// Day data de calendário no formato do idioma
+func (v view) Day(t time.Time) string {
+	return formatTime(v.Locale, v.location, t, false)
+}
+
+// Percent fração 0..1 como porcentagem; nil vira "sem dados" no idioma
+func (v view) Percent(f *float64) string {
+	if f == nil {
+		return translate(v.Locale, "nodata")
+	}
+	return fmt.Sprintf("%.0f%%", *f*100)
+}
+
+// Paragraphs quebra o texto (ex.: narrativa do modelo) em parágrafos não vazios
+func (v view) Paragraphs(s string) []string {
+	var list []string
+	for _, p := range strings.Split(s, "\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			list = append(list, p)
+		}
+	}
+	return list
+}
+
+type alertData struct {
+	Reason string
+	At     time.Time
+}
+
+type reportData struct {
+	Start     time.Time
+	End       time.Time
+	Narrative string
+	Tables    []ReportTable
+}
+
+type digestData struct {
+	Items []DigestItem
+}
+
+// render gera assunto, texto e HTML do template. Todo valor vindo de fora
+// (nomes, motivo do alerta, narrativa do modelo) é escapado pelo html/template.
+func render(name string, a audience, recipient, elder string, data interface{}) (*Message, error) {
+	h, ok := htmlTemplates[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown email template %q", name)
+	}
+
+	if recipient == "" {
+		recipient = translate(a.locale, "recipient.anonym")
+	}
+	v := view{
+		Locale:    a.locale,
+		Brand:     a.brand.withDefaults(a.locale),
+		Recipient: recipient,
+		Elder:     elder,
+		Data:      data,
+		location:  a.location,
+	}
+	v.Accent = v.Brand.CorPrimaria
+	if name == TemplateMissedCall || name == TemplateEmergencyAlert {
+		v.Accent = v.Brand.CorAlerta
+	}
+
+	var subject, text, body bytes.Buffer
+	t := textTemplates[name]
+	if err := t.ExecuteTemplate(&subject, "subject", v); err != nil {
+		return nil, fmt.Errorf("failed to render %s subject: %w", name, err)
+	}
+	if err := t.ExecuteTemplate(&text, "layout", v); err != nil {
+		return nil, fmt.Errorf("failed to render %s text: %w", name, err)
+	}
+	if err := h.ExecuteTemplate(&body, "layout", v); err != nil {
+		return nil, fmt.Errorf("failed to render %s html: %w", name, err)
+	}
+
+	return &Message{
+		// O assunto vai em cabeçalho: sem quebras de linha
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		Text:    strings.TrimSpace(text.String()) + "\n",
+		HTML:    body.String(),
+	}, nil
 }
@@ -0,0 +1,131 @@
+package email
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return loc
+}
+
+func TestRenderEmergencyAlertLocalized(t *testing.T) {
+	at := time.Date(2026, 3, 10, 18, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		locale    string
+		zone      string
+		recipient string
+		subject   string
+		text      []string
+	}{
+		{
+			name:      "pt-BR em São Paulo",
+			locale:    LocalePortuguese,
+			zone:      "America/Sao_Paulo",
+			recipient: "Ana",
+			subject:   "🚨 ALERTA CRÍTICO - Maria",
+			text:      []string{"Olá Ana,", "EMERGÊNCIA DETECTADA: queda", "Data/Hora: 10/03/2026 15:30"},
+		},
+		{
+			name:    "en em Nova York, sem nome do destinatário",
+			locale:  LocaleEnglish,
+			zone:    "America/New_York",
+			subject: "🚨 CRITICAL ALERT - Maria",
+			text:    []string{"Caregiver,", "EMERGENCY DETECTED: queda", "Date/Time: Mar 10, 2026 2:30 PM"},
+		},
+		{
+			name:      "es em Madri",
+			locale:    LocaleSpanish,
+			zone:      "Europe/Madrid",
+			recipient: "Luis",
+			subject:   "🚨 ALERTA CRÍTICA - Maria",
+			text:      []string{"EMERGENCIA DETECTADA: queda", "Fecha/Hora: 10/03/2026 19:30"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := audience{locale: tt.locale, location: mustLocation(t, tt.zone)}
+			msg, err := render(TemplateEmergencyAlert, a, tt.recipient, "Maria", alertData{Reason: "queda", At: at})
+			if err != nil {
+				t.Fatalf("render() error = %v", err)
+			}
+			if msg.Subject != tt.subject {
+				t.Errorf("Subject = %q, want %q", msg.Subject, tt.subject)
+			}
+			for _, want := range tt.text {
+				if !strings.Contains(msg.Text, want) {
+					t.Errorf("Text não contém %q:\n%s", want, msg.Text)
+				}
+			}
+		})
+	}
+}
+
+func TestRenderEscapesHTML(t *testing.T) {
+	a := audience{locale: LocalePortuguese, location: mustLocation(t, DefaultTimeZone)}
+	reason := `<script>alert("x")</script>`
+
+	msg, err := render(TemplateEmergencyAlert, a, "Ana <ana@exemplo.com>", "Maria\nSilva", alertData{Reason: reason, At: time.Now()})
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+
+	if strings.Contains(msg.HTML, "<script>") || !strings.Contains(msg.HTML, "&lt;script&gt;") {
+		t.Errorf("HTML não escapou o motivo:\n%s", msg.HTML)
+	}
+	if strings.Contains(msg.HTML, "<ana@exemplo.com>") {
+		t.Errorf("HTML não escapou o destinatário")
+	}
+	if !strings.Contains(msg.Text, reason) {
+		t.Errorf("Text deveria ter o motivo sem escape:\n%s", msg.Text)
+	}
+	if strings.Contains(msg.Subject, "\n") {
+		t.Errorf("Subject com quebra de linha: %q", msg.Subject)
+	}
+}
+
+func TestRenderUnknownTemplate(t *testing.T) {
+	if _, err := render("nao_existe", audience{locale: LocalePortuguese, location: time.UTC}, "", "", nil); err == nil {
+		t.Fatal("render() de template desconhecido deveria falhar")
+	}
+}
+
+func TestViewFormatting(t *testing.T) {
+	half := 0.5
+	day := time.Date(2026, 3, 9, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		locale  string
+		percent string
+		nodata  string
+		day     string
+	}{
+		{LocalePortuguese, "50%", "sem dados", "09/03/2026"},
+		{LocaleEnglish, "50%", "no data", "Mar 9, 2026"},
+		{LocaleSpanish, "50%", "sin datos", "09/03/2026"},
+		{"fr", "50%", "sem dados", "09/03/2026"},
+	}
+
+	for _, tt := range tests {
+		// O dia é data de calendário: não muda com o fuso
+		v := view{Locale: tt.locale, location: mustLocation(t, "America/Sao_Paulo")}
+		if got := v.Percent(&half); got != tt.percent {
+			t.Errorf("%s: Percent(0.5) = %q, want %q", tt.locale, got, tt.percent)
+		}
+		if got := v.Percent(nil); got != tt.nodata {
+			t.Errorf("%s: Percent(nil) = %q, want %q", tt.locale, got, tt.nodata)
+		}
+		if got := v.Day(day); got != tt.day {
+			t.Errorf("%s: Day() = %q, want %q", tt.locale, got, tt.day)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+
+	"eva-mind/internal/config"
+
+	"gopkg.in/gomail.v2"
+)
+
+// Transportes disponíveis (EMAIL_TRANSPORT)
+const (
+	TransportSMTP    = "smtp"
+	TransportHTTP    = "http"
+	TransportArquivo = "arquivo"
+)
+
+// ErrRejected o provedor recusou o email de forma definitiva (destinatário
+// inexistente, remetente não autorizado...): reenviar não adianta
+var ErrRejected = errors.New("email rejected")
+
+// Transport entrega um email já renderizado
+type Transport interface {
+	// Name identifica o transporte nos logs
+	Name() string
+	// Send entrega a mensagem a um destinatário. Erros com ErrRejected são definitivos.
+	Send(ctx context.Context, to string, msg *Message) error
+	// Close libera a conexão aberta, se houver; o próximo Send reabre
+	Close() error
+}
+
+// NewTransport escolhe o transporte pela config. Fora de produção os
+// transportes reais só entregam para EMAIL_ALLOWLIST; o resto vai para a pasta local.
+func NewTransport(cfg *config.Config) (Transport, error) {
+	from := Sender{Name: cfg.SMTPFromName, Email: cfg.SMTPFromEmail}
+
+	var t Transport
+	var err error
+	switch cfg.EmailTransport {
+	case TransportSMTP:
+		t, err = NewSMTPTransport(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, from)
+	case TransportHTTP:
+		t, err = NewHTTPTransport(cfg.EmailAPIURL, cfg.EmailAPIKey, from)
+	case TransportArquivo:
+		if from.Email == "" {
+			from.Email = "eva@localhost"
+		}
+		return NewFileTransport(cfg.EmailDir, from)
+	default:
+		return nil, fmt.Errorf("EMAIL_TRANSPORT inválido: %q (use smtp, http ou arquivo)", cfg.EmailTransport)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Environment == "production" {
+		return t, nil
+	}
+
+	sink, err := NewFileTransport(cfg.EmailDir, from)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("🧪 Email %s em %s: só %v recebem de verdade; o resto vai para %s", t.Name(), cfg.Environment, cfg.EmailAllowlist, cfg.EmailDir)
+	return &restrictedTransport{real: t, sink: sink, allowed: cfg.EmailAllowlist}, nil
+}
+
+// Sender remetente dos emails
+type Sender struct {
+	Name  string
+	Email string
+}
+
+// String "Nome <email>"
+func (s Sender) String() string {
+	if s.Name == "" {
+		return s.Email
+	}
+	return fmt.Sprintf("%s <%s>", s.Name, s.Email)
+}
+
+// mimeMessage email multipart: texto puro com HTML como alternativa
+func mimeMessage(from Sender, to string, msg *Message) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetAddressHeader("From", from.Email, from.Name)
+	m.SetHeader("To", to)
+	m.SetHeader("Subject", msg.Subject)
+	m.SetBody("text/plain", msg.Text)
+	m.AddAlternative("text/html", msg.HTML)
+	return m
+}
+
+// restrictedTransport protege famílias reais fora de produção: destinos fora
+// da allowlist são gravados na pasta local em vez de enviados
+type restrictedTransport struct {
+	real    Transport
+	sink    Transport
+	allowed []string
+}
+
+func (r *restrictedTransport) Name() string { return r.real.Name() + "+restrito" }
+
+func (r *restrictedTransport) Send(ctx context.Context, to string, msg *Message) error {
+	if allowed(to, r.allowed) {
+		return r.real.Send(ctx, to, msg)
+	}
+	log.Printf("🧪 Email para %s fora da allowlist: gravado localmente", to)
+	return r.sink.Send(ctx, to, msg)
+}
+
+func (r *restrictedTransport) Close() error { return r.real.Close() }
+
+// allowed informa se o endereço está na lista (endereço exato ou @dominio)
+func allowed(to string, list []string) bool {
+	to = strings.ToLower(strings.TrimSpace(to))
+	for _, a := range list {
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" {
+			continue
+		}
+		if to == a || (strings.HasPrefix(a, "@") && strings.HasSuffix(to, a)) {
+			return true
+		}
+	}
+	return false
+}
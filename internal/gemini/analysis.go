@@ -10,6 +10,10 @@ import (
 	"time"
 )
 
+// AnalysisVersion identifica o prompt de análise atual. Deve ser alterada
+// sempre que o prompt mudar, para que reanálises possam ser comparadas.
+const AnalysisVersion = "v1"
+
 // ConversationAnalysis resultado completo da análise
 type ConversationAnalysis struct {
 	// Saúde Física
@@ -83,6 +87,34 @@ CRITÉRIOS DE URGÊNCIA:
 
 Seja objetivo e preciso. Se não tiver informação, use false/vazio/0.`, cleanedTranscript)
 
+	responseText, err := generateContent(cfg, prompt, 0.1, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	responseText = strings.TrimPrefix(responseText, "```json")
+	responseText = strings.TrimPrefix(responseText, "```")
+	responseText = strings.TrimSuffix(responseText, "```")
+	responseText = strings.TrimSpace(responseText)
+
+	var analysis ConversationAnalysis
+	if err := json.Unmarshal([]byte(responseText), &analysis); err != nil {
+		return nil, fmt.Errorf("falha ao parsear análise: %w (resposta: %s)", err, responseText)
+	}
+
+	// Adiciona timestamp da análise
+	analysis.LastAnalysisAt = time.Now()
+
+	return &analysis, nil
+}
+
+// GenerateText envia um prompt livre ao modelo de análise e retorna o texto gerado
+func GenerateText(cfg *config.Config, prompt string) (string, error) {
+	return generateContent(cfg, prompt, 0.3, 4096)
+}
+
+// generateContent chama a API REST generateContent com o modelo de análise
+func generateContent(cfg *config.Config, prompt string, temperature float64, maxTokens int) (string, error) {
 	model := cfg.GeminiAnalysisModel
 	if model == "" {
 		model = "gemini-2.5-flash"
@@ -99,22 +131,22 @@ Seja objetivo e preciso. Se não tiver informação, use false/vazio/0.`, cleane
 			},
 		},
 		"generationConfig": map[string]interface{}{
-			"temperature":     0.1,
-			"maxOutputTokens": 2048,
+			"temperature":     temperature,
+			"maxOutputTokens": maxTokens,
 		},
 	}
 
 	jsonPayload, _ := json.Marshal(payload)
 	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonPayload))
 	if err != nil {
-		return nil, fmt.Errorf("falha ao chamar Gemini API: %w", err)
+		return "", fmt.Errorf("falha ao chamar Gemini API: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
 		var errResp map[string]interface{}
 		json.NewDecoder(resp.Body).Decode(&errResp)
-		return nil, fmt.Errorf("Gemini API retornou status %d: %v", resp.StatusCode, errResp)
+		return "", fmt.Errorf("Gemini API retornou status %d: %v", resp.StatusCode, errResp)
 	}
 
 	var result struct {
@@ -128,29 +160,14 @@ Seja objetivo e preciso. Se não tiver informação, use false/vazio/0.`, cleane
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("falha ao decodificar resposta: %w", err)
+		return "", fmt.Errorf("falha ao decodificar resposta: %w", err)
 	}
 
 	if len(result.Candidates) == 0 || len(result.Candidates[0].Content.Parts) == 0 {
-		return nil, fmt.Errorf("resposta vazia do Gemini")
-	}
-
-	responseText := result.Candidates[0].Content.Parts[0].Text
-	responseText = strings.TrimSpace(responseText)
-	responseText = strings.TrimPrefix(responseText, "```json")
-	responseText = strings.TrimPrefix(responseText, "```")
-	responseText = strings.TrimSuffix(responseText, "```")
-	responseText = strings.TrimSpace(responseText)
-
-	var analysis ConversationAnalysis
-	if err := json.Unmarshal([]byte(responseText), &analysis); err != nil {
-		return nil, fmt.Errorf("falha ao parsear análise: %w (resposta: %s)", err, responseText)
+		return "", fmt.Errorf("resposta vazia do Gemini")
 	}
 
-	// Adiciona timestamp da análise
-	analysis.LastAnalysisAt = time.Now()
-
-	return &analysis, nil
+	return strings.TrimSpace(result.Candidates[0].Content.Parts[0].Text), nil
 }
 
 // cleanTranscription (mantida igual, mas agora usada em AnalyzeConversation)
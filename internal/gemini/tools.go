@@ -1,13 +1,18 @@
 package gemini
 
 import (
+	"context"
 	"database/sql"
-	"eva-mind/internal/push"
+	"errors"
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/config"
+	"eva-mind/internal/notify"
+	"eva-mind/internal/preferences"
+	"eva-mind/internal/webhooks"
+	"eva-mind/internal/whatsapp"
 	"fmt"
 	"log"
 	"time"
-
-	"firebase.google.com/go/v4/messaging"
 )
 
 func GetDefaultTools() []interface{} {
@@ -52,156 +57,145 @@ func GetDefaultTools() []interface{} {
 	}
 }
 
-// AlertFamily envia notificação push para cuidadores com sistema de fallback
-func AlertFamily(db *sql.DB, pushService *push.FirebaseService, idosoID int64, reason string) error {
-	return AlertFamilyWithSeverity(db, pushService, idosoID, reason, "alta")
+// AlertFamily notifica os cuidadores pelo canal preferido de cada um, com fallback
+func AlertFamily(cfg *config.Config, db *sql.DB, dispatcher *notify.Dispatcher, idosoID int64, reason string) error {
+	return AlertFamilyWithSeverity(cfg, db, dispatcher, idosoID, reason, "alta")
 }
 
 // AlertFamilyWithSeverity envia alertas com níveis de severidade
-func AlertFamilyWithSeverity(db *sql.DB, pushService *push.FirebaseService, idosoID int64, reason, severity string) error {
+//
+// Alertas equivalentes (mesmo idoso, tipo e motivo) dentro da janela de
+// deduplicação são agrupados no alerta aberto sem notificar de novo, a menos
+// que a severidade tenha subido.
+func AlertFamilyWithSeverity(cfg *config.Config, db *sql.DB, dispatcher *notify.Dispatcher, idosoID int64, reason, severity string) error {
+	ctx := context.Background()
+	alertService := alerts.NewService(cfg, db)
+
 	// 1. Buscar todos os cuidadores ativos (primários e secundários)
-	query := `
-		SELECT 
-			c.device_token, 
-			c.telefone,
-			c.email,
-			c.prioridade,
-			i.nome 
-		FROM cuidadores c
-		JOIN idosos i ON i.id = c.idoso_id
-		WHERE c.idoso_id = $1 AND c.ativo = true
-		ORDER BY c.prioridade ASC
-	`
-
-	rows, err := db.Query(query, idosoID)
+	caregivers, err := alertService.Caregivers(ctx, idosoID, 0)
 	if err != nil {
 		return fmt.Errorf("failed to query caregivers: %w", err)
 	}
-	defer rows.Close()
-
-	type Caregiver struct {
-		Token     sql.NullString
-		Phone     sql.NullString
-		Email     sql.NullString
-		Priority  int
-		ElderName string
-	}
-
-	var caregivers []Caregiver
-
-	for rows.Next() {
-		var cg Caregiver
-		err := rows.Scan(&cg.Token, &cg.Phone, &cg.Email, &cg.Priority, &cg.ElderName)
-		if err != nil {
-			log.Printf("Error scanning caregiver: %v", err)
-			continue
-		}
-		caregivers = append(caregivers, cg)
-	}
 
 	if len(caregivers) == 0 {
 		log.Printf("⚠️ No active caregivers found for idoso %d", idosoID)
 		return fmt.Errorf("no caregivers registered")
 	}
 
-	elderName := caregivers[0].ElderName
+	var elderName string
+	if err := db.QueryRow(`SELECT nome FROM idosos WHERE id = $1`, idosoID).Scan(&elderName); err != nil {
+		return fmt.Errorf("failed to query idoso: %w", err)
+	}
+
+	// 2. Registrar alerta no banco ANTES de enviar (ou agrupar com o aberto)
+	alert := &alerts.Alert{
+		IdosoID:    idosoID,
+		NomeIdoso:  elderName,
+		Tipo:       "familia",
+		Severidade: severity,
+		Mensagem:   reason,
+	}
 
-	// 2. Registrar alerta no banco ANTES de enviar
 	var alertID int64
-	insertQuery := `
-		INSERT INTO alertas (
-			idoso_id, 
-			tipo, 
-			severidade,
-			mensagem, 
-			visualizado,
-			criado_em
-		) 
-		VALUES ($1, 'familia', $2, $3, false, NOW())
-		RETURNING id
-	`
-
-	err = db.QueryRow(insertQuery, idosoID, severity, reason).Scan(&alertID)
+	notifyFamily, err := alertService.Raise(ctx, alert)
 	if err != nil {
 		log.Printf("⚠️ Failed to log alert in database: %v", err)
 	} else {
-		log.Printf("📝 Alert registered in DB with ID: %d", alertID)
+		alertID = alert.ID
+	}
+
+	if !notifyFamily {
+		log.Printf("🔁 Alerta repetido para %s agrupado no alerta %d (%d ocorrências), sem nova notificação", elderName, alertID, alert.Ocorrencias)
+		return nil
 	}
 
-	// 3. Tentar enviar push notifications para todos os cuidadores
-	var successCount int
-	var tokens []string
+	// 3. Notificar os cuidadores pelo canal preferido, com fallback entre canais.
+	// Os pushes saem num único multicast.
+	var successCount, attempted, suppressed, deferred int
+	channelsUsed := make(map[string]int)
 
+	var targets []alerts.Recipient
 	for _, cg := range caregivers {
-		if cg.Token.Valid && cg.Token.String != "" {
-			tokens = append(tokens, cg.Token.String)
+		if throttled, err := alertService.Throttled(ctx, cg, alert.Severidade); err == nil && throttled {
+			suppressed++
+			if alertID != 0 {
+				if err := alertService.RecordSuppressed(ctx, alertID, cg, alerts.CanalPush); err != nil {
+					log.Printf("⚠️ %v", err)
+				}
+			}
+			continue
 		}
+		targets = append(targets, cg)
 	}
 
-	if len(tokens) > 0 {
-		log.Printf("📱 Enviando push para %d cuidador(es)", len(tokens))
+	for _, res := range dispatcher.DispatchAll(ctx, alert, targets) {
+		switch {
+		case errors.Is(res.Err, notify.ErrOptedOut):
+			suppressed++
+		case res.Err != nil:
+			attempted++
+			log.Printf("❌ Failed to alert %s: %v", res.Recipient.Label(), res.Err)
+		case res.Canal == notify.CanalResumo:
+			attempted++
+			deferred++
+		default:
+			attempted++
+			successCount++
+			channelsUsed[res.Canal]++
+			log.Printf("✅ Alert sent to %s via %s for %s", res.Recipient.Label(), res.Canal, elderName)
+		}
+	}
 
-		for _, token := range tokens {
-			result, err := pushService.SendAlertNotification(token, elderName, reason)
+	// Todos os cuidadores já atingiram o limite ou não querem este aviso: o alerta
+	// fica visível no app, sem notificação
+	if attempted == 0 && suppressed > 0 {
+		log.Printf("🔕 Alerta %d não notificado: limite ou preferências dos cuidadores de %s", alertID, elderName)
+		return nil
+	}
 
-			if err == nil && result.Success {
-				successCount++
+	// Quem não recebeu agora recebe no resumo (horário de silêncio ou modo resumo)
+	if successCount == 0 && deferred > 0 {
+		log.Printf("🌙 Alerta %d adiado para o resumo de %d cuidador(es)", alertID, deferred)
+		return nil
+	}
 
-				// Registrar envio no banco
-				_, _ = db.Exec(`
-					UPDATE alertas 
-					SET enviado = true, data_envio = NOW()
-					WHERE id = $1
-				`, alertID)
+	// 4. Se NENHUM canal funcionou, o alerta fica aguardando escalonamento
+	if successCount == 0 {
+		log.Printf("⚠️ Nenhum cuidador notificado com sucesso. Alerta marcado para escalonamento")
 
-				log.Printf("✅ Alert sent successfully to caregiver for %s", elderName)
-			} else {
-				log.Printf("❌ Failed to send alert to caregiver: %v", err)
+		if alertID != 0 {
+			if err := alertService.ScheduleEscalation(ctx, alertID, time.Now()); err != nil {
+				log.Printf("⚠️ %v", err)
 			}
 		}
-	}
 
-	// 4. Se NENHUM push funcionou, tentar fallbacks
-	if successCount == 0 {
-		log.Printf("⚠️ Nenhum push notification enviado com sucesso. Tentando fallbacks...")
-
-		// Registrar que o alerta precisa de escalamento
-		_, _ = db.Exec(`
-			UPDATE alertas 
-			SET 
-				necessita_escalamento = true,
-				tentativas_envio = tentativas_envio + 1,
-				ultima_tentativa = NOW()
-			WHERE id = $1
-		`, alertID)
-
-		// TODO: Implementar SMS via Twilio
-		// TODO: Implementar Email
-		// TODO: Implementar ligação telefônica para casos críticos
-
-		return fmt.Errorf("all push notifications failed, alert needs escalation")
+		return fmt.Errorf("all notification channels failed, alert needs escalation")
 	}
 
-	log.Printf("✅ Alert sent to %d of %d caregivers", successCount, len(tokens))
+	log.Printf("✅ Alert sent to %d of %d caregivers", successCount, attempted)
 
-	// 5. Para alertas críticos, marcar para escalonamento automático
-	if severity == "critica" {
-		_, _ = db.Exec(`
-			UPDATE alertas 
-			SET 
-				necessita_escalamento = true,
-				tempo_escalamento = NOW() + INTERVAL '5 minutes'
-			WHERE id = $1
-		`, alertID)
+	if alertID != 0 {
+		if alert.Status == alerts.StatusCriado {
+			err := alertService.Transition(ctx, alertID, alerts.StatusDespachado, alerts.Event{
+				Sucesso:  true,
+				Detalhes: fmt.Sprintf("%d de %d cuidador(es) notificado(s) %v", successCount, len(caregivers), channelsUsed),
+			})
+			if err != nil {
+				log.Printf("⚠️ %v", err)
+			}
+		}
 
-		log.Printf("🚨 Alert crítico - configurado para escalonamento em 5 minutos se não visualizado")
+		// 5. Escalonamento segue a política da severidade até alguém confirmar
+		if err := alertService.Arm(ctx, alertID); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
 	}
 
 	return nil
 }
 
 // ConfirmMedication registra que o idoso tomou o remédio
-func ConfirmMedication(db *sql.DB, pushService *push.FirebaseService, idosoID int64, medicationName string) error {
+func ConfirmMedication(cfg *config.Config, db *sql.DB, dispatcher *notify.Dispatcher, idosoID int64, medicationName string) error {
 	// 1. Registrar no histórico
 	_, err := db.Exec(`
 		INSERT INTO historico_medicamentos (idoso_id, medicamento, tomado_em) 
@@ -212,7 +206,7 @@ func ConfirmMedication(db *sql.DB, pushService *push.FirebaseService, idosoID in
 		return fmt.Errorf("failed to log medication: %w", err)
 	}
 
-	log.Printf("💊 Medication logged: %s took %s", idosoID, medicationName)
+	log.Printf("💊 Medication logged: %d took %s", idosoID, medicationName)
 
 	// 2. Atualizar status do agendamento de hoje
 	_, err = db.Exec(`
@@ -228,59 +222,100 @@ func ConfirmMedication(db *sql.DB, pushService *push.FirebaseService, idosoID in
 		log.Printf("⚠️ Failed to update schedule: %v", err)
 	}
 
-	// 3. Notificar TODOS os cuidadores ativos
-	query := `
-		SELECT c.device_token, i.nome 
-		FROM cuidadores c
-		JOIN idosos i ON i.id = c.idoso_id
-		WHERE c.idoso_id = $1 AND c.ativo = true
-	`
+	// 3. Notificar os cuidadores conforme as preferências de cada um
+	notifyMedication(cfg, db, dispatcher.Push(), idosoID, medicationName)
+
+	// 4. Avisar as integrações da entidade
+	if err := webhooks.NewService(db).Publish(context.Background(), idosoID, webhooks.EventoMedicamentoConfirmado, map[string]interface{}{
+		"medicamento":   medicationName,
+		"confirmado_em": time.Now(),
+	}); err != nil {
+		log.Printf("⚠️ Failed to publish medication webhook: %v", err)
+	}
 
-	rows, err := db.Query(query, idosoID)
+	return nil
+}
+
+// notifyMedication avisa os cuidadores que o remédio foi tomado, respeitando
+// eventos escolhidos, horário de silêncio e modo resumo. Quem prefere WhatsApp
+// (ou não tem o app) recebe por WhatsApp; os demais, por push.
+func notifyMedication(cfg *config.Config, db *sql.DB, pushNotifier *notify.PushNotifier, idosoID int64, medicationName string) {
+	ctx := context.Background()
+
+	var elderName string
+	if err := db.QueryRowContext(ctx, `SELECT nome FROM idosos WHERE id = $1`, idosoID).Scan(&elderName); err != nil {
+		log.Printf("⚠️ Failed to load elder name: %v", err)
+		return
+	}
+
+	caregivers, err := alerts.NewService(cfg, db).Caregivers(ctx, idosoID, 0)
 	if err != nil {
 		log.Printf("⚠️ Failed to query caregivers: %v", err)
-		return nil
+		return
 	}
-	defer rows.Close()
 
-	var elderName string
-	notificationsSent := 0
+	var whatsappService *whatsapp.Service
+	if cfg.EnableWhatsApp {
+		if whatsappService, err = whatsapp.NewService(cfg, db); err != nil {
+			log.Printf("⚠️ WhatsApp indisponível: %v", err)
+		}
+	}
 
-	for rows.Next() {
-		var token sql.NullString
-		err := rows.Scan(&token, &elderName)
+	prefs := preferences.NewService(db)
+	now := time.Now()
+	notificationsSent, deferred := 0, 0
+	var pushTargets []alerts.Recipient
 
-		if err != nil || !token.Valid || token.String == "" {
+	for _, cg := range caregivers {
+		p, err := prefs.Get(ctx, cg.CuidadorID)
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+			p = preferences.Default(cg.CuidadorID)
+		}
+
+		switch p.Decide(preferences.EventoMedicamento, "aviso", now) {
+		case preferences.Descartar:
+			continue
+		case preferences.Adiar:
+			_, err := prefs.Defer(ctx, preferences.Deferred{
+				CuidadorID: cg.CuidadorID,
+				Evento:     preferences.EventoMedicamento,
+				Severidade: "aviso",
+				Mensagem:   fmt.Sprintf("%s tomou %s às %s", elderName, medicationName, now.Format("15:04")),
+			})
+			if err != nil {
+				log.Printf("⚠️ %v", err)
+			} else {
+				deferred++
+			}
 			continue
 		}
 
-		message := &messaging.Message{
-			Token: token.String,
-			Notification: &messaging.Notification{
-				Title: "✅ Medicamento Confirmado",
-				Body:  fmt.Sprintf("%s tomou %s", elderName, medicationName),
-			},
-			Data: map[string]string{
-				"type":       "medication_confirmed",
-				"medication": medicationName,
-				"idosoId":    fmt.Sprintf("%d", idosoID),
-				"timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
-			},
-			Android: &messaging.AndroidConfig{
-				Priority: "normal",
-				Notification: &messaging.AndroidNotification{
-					Sound:        "default",
-					ChannelID:    "eva_medications",
-					DefaultSound: true,
-					Color:        "#00FF00",
-				},
-			},
+		wantsWhatsApp := notify.NormalizeChannel(cg.MetodoPreferido) == alerts.CanalWhatsApp
+		if whatsappService != nil && cg.Telefone != "" && (wantsWhatsApp || len(cg.Tokens()) == 0) {
+			if err := whatsappService.SendMedicationConfirmation(ctx, cg, elderName, medicationName); err != nil {
+				log.Printf("⚠️ Failed to send medication WhatsApp to %s: %v", cg.Label(), err)
+			} else {
+				notificationsSent++
+			}
+			continue
 		}
 
-		_, err = pushService.GetClient().Send(pushService.GetContext(), message)
-		if err != nil {
-			log.Printf("⚠️ Failed to notify caregiver: %v", err)
-		} else {
+		if len(cg.Tokens()) == 0 && len(cg.WebPush) == 0 {
+			continue
+		}
+		pushTargets = append(pushTargets, cg)
+	}
+
+	// Os pushes saem num único multicast (Android/iOS) e por Web Push no painel;
+	// tokens rejeitados são invalidados e falhas transitórias vão para a fila
+	if len(pushTargets) > 0 && pushNotifier != nil {
+		errs := pushNotifier.NotifyMedication(ctx, pushTargets, elderName, medicationName)
+		for i, err := range errs {
+			if err != nil {
+				log.Printf("⚠️ Failed to notify caregiver %s: %v", pushTargets[i].Label(), err)
+				continue
+			}
 			notificationsSent++
 		}
 	}
@@ -288,62 +323,7 @@ func ConfirmMedication(db *sql.DB, pushService *push.FirebaseService, idosoID in
 	if notificationsSent > 0 {
 		log.Printf("✅ %d caregiver(s) notified about medication", notificationsSent)
 	}
-
-	return nil
-}
-
-// CheckUnacknowledgedAlerts verifica alertas não visualizados e escalona se necessário
-func CheckUnacknowledgedAlerts(db *sql.DB, pushService *push.FirebaseService) error {
-	query := `
-		SELECT 
-			a.id,
-			a.idoso_id,
-			a.mensagem,
-			a.severidade,
-			i.nome,
-			c.telefone
-		FROM alertas a
-		JOIN idosos i ON i.id = a.idoso_id
-		LEFT JOIN cuidadores c ON c.idoso_id = i.id AND c.prioridade = 1
-		WHERE a.visualizado = false
-		  AND a.necessita_escalamento = true
-		  AND a.tempo_escalamento <= NOW()
-		  AND a.severidade IN ('critica', 'alta')
-	`
-
-	rows, err := db.Query(query)
-	if err != nil {
-		return fmt.Errorf("failed to query unacknowledged alerts: %w", err)
+	if deferred > 0 {
+		log.Printf("🌙 Medication notice deferred to the digest of %d caregiver(s)", deferred)
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		var alertID, idosoID int64
-		var message, severity, elderName string
-		var phone sql.NullString
-
-		if err := rows.Scan(&alertID, &idosoID, &message, &severity, &elderName, &phone); err != nil {
-			log.Printf("❌ Error scanning alert: %v", err)
-			continue
-		}
-
-		log.Printf("🚨 ESCALANDO alerta não visualizado - ID: %d, Idoso: %s", alertID, elderName)
-
-		// TODO: Implementar ligação telefônica via Twilio para alertas críticos não visualizados
-		// if phone.Valid && phone.String != "" {
-		//     twilioService.MakeCall(phone.String, elderName, message)
-		// }
-
-		// Marcar que o escalonamento foi tentado
-		_, _ = db.Exec(`
-			UPDATE alertas 
-			SET 
-				tentativas_envio = tentativas_envio + 1,
-				ultima_tentativa = NOW(),
-				tempo_escalamento = NOW() + INTERVAL '10 minutes'
-			WHERE id = $1
-		`, alertID)
-	}
-
-	return nil
 }
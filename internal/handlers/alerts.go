@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"eva-mind/internal/alerts"
+)
+
+// AlertsHandler endpoints do app do cuidador para acompanhar alertas
+type AlertsHandler struct {
+	service *alerts.Service
+}
+
+// NewAlertsHandler cria o handler de alertas
+func NewAlertsHandler(service *alerts.Service) *AlertsHandler {
+	return &AlertsHandler{service: service}
+}
+
+type alertActionRequest struct {
+	VisualizadoEm time.Time `json:"visualizado_em"`
+	Nota          string    `json:"nota"`
+}
+
+// GetPending GET /api/alerts/pending: alertas abertos do cuidador autenticado
+func (h *AlertsHandler) GetPending(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, ok := caregiverID(w, r)
+	if !ok {
+		return
+	}
+
+	list, err := h.service.Pending(r.Context(), cuidadorID)
+	if err != nil {
+		log.Printf("❌ Erro ao listar alertas pendentes do cuidador %d: %v", cuidadorID, err)
+		writeError(w, http.StatusInternalServerError, "falha ao listar alertas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"alertas": list})
+}
+
+// ListCaregiverAlerts GET /api/cuidadores/{id}/alertas?status=abertos|todos|<estado>&limit=N
+// ({id} precisa ser o cuidador autenticado)
+func (h *AlertsHandler) ListCaregiverAlerts(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, ok := pathCaregiver(w, r)
+	if !ok {
+		return
+	}
+
+	filter := alerts.ListFilter{CuidadorID: cuidadorID, Limit: 50}
+
+	switch status := r.URL.Query().Get("status"); status {
+	case "", "abertos":
+		filter.Statuses = alerts.OpenStatuses
+	case "todos":
+	default:
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 200 {
+			filter.Limit = n
+		}
+	}
+
+	list, err := h.service.List(r.Context(), filter)
+	if err != nil {
+		log.Printf("❌ Erro ao listar alertas do cuidador %d: %v", cuidadorID, err)
+		writeError(w, http.StatusInternalServerError, "falha ao listar alertas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"alertas": list})
+}
+
+// GetAlert GET /api/alerts/{id} (inclui a trilha de auditoria)
+func (h *AlertsHandler) GetAlert(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, ok := caregiverID(w, r)
+	if !ok {
+		return
+	}
+
+	alertID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id do alerta inválido")
+		return
+	}
+
+	if err := h.service.CheckCaregiver(r.Context(), alertID, cuidadorID); err != nil {
+		writeAlertError(w, alertID, err)
+		return
+	}
+
+	alert, err := h.service.Get(r.Context(), alertID)
+	if err != nil {
+		writeAlertError(w, alertID, err)
+		return
+	}
+
+	history, err := h.service.History(r.Context(), alertID)
+	if err != nil {
+		writeAlertError(w, alertID, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"alerta":    alert,
+		"historico": history,
+	})
+}
+
+// Acknowledge POST /api/alerts/{id}/acknowledge {"visualizado_em": "..."}
+func (h *AlertsHandler) Acknowledge(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, alertID, req, ok := parseAlertAction(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Acknowledge(r.Context(), alertID, cuidadorID, req.VisualizadoEm, "app"); err != nil {
+		writeAlertError(w, alertID, err)
+		return
+	}
+
+	log.Printf("👀 Alerta %d reconhecido pelo cuidador %d", alertID, cuidadorID)
+	h.respondAlert(w, r, alertID)
+}
+
+// Respond POST /api/alertas/{id}/resposta {"acao": "visto"|"a_caminho"}: botões
+// do push, com a credencial do app do cuidador (rota com RequireApp)
+func (h *AlertsHandler) Respond(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, ok := caregiverID(w, r)
+	if !ok {
+		return
+	}
+
+	alertID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id do alerta inválido")
+		return
+	}
+
+	var req struct {
+		Acao string `json:"acao"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return
+	}
+	if req.Acao != alerts.RespostaVisto && req.Acao != alerts.RespostaACaminho {
+		writeError(w, http.StatusBadRequest, "acao deve ser visto ou a_caminho")
+		return
+	}
+
+	if err := h.service.Respond(r.Context(), alertID, cuidadorID, req.Acao, alerts.CanalPush); err != nil {
+		writeAlertError(w, alertID, err)
+		return
+	}
+
+	log.Printf("👆 Alerta %d: cuidador %d respondeu %q pelo push", alertID, cuidadorID, req.Acao)
+	h.respondAlert(w, r, alertID)
+}
+
+// Resolve POST /api/alerts/{id}/resolve {"nota": "..."}
+func (h *AlertsHandler) Resolve(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, alertID, req, ok := parseAlertAction(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Resolve(r.Context(), alertID, cuidadorID, req.Nota); err != nil {
+		writeAlertError(w, alertID, err)
+		return
+	}
+
+	log.Printf("✅ Alerta %d resolvido pelo cuidador %d", alertID, cuidadorID)
+	h.respondAlert(w, r, alertID)
+}
+
+// AddNote POST /api/alerts/{id}/notes {"nota": "..."}
+func (h *AlertsHandler) AddNote(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, alertID, req, ok := parseAlertAction(w, r)
+	if !ok {
+		return
+	}
+	if strings.TrimSpace(req.Nota) == "" {
+		writeError(w, http.StatusBadRequest, "nota obrigatória")
+		return
+	}
+
+	if err := h.service.AddNote(r.Context(), alertID, cuidadorID, req.Nota); err != nil {
+		writeAlertError(w, alertID, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, map[string]string{"status": "ok"})
+}
+
+func (h *AlertsHandler) respondAlert(w http.ResponseWriter, r *http.Request, alertID int64) {
+	alert, err := h.service.Get(r.Context(), alertID)
+	if err != nil {
+		writeAlertError(w, alertID, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, alert)
+}
+
+// parseAlertAction lê o cuidador autenticado, o id do alerta e o corpo. O
+// cuidador vem sempre da credencial, nunca do corpo.
+func parseAlertAction(w http.ResponseWriter, r *http.Request) (int64, int64, alertActionRequest, bool) {
+	var req alertActionRequest
+
+	cuidadorID, ok := caregiverID(w, r)
+	if !ok {
+		return 0, 0, req, false
+	}
+
+	alertID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id do alerta inválido")
+		return 0, 0, req, false
+	}
+
+	// Corpo vazio vale: o acknowledge não precisa de nada além da credencial
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return 0, 0, req, false
+	}
+
+	return cuidadorID, alertID, req, true
+}
+
+// writeAlertError traduz os erros do serviço de alertas em status HTTP
+func writeAlertError(w http.ResponseWriter, alertID int64, err error) {
+	var transitionErr *alerts.TransitionError
+
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		writeError(w, http.StatusNotFound, "alerta não encontrado")
+	case errors.Is(err, alerts.ErrNotCaregiver):
+		writeError(w, http.StatusForbidden, err.Error())
+	case errors.As(err, &transitionErr):
+		writeError(w, http.StatusConflict, transitionErr.Error())
+	default:
+		log.Printf("❌ Erro no alerta %d: %v", alertID, err)
+		writeError(w, http.StatusInternalServerError, "falha ao processar alerta")
+	}
+}
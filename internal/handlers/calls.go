@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"eva-mind/internal/calls"
+	"eva-mind/internal/devices"
+	"eva-mind/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// CallsHandler eventos do app do idoso sobre a chamada recebida (rotas com RequireApp)
+type CallsHandler struct {
+	service *calls.Service
+}
+
+// NewCallsHandler cria o handler de chamadas
+func NewCallsHandler(service *calls.Service) *CallsHandler {
+	return &CallsHandler{service: service}
+}
+
+// Get GET /api/chamadas/{sessao}
+func (h *CallsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.Get)
+}
+
+// Delivered POST /api/chamadas/{sessao}/entregue: o push chegou e o app está tocando
+func (h *CallsHandler) Delivered(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.Delivered)
+}
+
+// Declined POST /api/chamadas/{sessao}/recusada: o idoso recusou a chamada
+func (h *CallsHandler) Declined(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, r, h.service.Declined)
+}
+
+func (h *CallsHandler) respond(w http.ResponseWriter, r *http.Request, action func(ctx context.Context, idosoID int64, sessaoID string) (*calls.Call, error)) {
+	p := middleware.AppPrincipal(r.Context())
+	if p.Tipo != devices.TipoIdoso {
+		writeError(w, http.StatusForbidden, "apenas o app do idoso informa o estado da chamada")
+		return
+	}
+
+	call, err := action(r.Context(), p.PessoaID, mux.Vars(r)["sessao"])
+	var transitionErr *calls.TransitionError
+	switch {
+	case errors.Is(err, calls.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.As(err, &transitionErr):
+		writeError(w, http.StatusConflict, err.Error())
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err.Error())
+	default:
+		writeJSON(w, http.StatusOK, call)
+	}
+}
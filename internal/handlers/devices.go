@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"eva-mind/internal/devices"
+	"eva-mind/internal/middleware"
+)
+
+// DevicesHandler registro de dispositivos dos apps (rotas com RequireApp)
+type DevicesHandler struct {
+	service      *devices.Service
+	vapidPublica string
+}
+
+// NewDevicesHandler cria o handler de dispositivos. vapidPublica vazia = sem Web Push.
+func NewDevicesHandler(service *devices.Service, vapidPublica string) *DevicesHandler {
+	return &DevicesHandler{service: service, vapidPublica: vapidPublica}
+}
+
+// WebPushKey GET /api/dispositivos/webpush: chave VAPID para o pushManager.subscribe do painel
+func (h *DevicesHandler) WebPushKey(w http.ResponseWriter, r *http.Request) {
+	if h.vapidPublica == "" {
+		writeError(w, http.StatusNotFound, "web push não configurado")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"chave_publica": h.vapidPublica})
+}
+
+// Register POST /api/dispositivos {"token", "plataforma", "versao_app", "locale", "token_anterior"}.
+// Serve tanto para o primeiro registro quanto para a troca de token.
+func (h *DevicesHandler) Register(w http.ResponseWriter, r *http.Request) {
+	p := middleware.AppPrincipal(r.Context())
+
+	var reg devices.Registration
+	if err := json.NewDecoder(r.Body).Decode(&reg); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo inválido")
+		return
+	}
+	if err := reg.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	d, err := h.service.Register(r.Context(), *p, reg)
+	if errors.Is(err, devices.ErrInvalidToken) {
+		writeError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, d)
+}
+
+// List GET /api/dispositivos
+func (h *DevicesHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.List(r.Context(), *middleware.AppPrincipal(r.Context()))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// Remove DELETE /api/dispositivos/{id} (logout no aparelho)
+func (h *DevicesHandler) Remove(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id inválido")
+		return
+	}
+
+	err = h.service.Remove(r.Context(), *middleware.AppPrincipal(r.Context()), id)
+	if errors.Is(err, devices.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"eva-mind/internal/alerts"
+)
+
+// EscalationHandler gerencia as políticas de escalonamento de alertas
+type EscalationHandler struct {
+	alerts    *alerts.Service
+	escalator *alerts.Escalator
+}
+
+// NewEscalationHandler cria o handler de políticas de escalonamento
+func NewEscalationHandler(service *alerts.Service, escalator *alerts.Escalator) *EscalationHandler {
+	return &EscalationHandler{alerts: service, escalator: escalator}
+}
+
+// GetElderPolicies GET /api/idosos/{id}/politicas-escalonamento
+//
+// Retorna a política efetiva de cada severidade (cadastrada ou padrão). Rota com RequireElder.
+func (h *EscalationHandler) GetElderPolicies(w http.ResponseWriter, r *http.Request) {
+	idosoID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id do idoso inválido")
+		return
+	}
+
+	policies := make(map[string]*alerts.Policy)
+	for _, sev := range []string{"critica", "alta", "media", "baixa", "aviso"} {
+		p, err := h.escalator.PolicyFor(r.Context(), idosoID, sev)
+		if err != nil {
+			log.Printf("❌ Erro ao carregar política do idoso %d: %v", idosoID, err)
+			writeError(w, http.StatusInternalServerError, "falha ao carregar políticas")
+			return
+		}
+		policies[sev] = p
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"politicas": policies})
+}
+
+// SavePolicy POST /api/politicas-escalonamento
+//
+// Cadastra a política de uma severidade para um idoso (idoso_id), uma
+// entidade (entidade_nome) ou global (nenhum dos dois), substituindo a anterior.
+// Rota de operação, com RequireAdmin.
+func (h *EscalationHandler) SavePolicy(w http.ResponseWriter, r *http.Request) {
+	var p alerts.Policy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return
+	}
+	h.save(w, r, &p)
+}
+
+// SaveElderPolicy POST /api/idosos/{id}/politicas-escalonamento
+//
+// Cadastra a política de uma severidade do idoso. Só o app de um cuidador
+// ativo do idoso (RequireElder); o idoso vem da rota, nunca do corpo.
+func (h *EscalationHandler) SaveElderPolicy(w http.ResponseWriter, r *http.Request) {
+	if _, ok := caregiverID(w, r); !ok {
+		return
+	}
+	idosoID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id do idoso inválido")
+		return
+	}
+
+	var p alerts.Policy
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo da requisição inválido")
+		return
+	}
+	if p.Entidade != "" {
+		writeError(w, http.StatusBadRequest, "políticas da entidade são cadastradas pela operação")
+		return
+	}
+	p.IdosoID = &idosoID
+	h.save(w, r, &p)
+}
+
+func (h *EscalationHandler) save(w http.ResponseWriter, r *http.Request, p *alerts.Policy) {
+	if err := p.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := h.alerts.SavePolicy(r.Context(), p); err != nil {
+		log.Printf("❌ Erro ao salvar política de escalonamento: %v", err)
+		writeError(w, http.StatusInternalServerError, "falha ao salvar política")
+		return
+	}
+
+	log.Printf("📋 Política de escalonamento %d (%s) salva", p.ID, p.Severidade)
+	writeJSON(w, http.StatusCreated, p)
+}
@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"eva-mind/internal/devices"
+	"eva-mind/internal/middleware"
+
+	"github.com/gorilla/mux"
+)
+
+// writeJSON responde com o status e o corpo em JSON
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError responde com {"error": message}
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// pathInt64 lê um parâmetro numérico da rota
+func pathInt64(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(mux.Vars(r)[name], 10, 64)
+}
+
+// caregiverID cuidador autenticado pela credencial do app (rotas com RequireApp).
+// Escreve 403 para outro tipo de credencial.
+func caregiverID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	p := middleware.AppPrincipal(r.Context())
+	if p == nil || p.Tipo != devices.TipoCuidador {
+		writeError(w, http.StatusForbidden, "apenas o app do cuidador acessa esta rota")
+		return 0, false
+	}
+	return p.PessoaID, true
+}
+
+// pathCaregiver cuidador autenticado em rotas /cuidadores/{id}/...: o {id}
+// precisa ser o da credencial
+func pathCaregiver(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	cuidadorID, ok := caregiverID(w, r)
+	if !ok {
+		return 0, false
+	}
+	if id, err := pathInt64(r, "id"); err != nil || id != cuidadorID {
+		writeError(w, http.StatusForbidden, "credencial não é deste cuidador")
+		return 0, false
+	}
+	return cuidadorID, true
+}
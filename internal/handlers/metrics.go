@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"eva-mind/internal/metrics"
+)
+
+// maxMetricsRange limita o intervalo consultado de uma só vez
+const maxMetricsRange = 366 * 24 * time.Hour
+
+// MetricsHandler expõe as métricas longitudinais de saúde dos idosos
+type MetricsHandler struct {
+	service *metrics.Service
+}
+
+// NewMetricsHandler cria o handler de métricas
+func NewMetricsHandler(service *metrics.Service) *MetricsHandler {
+	return &MetricsHandler{service: service}
+}
+
+// GetElderMetrics GET /api/idosos/{id}/metrics?from=AAAA-MM-DD&to=AAAA-MM-DD&bucket=day|week
+//
+// Rota com RequireElder. Por padrão retorna os últimos 30 dias agrupados por dia. "to" é inclusivo.
+func (h *MetricsHandler) GetElderMetrics(w http.ResponseWriter, r *http.Request) {
+	idosoID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id do idoso inválido")
+		return
+	}
+
+	q := r.URL.Query()
+
+	bucket := q.Get("bucket")
+	if bucket == "" {
+		bucket = metrics.BucketDay
+	}
+	if bucket != metrics.BucketDay && bucket != metrics.BucketWeek {
+		writeError(w, http.StatusBadRequest, "bucket deve ser 'day' ou 'week'")
+		return
+	}
+
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	to := today
+	if v := q.Get("to"); v != "" {
+		if to, err = time.Parse("2006-01-02", v); err != nil {
+			writeError(w, http.StatusBadRequest, "parâmetro 'to' inválido (use AAAA-MM-DD)")
+			return
+		}
+	}
+	to = to.AddDate(0, 0, 1)
+
+	from := to.AddDate(0, 0, -30)
+	if v := q.Get("from"); v != "" {
+		if from, err = time.Parse("2006-01-02", v); err != nil {
+			writeError(w, http.StatusBadRequest, "parâmetro 'from' inválido (use AAAA-MM-DD)")
+			return
+		}
+	}
+
+	if !to.After(from) || to.Sub(from) > maxMetricsRange {
+		writeError(w, http.StatusBadRequest, "intervalo inválido (máximo de 366 dias)")
+		return
+	}
+
+	series, err := h.service.ElderSeries(r.Context(), idosoID, from, to, bucket)
+	if err != nil {
+		log.Printf("❌ Erro ao calcular métricas do idoso %d: %v", idosoID, err)
+		writeError(w, http.StatusInternalServerError, "falha ao calcular métricas")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, series)
+}
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"eva-mind/internal/preferences"
+)
+
+// PreferencesHandler preferências de notificação dos cuidadores
+type PreferencesHandler struct {
+	service *preferences.Service
+}
+
+// NewPreferencesHandler cria o handler de preferências
+func NewPreferencesHandler(service *preferences.Service) *PreferencesHandler {
+	return &PreferencesHandler{service: service}
+}
+
+// Get GET /api/cuidadores/{id}/preferencias ({id} = cuidador da credencial)
+func (h *PreferencesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, ok := pathCaregiver(w, r)
+	if !ok {
+		return
+	}
+
+	p, err := h.service.Get(r.Context(), cuidadorID)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+// Save PUT /api/cuidadores/{id}/preferencias. Campos omitidos ficam com o padrão.
+func (h *PreferencesHandler) Save(w http.ResponseWriter, r *http.Request) {
+	cuidadorID, ok := pathCaregiver(w, r)
+	if !ok {
+		return
+	}
+
+	p := preferences.Default(cuidadorID)
+	if err := json.NewDecoder(r.Body).Decode(p); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo inválido")
+		return
+	}
+	p.CuidadorID = cuidadorID
+
+	if err := p.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.service.Save(r.Context(), p); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+
+	"eva-mind/internal/devices"
+	"eva-mind/internal/middleware"
+	"eva-mind/internal/reports"
+)
+
+// ReportsHandler expõe os resumos clínicos semanais
+type ReportsHandler struct {
+	service *reports.Service
+	devices *devices.Service
+}
+
+// NewReportsHandler cria o handler de relatórios. devices confere se a
+// credencial pode ver o idoso do relatório.
+func NewReportsHandler(service *reports.Service, devices *devices.Service) *ReportsHandler {
+	return &ReportsHandler{service: service, devices: devices}
+}
+
+// ListElderReports GET /api/idosos/{id}/relatorios?limit=N (rota com RequireElder)
+func (h *ReportsHandler) ListElderReports(w http.ResponseWriter, r *http.Request) {
+	idosoID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id do idoso inválido")
+		return
+	}
+
+	limit := 12
+	if v := r.URL.Query().Get("limit"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 52 {
+			limit = n
+		}
+	}
+
+	list, err := h.service.List(r.Context(), idosoID, limit)
+	if err != nil {
+		log.Printf("❌ Erro ao listar relatórios do idoso %d: %v", idosoID, err)
+		writeError(w, http.StatusInternalServerError, "falha ao listar relatórios")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"relatorios": list})
+}
+
+// GetReport GET /api/relatorios/{id}: só para o idoso do relatório e seus cuidadores
+func (h *ReportsHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id do relatório inválido")
+		return
+	}
+
+	report, err := h.service.Get(r.Context(), id)
+	if err == sql.ErrNoRows {
+		writeError(w, http.StatusNotFound, "relatório não encontrado")
+		return
+	}
+	if err != nil {
+		log.Printf("❌ Erro ao buscar relatório %d: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "falha ao buscar relatório")
+		return
+	}
+
+	allowed, err := h.devices.CanAccessElder(r.Context(), middleware.AppPrincipal(r.Context()), report.IdosoID)
+	if err != nil {
+		log.Printf("❌ Erro ao verificar acesso ao relatório %d: %v", id, err)
+		writeError(w, http.StatusInternalServerError, "falha ao buscar relatório")
+		return
+	}
+	if !allowed {
+		// Mesmo 404 de inexistente: não revela relatórios de outros idosos
+		writeError(w, http.StatusNotFound, "relatório não encontrado")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, report)
+}
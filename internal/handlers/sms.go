@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+
+	"eva-mind/internal/sms"
+)
+
+// SMSHandler recebe os callbacks de entrega do Twilio
+type SMSHandler struct {
+	service *sms.Service
+}
+
+// NewSMSHandler cria o handler de callbacks de SMS
+func NewSMSHandler(service *sms.Service) *SMSHandler {
+	return &SMSHandler{service: service}
+}
+
+// Status POST /api/sms/status (form do Twilio: MessageSid, MessageStatus, ErrorCode)
+func (h *SMSHandler) Status(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "formulário inválido")
+		return
+	}
+
+	if !h.service.ValidSignature(r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+		writeError(w, http.StatusForbidden, "assinatura inválida")
+		return
+	}
+
+	sid := r.PostForm.Get("MessageSid")
+	status := r.PostForm.Get("MessageStatus")
+	if sid == "" || status == "" {
+		writeError(w, http.StatusBadRequest, "MessageSid e MessageStatus obrigatórios")
+		return
+	}
+
+	if err := h.service.HandleStatus(r.Context(), sid, status, r.PostForm.Get("ErrorCode")); err != nil {
+		log.Printf("❌ Erro ao processar status do SMS %s: %v", sid, err)
+		writeError(w, http.StatusInternalServerError, "falha ao processar status")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+
+	"eva-mind/internal/voice"
+)
+
+// VoiceHandler webhooks do Twilio Voice para as ligações de alerta
+type VoiceHandler struct {
+	service *voice.Service
+}
+
+// NewVoiceHandler cria o handler das ligações de alerta
+func NewVoiceHandler(service *voice.Service) *VoiceHandler {
+	return &VoiceHandler{service: service}
+}
+
+// TwiML POST /api/voice/chamadas/{id}/twiml?repeticao=N
+func (h *VoiceHandler) TwiML(w http.ResponseWriter, r *http.Request) {
+	callID, ok := h.verify(w, r)
+	if !ok {
+		return
+	}
+
+	repeat, _ := strconv.Atoi(r.URL.Query().Get("repeticao"))
+	body, err := h.service.TwiML(r.Context(), callID, repeat)
+	h.writeTwiML(w, callID, body, err)
+}
+
+// Gather POST /api/voice/chamadas/{id}/gather (form do Twilio: Digits)
+func (h *VoiceHandler) Gather(w http.ResponseWriter, r *http.Request) {
+	callID, ok := h.verify(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := h.service.Gather(r.Context(), callID, r.PostForm.Get("Digits"))
+	h.writeTwiML(w, callID, body, err)
+}
+
+// Status POST /api/voice/chamadas/{id}/status (form do Twilio: CallStatus)
+func (h *VoiceHandler) Status(w http.ResponseWriter, r *http.Request) {
+	callID, ok := h.verify(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.HandleStatus(r.Context(), callID, r.PostForm.Get("CallStatus")); err != nil {
+		log.Printf("❌ Erro ao processar status da ligação %d: %v", callID, err)
+		writeError(w, http.StatusInternalServerError, "falha ao processar status")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// verify lê o id da ligação e confere a assinatura do Twilio
+func (h *VoiceHandler) verify(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	callID, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id da ligação inválido")
+		return 0, false
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "formulário inválido")
+		return 0, false
+	}
+
+	if !h.service.ValidSignature(r.URL.RequestURI(), r.PostForm, r.Header.Get("X-Twilio-Signature")) {
+		writeError(w, http.StatusForbidden, "assinatura inválida")
+		return 0, false
+	}
+
+	return callID, true
+}
+
+func (h *VoiceHandler) writeTwiML(w http.ResponseWriter, callID int64, body []byte, err error) {
+	if err != nil {
+		log.Printf("❌ Erro no roteiro da ligação %d: %v", callID, err)
+		writeError(w, http.StatusInternalServerError, "falha ao montar a ligação")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/xml")
+	w.Write(body)
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"eva-mind/internal/webhooks"
+)
+
+// WebhooksHandler assinaturas de webhooks das entidades (feature api_integracao).
+// A entidade vem do cabeçalho X-Entity-Name (ou ?entity=), como no middleware de planos.
+type WebhooksHandler struct {
+	service *webhooks.Service
+}
+
+// NewWebhooksHandler cria o handler de webhooks
+func NewWebhooksHandler(service *webhooks.Service) *WebhooksHandler {
+	return &WebhooksHandler{service: service}
+}
+
+func entityName(r *http.Request) string {
+	if e := r.URL.Query().Get("entity"); e != "" {
+		return e
+	}
+	return r.Header.Get("X-Entity-Name")
+}
+
+// List GET /api/webhooks
+func (h *WebhooksHandler) List(w http.ResponseWriter, r *http.Request) {
+	list, err := h.service.List(r.Context(), entityName(r))
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"assinaturas": list, "eventos": webhooks.Eventos})
+}
+
+// Create POST /api/webhooks {"url": "...", "eventos": [...], "descricao": "..."}.
+// O segredo de assinatura só aparece nesta resposta.
+func (h *WebhooksHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var sub webhooks.Subscription
+	if err := json.NewDecoder(r.Body).Decode(&sub); err != nil {
+		writeError(w, http.StatusBadRequest, "corpo inválido")
+		return
+	}
+	sub.Entidade = entityName(r)
+
+	if err := sub.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.service.Create(r.Context(), &sub); err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, sub)
+}
+
+// Delete DELETE /api/webhooks/{id} (desativa a assinatura)
+func (h *WebhooksHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id inválido")
+		return
+	}
+
+	if err := h.service.Disable(r.Context(), entityName(r), id); err != nil {
+		h.fail(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Ping POST /api/webhooks/{id}/ping (enfileira um evento de teste)
+func (h *WebhooksHandler) Ping(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id inválido")
+		return
+	}
+
+	entregaID, err := h.service.Ping(r.Context(), entityName(r), id)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]int64{"entrega_id": entregaID})
+}
+
+// Deliveries GET /api/webhooks/{id}/entregas?status=erro&limit=50
+func (h *WebhooksHandler) Deliveries(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id inválido")
+		return
+	}
+
+	limit := 50
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+
+	list, err := h.service.Deliveries(r.Context(), entityName(r), id, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, list)
+}
+
+// GetDelivery GET /api/webhooks/entregas/{id} (payload e log de tentativas)
+func (h *WebhooksHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id inválido")
+		return
+	}
+
+	d, err := h.service.Get(r.Context(), entityName(r), id)
+	if err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, d)
+}
+
+// Redeliver POST /api/webhooks/entregas/{id}/reenviar
+func (h *WebhooksHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id, err := pathInt64(r, "id")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "id inválido")
+		return
+	}
+
+	if err := h.service.Redeliver(r.Context(), entityName(r), id); err != nil {
+		h.fail(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, map[string]string{"status": webhooks.StatusPendente})
+}
+
+func (h *WebhooksHandler) fail(w http.ResponseWriter, err error) {
+	if errors.Is(err, webhooks.ErrNotFound) {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, err.Error())
+}
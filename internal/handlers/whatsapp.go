@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"eva-mind/internal/whatsapp"
+)
+
+// WhatsAppHandler webhook da WhatsApp Business Cloud API
+type WhatsAppHandler struct {
+	service *whatsapp.Service
+}
+
+// NewWhatsAppHandler cria o handler do webhook do WhatsApp
+func NewWhatsAppHandler(service *whatsapp.Service) *WhatsAppHandler {
+	return &WhatsAppHandler{service: service}
+}
+
+// Verify GET /api/whatsapp/webhook (hub.mode, hub.verify_token, hub.challenge)
+func (h *WhatsAppHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	challenge, ok := h.service.Verify(q.Get("hub.mode"), q.Get("hub.verify_token"), q.Get("hub.challenge"))
+	if !ok {
+		writeError(w, http.StatusForbidden, "token de verificação inválido")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte(challenge))
+}
+
+// Webhook POST /api/whatsapp/webhook (status das mensagens e respostas dos botões)
+func (h *WhatsAppHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "corpo inválido")
+		return
+	}
+
+	if !h.service.ValidSignature(body, r.Header.Get("X-Hub-Signature-256")) {
+		writeError(w, http.StatusForbidden, "assinatura inválida")
+		return
+	}
+
+	if err := h.service.HandleWebhook(r.Context(), body); err != nil {
+		log.Printf("❌ Erro no webhook do WhatsApp: %v", err)
+		writeError(w, http.StatusBadRequest, "payload inválido")
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,276 @@
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Granularidades suportadas para as séries temporais
+const (
+	BucketDay  = "day"
+	BucketWeek = "week"
+)
+
+// Point métricas agregadas de um intervalo (dia ou semana)
+type Point struct {
+	Inicio time.Time `json:"inicio"`
+
+	// Ligações analisadas (historico_ligacoes)
+	LigacoesAnalisadas int            `json:"ligacoes_analisadas"`
+	Humor              map[string]int `json:"humor"`
+	DorMedia           *float64       `json:"dor_media"`
+	EpisodiosDor       int            `json:"episodios_dor"`
+	EpisodiosConfusao  int            `json:"episodios_confusao"`
+	EpisodiosSolidao   int            `json:"episodios_solidao"`
+
+	// Agendamentos. A taxa de conclusão considera só as chamadas já finalizadas.
+	ChamadasAgendadas     int      `json:"chamadas_agendadas"`
+	ChamadasConcluidas    int      `json:"chamadas_concluidas"`
+	ChamadasNaoAtendidas  int      `json:"chamadas_nao_atendidas"`
+	TaxaConclusao         *float64 `json:"taxa_conclusao_chamadas"`
+	LembretesMedicacao    int      `json:"lembretes_medicacao"`
+	MedicacoesConfirmadas int      `json:"medicacoes_confirmadas"`
+	AdesaoMedicacao       *float64 `json:"adesao_medicacao"`
+
+	somaDor     int
+	finalizadas int
+}
+
+// Series série temporal de métricas de um idoso
+type Series struct {
+	IdosoID int64     `json:"idoso_id"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Bucket  string    `json:"bucket"`
+	Pontos  []*Point  `json:"pontos"`
+	Resumo  *Point    `json:"resumo"`
+}
+
+// Service calcula métricas longitudinais de saúde
+type Service struct {
+	db *sql.DB
+}
+
+// NewService cria um novo serviço de métricas
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// ElderSeries calcula as métricas do idoso no intervalo [from, to), agrupadas por bucket
+func (s *Service) ElderSeries(ctx context.Context, idosoID int64, from, to time.Time, bucket string) (*Series, error) {
+	if bucket != BucketDay && bucket != BucketWeek {
+		return nil, fmt.Errorf("bucket inválido: %s", bucket)
+	}
+	if !to.After(from) {
+		return nil, fmt.Errorf("intervalo inválido")
+	}
+
+	series := &Series{
+		IdosoID: idosoID,
+		From:    from,
+		To:      to,
+		Bucket:  bucket,
+		Resumo:  newPoint(from),
+	}
+
+	points := make(map[string]*Point)
+	for t := truncate(from, bucket); t.Before(to); t = next(t, bucket) {
+		p := newPoint(t)
+		points[bucketKey(t)] = p
+		series.Pontos = append(series.Pontos, p)
+	}
+
+	if err := s.loadCalls(ctx, idosoID, from, to, bucket, points); err != nil {
+		return nil, err
+	}
+	if err := s.loadMoods(ctx, idosoID, from, to, bucket, points); err != nil {
+		return nil, err
+	}
+	if err := s.loadSchedules(ctx, idosoID, from, to, bucket, points); err != nil {
+		return nil, err
+	}
+
+	for _, p := range series.Pontos {
+		p.finish()
+		series.Resumo.add(p)
+	}
+	series.Resumo.finish()
+
+	return series, nil
+}
+
+func (s *Service) loadCalls(ctx context.Context, idosoID int64, from, to time.Time, bucket string, points map[string]*Point) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			date_trunc($2, inicio_chamada) AS bucket,
+			COUNT(*) AS analisadas,
+			COALESCE(SUM(dor_intensidade), 0) AS soma_dor,
+			COUNT(*) FILTER (WHERE dor_intensidade > 0) AS episodios_dor,
+			COUNT(*) FILTER (WHERE confusao = true) AS episodios_confusao,
+			COUNT(*) FILTER (WHERE solidao = true) AS episodios_solidao
+		FROM historico_ligacoes
+		WHERE idoso_id = $1
+		  AND inicio_chamada >= $3 AND inicio_chamada < $4
+		  AND sentimento_geral IS NOT NULL
+		GROUP BY 1
+	`, idosoID, bucket, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query call metrics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t time.Time
+		var analisadas, somaDor, dor, confusao, solidao int
+		if err := rows.Scan(&t, &analisadas, &somaDor, &dor, &confusao, &solidao); err != nil {
+			return fmt.Errorf("failed to scan call metrics: %w", err)
+		}
+
+		p := pointFor(points, t)
+		p.LigacoesAnalisadas += analisadas
+		p.somaDor += somaDor
+		p.EpisodiosDor += dor
+		p.EpisodiosConfusao += confusao
+		p.EpisodiosSolidao += solidao
+	}
+
+	return rows.Err()
+}
+
+func (s *Service) loadMoods(ctx context.Context, idosoID int64, from, to time.Time, bucket string, points map[string]*Point) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT date_trunc($2, inicio_chamada) AS bucket, sentimento_geral, COUNT(*)
+		FROM historico_ligacoes
+		WHERE idoso_id = $1
+		  AND inicio_chamada >= $3 AND inicio_chamada < $4
+		  AND sentimento_geral IS NOT NULL
+		GROUP BY 1, 2
+	`, idosoID, bucket, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query mood distribution: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t time.Time
+		var humor string
+		var total int
+		if err := rows.Scan(&t, &humor, &total); err != nil {
+			return fmt.Errorf("failed to scan mood distribution: %w", err)
+		}
+		pointFor(points, t).Humor[humor] += total
+	}
+
+	return rows.Err()
+}
+
+func (s *Service) loadSchedules(ctx context.Context, idosoID int64, from, to time.Time, bucket string, points map[string]*Point) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT
+			date_trunc($2, data_hora_agendada) AS bucket,
+			COUNT(*) AS agendadas,
+			COUNT(*) FILTER (WHERE status NOT IN ('agendado', 'em_andamento')) AS finalizadas,
+			COUNT(*) FILTER (WHERE status = 'concluido') AS concluidas,
+			COUNT(*) FILTER (WHERE status = 'nao_atendido') AS nao_atendidas,
+			COUNT(*) FILTER (WHERE tipo = 'lembrete_medicamento' AND status NOT IN ('agendado', 'em_andamento')) AS lembretes,
+			COUNT(*) FILTER (WHERE tipo = 'lembrete_medicamento' AND medicamento_confirmado = true) AS confirmados
+		FROM agendamentos
+		WHERE idoso_id = $1
+		  AND data_hora_agendada >= $3 AND data_hora_agendada < $4
+		GROUP BY 1
+	`, idosoID, bucket, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query schedule metrics: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t time.Time
+		var agendadas, finalizadas, concluidas, naoAtendidas, lembretes, confirmados int
+		if err := rows.Scan(&t, &agendadas, &finalizadas, &concluidas, &naoAtendidas, &lembretes, &confirmados); err != nil {
+			return fmt.Errorf("failed to scan schedule metrics: %w", err)
+		}
+
+		p := pointFor(points, t)
+		p.ChamadasAgendadas += agendadas
+		p.finalizadas += finalizadas
+		p.ChamadasConcluidas += concluidas
+		p.ChamadasNaoAtendidas += naoAtendidas
+		p.LembretesMedicacao += lembretes
+		p.MedicacoesConfirmadas += confirmados
+	}
+
+	return rows.Err()
+}
+
+func newPoint(t time.Time) *Point {
+	return &Point{Inicio: t, Humor: make(map[string]int)}
+}
+
+// pointFor localiza o ponto do bucket retornado pelo banco
+func pointFor(points map[string]*Point, t time.Time) *Point {
+	key := bucketKey(t)
+	if p, ok := points[key]; ok {
+		return p
+	}
+	p := newPoint(t)
+	points[key] = p
+	return p
+}
+
+// bucketKey identifica o bucket pela data, independente do fuso devolvido pelo driver
+func bucketKey(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+// add acumula as contagens de outro ponto (usado no resumo do período)
+func (p *Point) add(o *Point) {
+	p.LigacoesAnalisadas += o.LigacoesAnalisadas
+	p.somaDor += o.somaDor
+	p.EpisodiosDor += o.EpisodiosDor
+	p.EpisodiosConfusao += o.EpisodiosConfusao
+	p.EpisodiosSolidao += o.EpisodiosSolidao
+	p.ChamadasAgendadas += o.ChamadasAgendadas
+	p.finalizadas += o.finalizadas
+	p.ChamadasConcluidas += o.ChamadasConcluidas
+	p.ChamadasNaoAtendidas += o.ChamadasNaoAtendidas
+	p.LembretesMedicacao += o.LembretesMedicacao
+	p.MedicacoesConfirmadas += o.MedicacoesConfirmadas
+	for humor, total := range o.Humor {
+		p.Humor[humor] += total
+	}
+}
+
+// finish calcula as médias e taxas; ficam nulas quando não há dados
+func (p *Point) finish() {
+	p.DorMedia = ratio(p.somaDor, p.LigacoesAnalisadas)
+	p.TaxaConclusao = ratio(p.ChamadasConcluidas, p.finalizadas)
+	p.AdesaoMedicacao = ratio(p.MedicacoesConfirmadas, p.LembretesMedicacao)
+}
+
+func ratio(num, den int) *float64 {
+	if den == 0 {
+		return nil
+	}
+	v := float64(num) / float64(den)
+	return &v
+}
+
+// truncate alinha t ao início do bucket, como date_trunc do PostgreSQL (semana começa na segunda)
+func truncate(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if bucket == BucketWeek {
+		offset := (int(day.Weekday()) + 6) % 7
+		day = day.AddDate(0, 0, -offset)
+	}
+	return day
+}
+
+func next(t time.Time, bucket string) time.Time {
+	if bucket == BucketWeek {
+		return t.AddDate(0, 0, 7)
+	}
+	return t.AddDate(0, 0, 1)
+}
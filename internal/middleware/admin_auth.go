@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// RequireAdmin protege as rotas de operação (políticas da entidade,
+// reprocessamento) com o token ADMIN_API_TOKEN em "Authorization: Bearer".
+// Sem token configurado, as rotas ficam fechadas.
+func RequireAdmin(token string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if token == "" {
+				writeAuthError(w, http.StatusForbidden, "API de operação desabilitada")
+				return
+			}
+
+			given := strings.TrimSpace(strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "))
+			if subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="eva-mind-admin"`)
+				writeAuthError(w, http.StatusUnauthorized, "token de operação inválido")
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"eva-mind/internal/devices"
+
+	"github.com/gorilla/mux"
+)
+
+type principalKey struct{}
+
+// AppAuthMiddleware autentica os apps do idoso e do cuidador pelo token Bearer
+type AppAuthMiddleware struct {
+	devices *devices.Service
+}
+
+// NewAppAuthMiddleware cria o middleware de autenticação dos apps
+func NewAppAuthMiddleware(service *devices.Service) *AppAuthMiddleware {
+	return &AppAuthMiddleware{devices: service}
+}
+
+// RequireApp exige "Authorization: Bearer <credencial do app>" e coloca a pessoa no contexto
+func (am *AppAuthMiddleware) RequireApp(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+		p, err := am.devices.Authenticate(r.Context(), strings.TrimSpace(token))
+		if err != nil {
+			status := http.StatusUnauthorized
+			if !errors.Is(err, devices.ErrUnauthorized) {
+				log.Printf("❌ Erro ao autenticar app: %v", err)
+				status = http.StatusInternalServerError
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="eva-mind"`)
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]string{"error": "credencial do app inválida"})
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, p)))
+	})
+}
+
+// RequireElder usado depois do RequireApp em rotas /idosos/{id}/...: só o
+// próprio idoso e os cuidadores ativos dele passam
+func (am *AppAuthMiddleware) RequireElder(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idosoID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+		if err != nil {
+			writeAuthError(w, http.StatusBadRequest, "id do idoso inválido")
+			return
+		}
+
+		ok, err := am.devices.CanAccessElder(r.Context(), AppPrincipal(r.Context()), idosoID)
+		if err != nil {
+			log.Printf("❌ Erro ao verificar acesso ao idoso %d: %v", idosoID, err)
+			writeAuthError(w, http.StatusInternalServerError, "falha ao verificar acesso")
+			return
+		}
+		if !ok {
+			writeAuthError(w, http.StatusForbidden, "credencial sem acesso a este idoso")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func writeAuthError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// AppPrincipal pessoa autenticada pelo RequireApp (nil fora dele)
+func AppPrincipal(ctx context.Context) *devices.Principal {
+	p, _ := ctx.Value(principalKey{}).(*devices.Principal)
+	return p
+}
@@ -0,0 +1,87 @@
+package notify
+
+import (
+	"context"
+	"log"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/preferences"
+)
+
+// MulticastNotifier canal capaz de enviar o mesmo alerta a vários destinatários
+// numa única requisição. Os erros voltam na ordem dos destinatários.
+type MulticastNotifier interface {
+	Notifier
+	NotifyMany(ctx context.Context, alert *alerts.Alert, rs []alerts.Recipient) []error
+}
+
+// Result resultado do envio para um destinatário em DispatchAll
+type Result struct {
+	Recipient alerts.Recipient
+	Canal     string
+	Err       error
+}
+
+// DispatchAll entrega o alerta a vários destinatários. Quem tem push como
+// primeiro canal recebe num único multicast; falhas do multicast e os demais
+// seguem o fallback individual. Os resultados seguem a ordem de rs.
+func (d *Dispatcher) DispatchAll(ctx context.Context, alert *alerts.Alert, rs []alerts.Recipient) []Result {
+	results := make([]Result, len(rs))
+	channels := make([][]string, len(rs))
+	multi, _ := d.notifiers[alerts.CanalPush].(MulticastNotifier)
+
+	var batch []int
+	for i, r := range rs {
+		results[i].Recipient = r
+
+		switch d.decide(ctx, alert, r) {
+		case preferences.Adiar:
+			results[i].Canal = CanalResumo
+			continue
+		case preferences.Descartar:
+			results[i].Err = ErrOptedOut
+			continue
+		}
+
+		channels[i] = d.Channels(alert, r, r.MetodoPreferido)
+		if multi != nil && len(channels[i]) > 0 && channels[i][0] == alerts.CanalPush {
+			batch = append(batch, i)
+			continue
+		}
+		results[i].Canal, results[i].Err = d.try(ctx, alert, r, channels[i])
+	}
+
+	if len(batch) == 0 {
+		return results
+	}
+
+	recipients := make([]alerts.Recipient, len(batch))
+	for j, i := range batch {
+		recipients[j] = rs[i]
+	}
+	errs := multi.NotifyMany(ctx, alert, recipients)
+
+	for j, i := range batch {
+		err := errs[j]
+		ev := alerts.Event{
+			Canal:        alerts.CanalPush,
+			Destinatario: rs[i].Label(),
+			Sucesso:      err == nil,
+			Detalhes:     "multicast",
+		}
+		if err != nil {
+			ev.Erro = err.Error()
+		}
+		d.record(ctx, alert, ev)
+
+		if err == nil {
+			results[i].Canal = alerts.CanalPush
+			continue
+		}
+
+		log.Printf("⚠️ Alerta %d: push falhou para %s: %v", alert.ID, rs[i].Label(), err)
+		results[i].Canal, results[i].Err = d.tryAfter(ctx, alert, rs[i], channels[i][1:], alerts.CanalPush)
+	}
+
+	return results
+}
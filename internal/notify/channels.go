@@ -0,0 +1,267 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/email"
+	"eva-mind/internal/push"
+	"eva-mind/internal/sms"
+)
+
+// isMissedCall alertas de chamada não atendida usam mensagens próprias
+func isMissedCall(alert *alerts.Alert) bool {
+	return alert.Tipo == "nao_atende_telefone"
+}
+
+// alertRef ids do alerta para o deep link e as ações do push
+func alertRef(alert *alerts.Alert) push.AlertRef {
+	return push.AlertRef{
+		AlertID:    alert.ID,
+		ElderID:    alert.IdosoID,
+		ElderName:  alert.NomeIdoso,
+		ElderPhone: alert.TelefoneIdoso,
+	}
+}
+
+// PushNotifier canal push: FCM (Android/iOS) e Web Push (painel web)
+type PushNotifier struct {
+	push *push.FirebaseService
+	web  *push.WebPushService
+}
+
+// NewPushNotifier cria o canal push. Qualquer um dos dois provedores pode ser nil.
+func NewPushNotifier(pushService *push.FirebaseService, webPush *push.WebPushService) *PushNotifier {
+	return &PushNotifier{push: pushService, web: webPush}
+}
+
+func (n *PushNotifier) Channel() string { return alerts.CanalPush }
+
+// Notify envia o push de alerta ou de chamada perdida para todos os aparelhos
+// do destinatário; basta um receber
+func (n *PushNotifier) Notify(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error {
+	tokens := r.Tokens()
+	if len(tokens) > 1 || len(r.WebPush) > 0 || n.push == nil {
+		return n.NotifyMany(ctx, alert, []alerts.Recipient{r})[0]
+	}
+
+	var token string
+	if len(tokens) == 1 {
+		token = tokens[0]
+	}
+	if isMissedCall(alert) {
+		return n.push.SendMissedCallAlert(alertRef(alert), token)
+	}
+	_, err := n.push.SendAlertNotification(alertRef(alert), token, alert.Mensagem)
+	return err
+}
+
+// NotifyMany envia o alerta a todos os aparelhos dos destinatários: um único
+// multicast FCM e um Web Push por assinatura do painel. Um destinatário conta
+// como alcançado se algum aparelho receber.
+func (n *PushNotifier) NotifyMany(ctx context.Context, alert *alerts.Alert, rs []alerts.Recipient) []error {
+	return n.sendMany(ctx, rs,
+		func(tokens []string) *push.MulticastResult {
+			if isMissedCall(alert) {
+				return n.push.SendMissedCallMulticast(alertRef(alert), tokens)
+			}
+			return n.push.SendAlertMulticast(alertRef(alert), tokens, alert.Mensagem)
+		},
+		func(subs []string) []error {
+			if isMissedCall(alert) {
+				return n.web.SendMissedCall(ctx, subs, alertRef(alert))
+			}
+			return n.web.SendAlert(ctx, subs, alertRef(alert), alert.Mensagem)
+		})
+}
+
+// NotifyMedication confirma aos destinatários que o idoso tomou o remédio, pelos
+// mesmos caminhos do alerta (multicast FCM e Web Push)
+func (n *PushNotifier) NotifyMedication(ctx context.Context, rs []alerts.Recipient, elderName, medicationName string) []error {
+	return n.sendMany(ctx, rs,
+		func(tokens []string) *push.MulticastResult {
+			return n.push.SendMedicationMulticast(tokens, elderName, medicationName)
+		},
+		func(subs []string) []error {
+			return n.web.SendMedication(ctx, subs, elderName, medicationName)
+		})
+}
+
+// sendMany junta os tokens FCM de todos os destinatários num multicast e envia
+// o Web Push de cada um; os erros voltam na ordem de rs
+func (n *PushNotifier) sendMany(ctx context.Context, rs []alerts.Recipient, fcm func(tokens []string) *push.MulticastResult, web func(subs []string) []error) []error {
+	errs := make([]error, len(rs))
+	for i := range errs {
+		errs[i] = fmt.Errorf("device token is empty")
+	}
+	reached := make([]bool, len(rs))
+	mark := func(i int, err error) {
+		switch {
+		case err == nil:
+			reached[i] = true
+			errs[i] = nil
+		case !reached[i]:
+			errs[i] = err
+		}
+	}
+
+	var tokens []string
+	owner := make([]int, 0, len(rs))
+	for i, r := range rs {
+		for _, t := range r.Tokens() {
+			tokens = append(tokens, t)
+			owner = append(owner, i)
+		}
+	}
+
+	if len(tokens) > 0 && n.push != nil {
+		for j, res := range fcm(tokens).Results {
+			mark(owner[j], res.Error)
+		}
+	}
+
+	if n.web != nil {
+		for i, r := range rs {
+			if len(r.WebPush) == 0 {
+				continue
+			}
+			for _, err := range web(r.WebPush) {
+				mark(i, err)
+			}
+		}
+	}
+	return errs
+}
+
+// EmailNotifier canal email (SMTP)
+type EmailNotifier struct {
+	email *email.EmailService
+}
+
+// NewEmailNotifier cria o canal email
+func NewEmailNotifier(emailService *email.EmailService) *EmailNotifier {
+	return &EmailNotifier{email: emailService}
+}
+
+func (n *EmailNotifier) Channel() string { return alerts.CanalEmail }
+
+// Notify envia o email de emergência ou de chamada perdida, no idioma do
+// cuidador e no fuso do idoso
+func (n *EmailNotifier) Notify(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error {
+	env := email.Envelope{To: r.Email, Name: r.Nome, IdosoID: alert.IdosoID, CuidadorID: r.CuidadorID, AlertaID: alert.ID}
+	if isMissedCall(alert) {
+		return n.email.SendMissedCallAlert(ctx, env, alert.NomeIdoso, alert.CriadoEm)
+	}
+	return n.email.SendEmergencyAlert(ctx, env, alert.NomeIdoso, alert.Mensagem, alert.CriadoEm)
+}
+
+// SMSSender provedor de SMS; alertID liga a mensagem aos callbacks de entrega
+type SMSSender interface {
+	SendSMS(ctx context.Context, alertID int64, to, body string) error
+}
+
+// SMSNotifier canal SMS
+type SMSNotifier struct {
+	sender SMSSender
+}
+
+// NewSMSNotifier cria o canal SMS sobre um provedor
+func NewSMSNotifier(sender SMSSender) *SMSNotifier {
+	return &SMSNotifier{sender: sender}
+}
+
+func (n *SMSNotifier) Channel() string { return alerts.CanalSMS }
+
+// Notify envia o texto do alerta por SMS
+func (n *SMSNotifier) Notify(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error {
+	return n.sender.SendSMS(ctx, alert.ID, r.Telefone, sms.Text(alert))
+}
+
+// CallPlacer provedor de ligações com texto falado
+type CallPlacer interface {
+	PlaceCall(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error
+}
+
+// CallNotifier canal ligação telefônica
+type CallNotifier struct {
+	placer CallPlacer
+}
+
+// NewCallNotifier cria o canal de ligação sobre um provedor
+func NewCallNotifier(placer CallPlacer) *CallNotifier {
+	return &CallNotifier{placer: placer}
+}
+
+func (n *CallNotifier) Channel() string { return alerts.CanalLigacao }
+
+// Notify liga para o destinatário e lê o alerta
+func (n *CallNotifier) Notify(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error {
+	return n.placer.PlaceCall(ctx, alert, r)
+}
+
+// WhatsAppSender provedor de WhatsApp com templates aprovados
+type WhatsAppSender interface {
+	SendAlert(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error
+}
+
+// WhatsAppNotifier canal WhatsApp
+type WhatsAppNotifier struct {
+	sender WhatsAppSender
+}
+
+// NewWhatsAppNotifier cria o canal WhatsApp sobre um provedor
+func NewWhatsAppNotifier(sender WhatsAppSender) *WhatsAppNotifier {
+	return &WhatsAppNotifier{sender: sender}
+}
+
+func (n *WhatsAppNotifier) Channel() string { return alerts.CanalWhatsApp }
+
+// Notify envia o template do alerta com os botões "Vi" / "Estou indo"
+func (n *WhatsAppNotifier) Notify(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error {
+	return n.sender.SendAlert(ctx, alert, r)
+}
+
+// WebhookNotifier canal webhook (centrais de monitoramento)
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier cria o canal webhook
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (n *WebhookNotifier) Channel() string { return alerts.CanalWebhook }
+
+// Notify envia o alerta em JSON para a URL do destinatário
+func (n *WebhookNotifier) Notify(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"evento": "alerta",
+		"alerta": alert,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondeu %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,301 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/preferences"
+	"eva-mind/internal/sms"
+)
+
+// Notifier entrega um alerta por um canal específico
+type Notifier interface {
+	Channel() string
+	Notify(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error
+}
+
+// ErrNoChannel nenhum canal disponível alcança o destinatário
+var ErrNoChannel = errors.New("nenhum canal disponível para o destinatário")
+
+// ErrOptedOut o cuidador escolheu não receber este tipo de evento ou severidade
+var ErrOptedOut = errors.New("cuidador optou por não receber esta notificação")
+
+// ErrDeferred a notificação ficou para o resumo do cuidador (ver alerts.ErrDeferred)
+var ErrDeferred = alerts.ErrDeferred
+
+// CanalResumo notificação adiada para o resumo do cuidador (silêncio ou modo resumo)
+const CanalResumo = "resumo"
+
+// severityChannels ordem de fallback por severidade. Ligação só para críticos.
+var severityChannels = map[string][]string{
+	"critica": {alerts.CanalPush, alerts.CanalLigacao, alerts.CanalSMS, alerts.CanalWhatsApp, alerts.CanalEmail, alerts.CanalWebhook},
+	"alta":    {alerts.CanalPush, alerts.CanalSMS, alerts.CanalWhatsApp, alerts.CanalEmail, alerts.CanalWebhook},
+}
+
+var defaultChannels = []string{alerts.CanalPush, alerts.CanalWhatsApp, alerts.CanalEmail, alerts.CanalSMS, alerts.CanalWebhook}
+
+// Dispatcher escolhe os canais de cada destinatário e tenta o próximo quando um falha
+type Dispatcher struct {
+	alerts    *alerts.Service
+	prefs     *preferences.Service
+	notifiers map[string]Notifier
+}
+
+// NewDispatcher cria o dispatcher com os canais informados
+func NewDispatcher(alertService *alerts.Service, notifiers ...Notifier) *Dispatcher {
+	d := &Dispatcher{
+		alerts:    alertService,
+		notifiers: make(map[string]Notifier),
+	}
+	for _, n := range notifiers {
+		d.Register(n)
+	}
+	return d
+}
+
+// WithPreferences aplica as preferências dos cuidadores (eventos, severidade
+// mínima, horário de silêncio e modo resumo) antes de cada envio
+func (d *Dispatcher) WithPreferences(prefs *preferences.Service) *Dispatcher {
+	d.prefs = prefs
+	return d
+}
+
+// Register adiciona (ou substitui) o notifier do canal
+func (d *Dispatcher) Register(n Notifier) {
+	d.notifiers[n.Channel()] = n
+}
+
+// Available informa se o canal está configurado
+func (d *Dispatcher) Available(canal string) bool {
+	_, ok := d.notifiers[canal]
+	return ok
+}
+
+// Push canal push configurado (nil quando não há FCM nem Web Push)
+func (d *Dispatcher) Push() *PushNotifier {
+	if d == nil {
+		return nil
+	}
+	n, _ := d.notifiers[alerts.CanalPush].(*PushNotifier)
+	return n
+}
+
+// NormalizeChannel converte os valores de metodo_preferido para os canais internos
+func NormalizeChannel(metodo string) string {
+	switch m := strings.ToLower(strings.TrimSpace(metodo)); m {
+	case "call", "telefone", "ligacao", "ligação":
+		return alerts.CanalLigacao
+	case "whats", "whatsapp":
+		return alerts.CanalWhatsApp
+	default:
+		return m
+	}
+}
+
+// Channels ordem de tentativa para o destinatário: o preferido (se a severidade
+// permitir) e depois o fallback da severidade, apenas canais configurados e
+// para os quais o destinatário tem endereço
+func (d *Dispatcher) Channels(alert *alerts.Alert, r alerts.Recipient, preferred string) []string {
+	order, ok := severityChannels[alert.Severidade]
+	if !ok {
+		order = defaultChannels
+	}
+
+	preferred = NormalizeChannel(preferred)
+	candidates := order
+	if preferred != "" && contains(order, preferred) {
+		candidates = append([]string{preferred}, order...)
+	}
+
+	return d.filter(candidates, r)
+}
+
+// Dispatch entrega o alerta pelo primeiro canal que funcionar e retorna o canal
+// usado. CanalResumo indica que o envio ficou para o resumo do cuidador.
+func (d *Dispatcher) Dispatch(ctx context.Context, alert *alerts.Alert, r alerts.Recipient, preferred string) (string, error) {
+	switch d.decide(ctx, alert, r) {
+	case preferences.Adiar:
+		return CanalResumo, nil
+	case preferences.Descartar:
+		return "", ErrOptedOut
+	}
+	return d.try(ctx, alert, r, d.Channels(alert, r, preferred))
+}
+
+// Send implementa alerts.Sender: o canal da etapa da política vem primeiro,
+// mesmo que a severidade não o inclua, seguido do fallback normal. Retorna
+// ErrDeferred quando o cuidador está em silêncio ou no modo resumo.
+func (d *Dispatcher) Send(ctx context.Context, alert *alerts.Alert, r alerts.Recipient, canal string) error {
+	switch d.decide(ctx, alert, r) {
+	case preferences.Adiar:
+		return ErrDeferred
+	case preferences.Descartar:
+		return ErrOptedOut
+	}
+
+	channels := d.filter([]string{canal}, r)
+	for _, c := range d.Channels(alert, r, r.MetodoPreferido) {
+		if !contains(channels, c) {
+			channels = append(channels, c)
+		}
+	}
+
+	_, err := d.try(ctx, alert, r, channels)
+	return err
+}
+
+// decide aplica as preferências do cuidador; adiamentos vão para a fila do
+// resumo e ambos os casos ficam no histórico do alerta. Um alerta que já está
+// no resumo pendente do cuidador não é adiado (nem registrado) de novo.
+func (d *Dispatcher) decide(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) preferences.Decision {
+	if d.prefs == nil || r.CuidadorID == 0 {
+		return preferences.Enviar
+	}
+
+	p, err := d.prefs.Get(ctx, r.CuidadorID)
+	if err != nil {
+		// Na dúvida, envia
+		log.Printf("⚠️ %v", err)
+		return preferences.Enviar
+	}
+
+	evento := preferences.EventOf(alert)
+	decision := p.Decide(evento, alert.Severidade, time.Now())
+
+	switch decision {
+	case preferences.Adiar:
+		item := preferences.Deferred{
+			CuidadorID: r.CuidadorID,
+			Evento:     evento,
+			Severidade: alert.Severidade,
+			Mensagem:   sms.Text(alert),
+		}
+		if alert.ID != 0 {
+			item.AlertaID = &alert.ID
+		}
+		queued, err := d.prefs.Defer(ctx, item)
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+			return preferences.Enviar
+		}
+		if !queued {
+			break
+		}
+		d.record(ctx, alert, alerts.Event{
+			Acao:         alerts.AcaoAdiado,
+			Canal:        CanalResumo,
+			Destinatario: r.Label(),
+			Sucesso:      true,
+			Detalhes:     "horário de silêncio ou modo resumo do cuidador",
+		})
+		log.Printf("🌙 Alerta %d adiado para o resumo de %s", alert.ID, r.Label())
+
+	case preferences.Descartar:
+		d.record(ctx, alert, alerts.Event{
+			Acao:         alerts.AcaoSuprimido,
+			Destinatario: r.Label(),
+			Sucesso:      false,
+			Erro:         ErrOptedOut.Error(),
+		})
+	}
+
+	return decision
+}
+
+func (d *Dispatcher) try(ctx context.Context, alert *alerts.Alert, r alerts.Recipient, channels []string) (string, error) {
+	return d.tryAfter(ctx, alert, r, channels, "")
+}
+
+// tryAfter tenta os canais em ordem; after é o canal que já falhou antes deles
+func (d *Dispatcher) tryAfter(ctx context.Context, alert *alerts.Alert, r alerts.Recipient, channels []string, after string) (string, error) {
+	if len(channels) == 0 && after != "" {
+		return "", fmt.Errorf("%s falhou para %s e não há outro canal", after, r.Label())
+	}
+	if len(channels) == 0 {
+		d.record(ctx, alert, alerts.Event{
+			Destinatario: r.Label(),
+			Sucesso:      false,
+			Erro:         ErrNoChannel.Error(),
+		})
+		return "", ErrNoChannel
+	}
+
+	var lastErr error
+	for i, canal := range channels {
+		err := d.notifiers[canal].Notify(ctx, alert, r)
+
+		ev := alerts.Event{
+			Canal:        canal,
+			Destinatario: r.Label(),
+			Sucesso:      err == nil,
+		}
+		switch {
+		case i > 0:
+			ev.Detalhes = fmt.Sprintf("fallback após %s", channels[i-1])
+		case after != "":
+			ev.Detalhes = fmt.Sprintf("fallback após %s", after)
+		}
+		if err != nil {
+			ev.Erro = err.Error()
+		}
+		d.record(ctx, alert, ev)
+
+		if err == nil {
+			return canal, nil
+		}
+
+		log.Printf("⚠️ Alerta %d: %s falhou para %s: %v", alert.ID, canal, r.Label(), err)
+		lastErr = err
+	}
+
+	return "", fmt.Errorf("todos os canais falharam para %s: %w", r.Label(), lastErr)
+}
+
+func (d *Dispatcher) record(ctx context.Context, alert *alerts.Alert, ev alerts.Event) {
+	if alert.ID == 0 || d.alerts == nil {
+		return
+	}
+	if err := d.alerts.RecordAttempt(ctx, alert.ID, ev); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// filter mantém canais configurados, sem repetição, para os quais o destinatário tem endereço
+func (d *Dispatcher) filter(channels []string, r alerts.Recipient) []string {
+	var out []string
+	for _, c := range channels {
+		if !d.Available(c) || !hasAddress(r, c) || contains(out, c) {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func hasAddress(r alerts.Recipient, canal string) bool {
+	switch canal {
+	case alerts.CanalPush:
+		return len(r.Tokens()) > 0 || len(r.WebPush) > 0
+	case alerts.CanalSMS, alerts.CanalLigacao, alerts.CanalWhatsApp:
+		return r.Telefone != ""
+	case alerts.CanalEmail:
+		return r.Email != ""
+	case alerts.CanalWebhook:
+		return r.URL != ""
+	}
+	return false
+}
+
+func contains(list []string, v string) bool {
+	for _, s := range list {
+		if s == v {
+			return true
+		}
+	}
+	return false
+}
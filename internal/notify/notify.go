@@ -0,0 +1,81 @@
+package notify
+
+import (
+	"database/sql"
+	"log"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/config"
+	"eva-mind/internal/email"
+	"eva-mind/internal/preferences"
+	"eva-mind/internal/push"
+	"eva-mind/internal/sms"
+	"eva-mind/internal/voice"
+	"eva-mind/internal/whatsapp"
+)
+
+// New monta o dispatcher com todos os canais configurados no ambiente
+func New(cfg *config.Config, db *sql.DB, pushService *push.FirebaseService) *Dispatcher {
+	d := NewDispatcher(alerts.NewService(cfg, db), NewWebhookNotifier()).
+		WithPreferences(preferences.NewService(db))
+
+	webPush, err := NewWebPush(cfg, db)
+	if err != nil {
+		log.Printf("⚠️ Web Push indisponível: %v", err)
+	}
+	if pushService != nil || webPush != nil {
+		d.Register(NewPushNotifier(pushService, webPush))
+	}
+
+	if cfg.EnableSMSFallback {
+		if smsService, err := sms.NewService(cfg, db); err == nil {
+			d.Register(NewSMSNotifier(smsService))
+		} else {
+			log.Printf("⚠️ SMS indisponível: %v", err)
+		}
+	}
+
+	if cfg.EnableWhatsApp {
+		if whatsappService, err := whatsapp.NewService(cfg, db); err == nil {
+			d.Register(NewWhatsAppNotifier(whatsappService))
+		} else {
+			log.Printf("⚠️ WhatsApp indisponível: %v", err)
+		}
+	}
+
+	if cfg.EnableCallFallback {
+		if voiceService, err := voice.NewService(cfg, db); err == nil {
+			d.Register(NewCallNotifier(voiceService))
+		} else {
+			log.Printf("⚠️ Ligação indisponível: %v", err)
+		}
+	}
+
+	if cfg.EnableEmailFallback {
+		// Sem transporte configurado (credenciais SMTP ou da API) o canal não entra no fallback
+		if emailService, err := email.NewEmailService(cfg); err == nil {
+			d.Register(NewEmailNotifier(emailService.EnableLocalization(db).EnableQueue(db)))
+		}
+	}
+
+	return d
+}
+
+// NewWebPush cria o Web Push do painel web a partir das chaves VAPID
+// (nil, nil quando não configurado)
+func NewWebPush(cfg *config.Config, db *sql.DB) (*push.WebPushService, error) {
+	if cfg.VAPIDPrivateKey == "" {
+		return nil, nil
+	}
+
+	subject := cfg.VAPIDSubject
+	if subject == "" && cfg.SMTPFromEmail != "" {
+		subject = "mailto:" + cfg.SMTPFromEmail
+	}
+
+	webPush, err := push.NewWebPushService(cfg.VAPIDPrivateKey, subject)
+	if err != nil {
+		return nil, err
+	}
+	return webPush.EnableTokenCleanup(db), nil
+}
@@ -0,0 +1,174 @@
+package preferences
+
+import (
+	"fmt"
+	"time"
+
+	"eva-mind/internal/alerts"
+)
+
+// Tipos de evento que o cuidador pode escolher receber
+const (
+	EventoAlerta         = "alerta"
+	EventoChamadaPerdida = "chamada_perdida"
+	EventoMedicamento    = "medicamento"
+	EventoRelatorio      = "relatorio"
+	EventoResumoSemanal  = "resumo_semanal"
+)
+
+// Modos de entrega
+const (
+	ModoImediato = "imediato"
+	ModoResumo   = "resumo"
+)
+
+// Decision o que fazer com uma notificação para o cuidador
+type Decision int
+
+const (
+	Enviar    Decision = iota // envia agora
+	Adiar                     // guarda para o resumo (silêncio ou modo resumo)
+	Descartar                 // o cuidador não quer este evento/severidade
+)
+
+var validEvents = map[string]bool{
+	EventoAlerta: true, EventoChamadaPerdida: true, EventoMedicamento: true, EventoRelatorio: true, EventoResumoSemanal: true,
+}
+
+var validSeverities = map[string]bool{"aviso": true, "baixa": true, "media": true, "alta": true, "critica": true}
+
+// Preferences preferências de notificação de um cuidador
+type Preferences struct {
+	CuidadorID       int64      `json:"cuidador_id"`
+	Eventos          []string   `json:"eventos"`
+	SeveridadeMinima string     `json:"severidade_minima"`
+	SilencioInicio   string     `json:"silencio_inicio,omitempty"` // HH:MM
+	SilencioFim      string     `json:"silencio_fim,omitempty"`    // HH:MM
+	FusoHorario      string     `json:"fuso_horario"`
+	ModoEntrega      string     `json:"modo_entrega"`
+	IntervaloResumo  int        `json:"intervalo_resumo_horas"`
+	UltimoResumoEm   *time.Time `json:"ultimo_resumo_em,omitempty"`
+}
+
+// Default preferências de quem nunca configurou: tudo, na hora, a qualquer hora
+func Default(cuidadorID int64) *Preferences {
+	return &Preferences{
+		CuidadorID:       cuidadorID,
+		Eventos:          []string{EventoAlerta, EventoChamadaPerdida, EventoMedicamento, EventoRelatorio, EventoResumoSemanal},
+		SeveridadeMinima: "aviso",
+		FusoHorario:      "America/Sao_Paulo",
+		ModoEntrega:      ModoImediato,
+		IntervaloResumo:  4,
+	}
+}
+
+// Validate verifica eventos, severidade, horários, fuso e modo
+func (p *Preferences) Validate() error {
+	for _, e := range p.Eventos {
+		if !validEvents[e] {
+			return fmt.Errorf("evento inválido: %s", e)
+		}
+	}
+	if !validSeverities[p.SeveridadeMinima] {
+		return fmt.Errorf("severidade inválida: %s", p.SeveridadeMinima)
+	}
+	if (p.SilencioInicio == "") != (p.SilencioFim == "") {
+		return fmt.Errorf("informe início e fim do horário de silêncio")
+	}
+	if p.SilencioInicio != "" {
+		if _, err := time.Parse("15:04", p.SilencioInicio); err != nil {
+			return fmt.Errorf("silencio_inicio inválido (use HH:MM)")
+		}
+		if _, err := time.Parse("15:04", p.SilencioFim); err != nil {
+			return fmt.Errorf("silencio_fim inválido (use HH:MM)")
+		}
+	}
+	if _, err := time.LoadLocation(p.FusoHorario); err != nil {
+		return fmt.Errorf("fuso horário inválido: %s", p.FusoHorario)
+	}
+	if p.ModoEntrega != ModoImediato && p.ModoEntrega != ModoResumo {
+		return fmt.Errorf("modo de entrega inválido: %s", p.ModoEntrega)
+	}
+	if p.IntervaloResumo <= 0 {
+		return fmt.Errorf("intervalo do resumo deve ser positivo")
+	}
+	return nil
+}
+
+// Wants informa se o cuidador escolheu receber o tipo de evento
+func (p *Preferences) Wants(evento string) bool {
+	for _, e := range p.Eventos {
+		if e == evento {
+			return true
+		}
+	}
+	return false
+}
+
+// Quiet informa se now cai no horário de silêncio (no fuso do cuidador)
+func (p *Preferences) Quiet(now time.Time) bool {
+	if p.SilencioInicio == "" {
+		return false
+	}
+
+	loc, err := time.LoadLocation(p.FusoHorario)
+	if err != nil {
+		loc = time.Local
+	}
+
+	start, err1 := time.Parse("15:04", p.SilencioInicio)
+	end, err2 := time.Parse("15:04", p.SilencioFim)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	from := start.Hour()*60 + start.Minute()
+	to := end.Hour()*60 + end.Minute()
+
+	if from <= to {
+		return minute >= from && minute < to
+	}
+	// Cruza a meia-noite (ex.: 22:00 → 07:00)
+	return minute >= from || minute < to
+}
+
+// Decide aplica as preferências a uma notificação. Alertas críticos sempre saem na hora.
+func (p *Preferences) Decide(evento, severidade string, now time.Time) Decision {
+	if severidade == "critica" {
+		return Enviar
+	}
+	if !p.Wants(evento) || !alerts.SeverityAtLeast(severidade, p.SeveridadeMinima) {
+		return Descartar
+	}
+	if p.Quiet(now) || p.ModoEntrega == ModoResumo {
+		return Adiar
+	}
+	return Enviar
+}
+
+// DigestDue informa se as notificações adiadas já podem sair: fora do silêncio
+// e, no modo resumo, um intervalo depois do último resumo (ou da mais antiga pendente)
+func (p *Preferences) DigestDue(now, oldest time.Time) bool {
+	if p.Quiet(now) {
+		return false
+	}
+	if p.ModoEntrega != ModoResumo {
+		return true
+	}
+
+	ref := oldest
+	if p.UltimoResumoEm != nil && p.UltimoResumoEm.After(ref) {
+		ref = *p.UltimoResumoEm
+	}
+	return now.Sub(ref) >= time.Duration(p.IntervaloResumo)*time.Hour
+}
+
+// EventOf tipo de evento de preferência correspondente ao alerta
+func EventOf(alert *alerts.Alert) string {
+	if alert.Tipo == "nao_atende_telefone" {
+		return EventoChamadaPerdida
+	}
+	return EventoAlerta
+}
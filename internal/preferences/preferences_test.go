@@ -0,0 +1,89 @@
+package preferences
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuiet(t *testing.T) {
+	sp, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, 3, 10, hour, minute, 0, 0, sp)
+	}
+
+	tests := []struct {
+		name      string
+		inicio    string
+		fim       string
+		fuso      string
+		now       time.Time
+		wantQuiet bool
+	}{
+		{"sem silêncio", "", "", "America/Sao_Paulo", at(3, 0), false},
+		{"dentro no mesmo dia", "13:00", "15:00", "America/Sao_Paulo", at(14, 0), true},
+		{"início incluído", "13:00", "15:00", "America/Sao_Paulo", at(13, 0), true},
+		{"fim excluído", "13:00", "15:00", "America/Sao_Paulo", at(15, 0), false},
+		{"cruza a meia-noite, antes", "22:00", "07:00", "America/Sao_Paulo", at(23, 30), true},
+		{"cruza a meia-noite, depois", "22:00", "07:00", "America/Sao_Paulo", at(6, 59), true},
+		{"cruza a meia-noite, fora", "22:00", "07:00", "America/Sao_Paulo", at(7, 0), false},
+		{"cruza a meia-noite, tarde", "22:00", "07:00", "America/Sao_Paulo", at(21, 59), false},
+		{"fuso do cuidador", "22:00", "07:00", "Europe/Lisbon", at(20, 0), true},
+		{"horário inválido", "25:00", "07:00", "America/Sao_Paulo", at(23, 0), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Default(1)
+			p.SilencioInicio, p.SilencioFim, p.FusoHorario = tt.inicio, tt.fim, tt.fuso
+			if got := p.Quiet(tt.now); got != tt.wantQuiet {
+				t.Errorf("Quiet(%s) = %v, want %v", tt.now.Format(time.RFC3339), got, tt.wantQuiet)
+			}
+		})
+	}
+}
+
+func TestDecide(t *testing.T) {
+	sp, err := time.LoadLocation("America/Sao_Paulo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	night := time.Date(2026, 3, 10, 23, 0, 0, 0, sp)
+	day := time.Date(2026, 3, 10, 10, 0, 0, 0, sp)
+
+	quiet := func(p *Preferences) { p.SilencioInicio, p.SilencioFim = "22:00", "07:00" }
+
+	tests := []struct {
+		name       string
+		setup      func(p *Preferences)
+		evento     string
+		severidade string
+		now        time.Time
+		want       Decision
+	}{
+		{"padrão envia", nil, EventoAlerta, "media", day, Enviar},
+		{"silêncio adia", quiet, EventoAlerta, "alta", night, Adiar},
+		{"fora do silêncio envia", quiet, EventoAlerta, "alta", day, Enviar},
+		{"crítico ignora o silêncio", quiet, EventoAlerta, "critica", night, Enviar},
+		{"modo resumo adia", func(p *Preferences) { p.ModoEntrega = ModoResumo }, EventoMedicamento, "aviso", day, Adiar},
+		{"crítico ignora o modo resumo", func(p *Preferences) { p.ModoEntrega = ModoResumo }, EventoAlerta, "critica", day, Enviar},
+		{"evento fora das preferências", func(p *Preferences) { p.Eventos = []string{EventoAlerta} }, EventoMedicamento, "aviso", day, Descartar},
+		{"crítico ignora os eventos", func(p *Preferences) { p.Eventos = nil }, EventoAlerta, "critica", night, Enviar},
+		{"abaixo da severidade mínima", func(p *Preferences) { p.SeveridadeMinima = "alta" }, EventoAlerta, "media", day, Descartar},
+		{"descartar vem antes de adiar", func(p *Preferences) { quiet(p); p.SeveridadeMinima = "alta" }, EventoAlerta, "baixa", night, Descartar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Default(1)
+			if tt.setup != nil {
+				tt.setup(p)
+			}
+			if got := p.Decide(tt.evento, tt.severidade, tt.now); got != tt.want {
+				t.Errorf("Decide(%s, %s) = %v, want %v", tt.evento, tt.severidade, got, tt.want)
+			}
+		})
+	}
+}
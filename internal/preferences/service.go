@@ -0,0 +1,186 @@
+package preferences
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Service lê e grava preferências e a fila de notificações adiadas
+type Service struct {
+	db *sql.DB
+}
+
+// NewService cria o serviço de preferências
+func NewService(db *sql.DB) *Service {
+	return &Service{db: db}
+}
+
+// Deferred notificação guardada para o próximo resumo
+type Deferred struct {
+	ID         int64     `json:"id"`
+	CuidadorID int64     `json:"cuidador_id"`
+	AlertaID   *int64    `json:"alerta_id,omitempty"`
+	Evento     string    `json:"evento"`
+	Severidade string    `json:"severidade"`
+	Mensagem   string    `json:"mensagem"`
+	CriadoEm   time.Time `json:"criado_em"`
+}
+
+// Get retorna as preferências do cuidador (padrão se nunca configurou)
+func (s *Service) Get(ctx context.Context, cuidadorID int64) (*Preferences, error) {
+	p := Default(cuidadorID)
+	var inicio, fim sql.NullString
+	var ultimo sql.NullTime
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT eventos, severidade_minima, to_char(silencio_inicio, 'HH24:MI'), to_char(silencio_fim, 'HH24:MI'),
+		       fuso_horario, modo_entrega, intervalo_resumo_horas, ultimo_resumo_em
+		FROM preferencias_notificacao
+		WHERE cuidador_id = $1
+	`, cuidadorID).Scan(pq.Array(&p.Eventos), &p.SeveridadeMinima, &inicio, &fim,
+		&p.FusoHorario, &p.ModoEntrega, &p.IntervaloResumo, &ultimo)
+	if err == sql.ErrNoRows {
+		return p, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load preferences of caregiver %d: %w", cuidadorID, err)
+	}
+
+	p.SilencioInicio = inicio.String
+	p.SilencioFim = fim.String
+	if ultimo.Valid {
+		p.UltimoResumoEm = &ultimo.Time
+	}
+
+	return p, nil
+}
+
+// Save grava as preferências do cuidador
+func (s *Service) Save(ctx context.Context, p *Preferences) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO preferencias_notificacao
+			(cuidador_id, eventos, severidade_minima, silencio_inicio, silencio_fim,
+			 fuso_horario, modo_entrega, intervalo_resumo_horas)
+		VALUES ($1, $2, $3, NULLIF($4, '')::time, NULLIF($5, '')::time, $6, $7, $8)
+		ON CONFLICT (cuidador_id) DO UPDATE SET
+			eventos = EXCLUDED.eventos,
+			severidade_minima = EXCLUDED.severidade_minima,
+			silencio_inicio = EXCLUDED.silencio_inicio,
+			silencio_fim = EXCLUDED.silencio_fim,
+			fuso_horario = EXCLUDED.fuso_horario,
+			modo_entrega = EXCLUDED.modo_entrega,
+			intervalo_resumo_horas = EXCLUDED.intervalo_resumo_horas,
+			atualizado_em = NOW()
+	`, p.CuidadorID, pq.Array(p.Eventos), p.SeveridadeMinima, p.SilencioInicio, p.SilencioFim,
+		p.FusoHorario, p.ModoEntrega, p.IntervaloResumo)
+	if err != nil {
+		return fmt.Errorf("failed to save preferences of caregiver %d: %w", p.CuidadorID, err)
+	}
+	return nil
+}
+
+// Defer guarda a notificação para o próximo resumo do cuidador. Um alerta
+// entra uma única vez no resumo pendente: se já estiver lá, retorna false.
+func (s *Service) Defer(ctx context.Context, d Deferred) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `
+		INSERT INTO notificacoes_adiadas (cuidador_id, alerta_id, evento, severidade, mensagem)
+		SELECT $1, $2, $3, $4, $5
+		WHERE $2::INTEGER IS NULL OR NOT EXISTS (
+			SELECT 1 FROM notificacoes_adiadas
+			WHERE cuidador_id = $1 AND alerta_id = $2 AND enviado_em IS NULL
+		)
+	`, d.CuidadorID, d.AlertaID, d.Evento, d.Severidade, d.Mensagem)
+	if err != nil {
+		return false, fmt.Errorf("failed to defer notification: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to defer notification: %w", err)
+	}
+	return n > 0, nil
+}
+
+// CaregiversWithPending cuidadores com notificações adiadas ainda não enviadas
+func (s *Service) CaregiversWithPending(ctx context.Context) ([]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT cuidador_id FROM notificacoes_adiadas WHERE enviado_em IS NULL
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deferred notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Pending notificações adiadas do cuidador, da mais antiga para a mais nova
+func (s *Service) Pending(ctx context.Context, cuidadorID int64) ([]Deferred, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, cuidador_id, alerta_id, evento, severidade, mensagem, criado_em
+		FROM notificacoes_adiadas
+		WHERE cuidador_id = $1 AND enviado_em IS NULL
+		ORDER BY criado_em
+	`, cuidadorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deferred notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Deferred
+	for rows.Next() {
+		var d Deferred
+		var alertaID sql.NullInt64
+		if err := rows.Scan(&d.ID, &d.CuidadorID, &alertaID, &d.Evento, &d.Severidade, &d.Mensagem, &d.CriadoEm); err != nil {
+			return nil, err
+		}
+		if alertaID.Valid {
+			d.AlertaID = &alertaID.Int64
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// MarkSent marca as notificações como entregues no resumo
+func (s *Service) MarkSent(ctx context.Context, cuidadorID int64, items []Deferred) error {
+	ids := make([]int64, len(items))
+	for i, d := range items {
+		ids[i] = d.ID
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE notificacoes_adiadas SET enviado_em = NOW() WHERE id = ANY($1)
+	`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to mark deferred notifications: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE preferencias_notificacao SET ultimo_resumo_em = NOW() WHERE cuidador_id = $1
+	`, cuidadorID); err != nil {
+		return fmt.Errorf("failed to update digest time: %w", err)
+	}
+
+	return tx.Commit()
+}
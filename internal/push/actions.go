@@ -0,0 +1,102 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Ações dos pushes de alerta. São os identificadores das ações nas categorias
+// APNs (EMERGENCY_ALERT, MISSED_CALL), nos botões Android e nas actions do Web Push.
+const (
+	ActionCall     = "ligar"
+	ActionSeen     = "visto"
+	ActionOnTheWay = "a_caminho"
+)
+
+// AlertRef alerta que originou o push: ids reais para o deep link e as ações
+type AlertRef struct {
+	AlertID    int64
+	ElderID    int64
+	ElderName  string
+	ElderPhone string
+}
+
+// Action botão da notificação. "ligar" abre o discador (tel:); as respostas
+// fazem POST em URL com a credencial do app, sem abrir o app.
+type Action struct {
+	ID     string `json:"id"`
+	Title  string `json:"title"`
+	URL    string `json:"url"`
+	Method string `json:"method,omitempty"`
+	Body   string `json:"body,omitempty"`
+}
+
+// DeepLink abre a tela do alerta no app do cuidador
+func (r AlertRef) DeepLink() string {
+	return fmt.Sprintf("evamind://alertas/%d", r.AlertID)
+}
+
+// ResponseURL endpoint das respostas rápidas (relativo ao servidor da API)
+func (r AlertRef) ResponseURL() string {
+	return fmt.Sprintf("/api/alertas/%d/resposta", r.AlertID)
+}
+
+// Actions botões do alerta. Sem alerta gravado não há o que confirmar, e
+// sem telefone não há para quem ligar.
+func (r AlertRef) Actions() []Action {
+	var actions []Action
+	if r.ElderPhone != "" {
+		actions = append(actions, Action{ID: ActionCall, Title: "Ligar para o idoso", URL: "tel:" + r.ElderPhone})
+	}
+	if r.AlertID != 0 {
+		actions = append(actions,
+			Action{ID: ActionSeen, Title: "Vi o alerta", URL: r.ResponseURL(), Method: "POST", Body: `{"acao":"visto"}`},
+			Action{ID: ActionOnTheWay, Title: "Estou a caminho", URL: r.ResponseURL(), Method: "POST", Body: `{"acao":"a_caminho"}`},
+		)
+	}
+	return actions
+}
+
+// args argumentos para recriar o push na fila (ver rebuild)
+func (r AlertRef) args() map[string]string {
+	return map[string]string{
+		"alert_id":    strconv.FormatInt(r.AlertID, 10),
+		"elder_id":    strconv.FormatInt(r.ElderID, 10),
+		"elder_name":  r.ElderName,
+		"elder_phone": r.ElderPhone,
+	}
+}
+
+// alertRefFromArgs lê o AlertRef gravado na fila
+func alertRefFromArgs(args map[string]string) AlertRef {
+	alertID, _ := strconv.ParseInt(args["alert_id"], 10, 64)
+	elderID, _ := strconv.ParseInt(args["elder_id"], 10, 64)
+	return AlertRef{AlertID: alertID, ElderID: elderID, ElderName: args["elder_name"], ElderPhone: args["elder_phone"]}
+}
+
+// withAlert adiciona ids, deep link e ações aos dados do push
+func (c content) withAlert(r AlertRef) content {
+	c.actions = r.Actions()
+	c.data["elder_name"] = r.ElderName
+	if r.ElderID != 0 {
+		c.data["elder_id"] = strconv.FormatInt(r.ElderID, 10)
+	}
+	if r.ElderPhone != "" {
+		c.data["elder_phone"] = r.ElderPhone
+	}
+	if r.AlertID != 0 {
+		c.data["alert_id"] = strconv.FormatInt(r.AlertID, 10)
+		c.data["deep_link"] = r.DeepLink()
+		c.data["response_url"] = r.ResponseURL()
+	}
+	if len(c.actions) > 0 {
+		b, _ := json.Marshal(c.actions)
+		c.data["actions"] = string(b)
+	}
+
+	for k, v := range r.args() {
+		c.args[k] = v
+	}
+	return c
+}
@@ -0,0 +1,169 @@
+package push
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"firebase.google.com/go/v4/messaging"
+)
+
+// Níveis de interrupção do iOS (interruption-level do aps)
+const (
+	InterruptionPassive       = "passive"
+	InterruptionActive        = "active"
+	InterruptionTimeSensitive = "time-sensitive"
+	InterruptionCritical      = "critical"
+)
+
+// Urgência do Web Push (RFC 8030)
+const (
+	UrgencyLow    = "low"
+	UrgencyNormal = "normal"
+	UrgencyHigh   = "high"
+)
+
+// content notificação com a configuração de cada plataforma. O FCM aplica a
+// config Android ou APNs conforme o token; o Web Push usa webPayload.
+type content struct {
+	notification *messaging.Notification
+	data         map[string]string
+	android      *messaging.AndroidConfig
+	apns         *messaging.APNSConfig
+	urgency      string
+	actions      []Action
+
+	// ttl validade do push (a fila descarta depois disso); args recriam o
+	// conteúdo no reenvio (ver rebuild)
+	ttl  time.Duration
+	args map[string]string
+}
+
+// rebuild recria o conteúdo de um push da fila a partir do tipo e dos argumentos
+func rebuild(tipo string, args map[string]string) (content, error) {
+	switch tipo {
+	case "incoming_call":
+		return callContent(args["session_id"], args["elder_name"]), nil
+	case "emergency_alert":
+		return alertContent(alertRefFromArgs(args), args["reason"]), nil
+	case "missed_call_alert":
+		return missedCallContent(alertRefFromArgs(args)), nil
+	case "medication_confirmed":
+		return medicationContent(args["elder_name"], args["medication"]), nil
+	case "notification_digest":
+		count, _ := strconv.Atoi(args["count"])
+		return digestContent(args["elder_name"], count, args["body"]), nil
+	}
+	return content{}, fmt.Errorf("unknown push type %q", tipo)
+}
+
+// expiring ajusta a validade no FCM/APNs ao tempo que ainda resta até expiresAt
+func (c content) expiring(expiresAt time.Time) content {
+	remaining := time.Until(expiresAt)
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	if c.android != nil {
+		android := *c.android
+		android.TTL = &remaining
+		c.android = &android
+	}
+
+	if c.apns != nil {
+		apns := *c.apns
+		apns.Headers = map[string]string{"apns-expiration": strconv.FormatInt(expiresAt.Unix(), 10)}
+		for k, v := range c.apns.Headers {
+			apns.Headers[k] = v
+		}
+		c.apns = &apns
+	}
+	return c
+}
+
+// message mensagem FCM para um token
+func (c content) message(token string) *messaging.Message {
+	return &messaging.Message{
+		Token:        token,
+		Notification: c.notification,
+		Data:         c.data,
+		Android:      c.android,
+		APNS:         c.apns,
+	}
+}
+
+// multicast mensagem FCM para vários tokens
+func (c content) multicast(tokens []string) *messaging.MulticastMessage {
+	return &messaging.MulticastMessage{
+		Tokens:       tokens,
+		Notification: c.notification,
+		Data:         c.data,
+		Android:      c.android,
+		APNS:         c.apns,
+	}
+}
+
+// webPayload JSON entregue ao service worker do painel web
+func (c content) webPayload() ([]byte, error) {
+	payload := map[string]interface{}{
+		"data":               c.data,
+		"requireInteraction": c.urgency == UrgencyHigh,
+	}
+	if c.notification != nil {
+		payload["title"] = c.notification.Title
+		payload["body"] = c.notification.Body
+	}
+	if t := c.data["type"]; t != "" {
+		payload["tag"] = t
+	}
+	if id := c.data["alert_id"]; id != "" {
+		payload["tag"] = c.data["type"] + "-" + id
+	}
+
+	// O service worker trata notificationclick pelo id da ação (ver Action)
+	if len(c.actions) > 0 {
+		actions := make([]map[string]string, 0, len(c.actions))
+		for _, a := range c.actions {
+			actions = append(actions, map[string]string{"action": a.ID, "title": a.Title})
+		}
+		payload["actions"] = actions
+	}
+	return json.Marshal(payload)
+}
+
+// webUrgency urgência do Web Push (normal se não definida)
+func (c content) webUrgency() string {
+	if c.urgency == "" {
+		return UrgencyNormal
+	}
+	return c.urgency
+}
+
+// apnsConfig config APNs com nível de interrupção, som e categoria (ações no
+// app). Alertas critical tocam mesmo no modo silencioso e exigem o entitlement
+// de critical alerts no app iOS; sem ele o iOS entrega como time-sensitive.
+func apnsConfig(level, sound, category string) *messaging.APNSConfig {
+	aps := &messaging.Aps{
+		Category:   category,
+		CustomData: map[string]interface{}{"interruption-level": level},
+	}
+	if level == InterruptionCritical {
+		aps.CriticalSound = &messaging.CriticalSound{Critical: true, Name: sound, Volume: 1.0}
+	} else {
+		aps.Sound = sound
+	}
+
+	priority := "10"
+	if level == InterruptionPassive {
+		priority = "5"
+	}
+
+	return &messaging.APNSConfig{
+		Headers: map[string]string{
+			"apns-priority":  priority,
+			"apns-push-type": "alert",
+		},
+		Payload: &messaging.APNSPayload{Aps: aps},
+	}
+}
@@ -2,11 +2,14 @@ package push
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 
 	firebase "firebase.google.com/go/v4"
+	"firebase.google.com/go/v4/errorutils"
 	"firebase.google.com/go/v4/messaging"
 	"google.golang.org/api/option"
 )
@@ -14,6 +17,8 @@ import (
 type FirebaseService struct {
 	client *messaging.Client
 	ctx    context.Context
+	tokens *TokenStore
+	outbox *Outbox
 }
 
 type AlertResult struct {
@@ -47,28 +52,80 @@ func NewFirebaseService(credentialsPath string) (*FirebaseService, error) {
 	}, nil
 }
 
-// SendCallNotification dispara o sinal para o App "Ligar" e abrir o WebRTC
-func (s *FirebaseService) SendCallNotification(deviceToken, sessionID, elderName string) error {
+// EnableTokenCleanup marca automaticamente como inválidos os tokens que o FCM
+// rejeitar (não registrado / sender diferente) e grava o resumo dos multicasts
+func (s *FirebaseService) EnableTokenCleanup(db *sql.DB) *FirebaseService {
+	s.tokens = NewTokenStore(db)
+	return s
+}
+
+// EnableOutbox guarda na fila push_outbox os envios que falharem por erro
+// transitório do FCM; o PushOutboxWorker reenvia até o TTL de cada tipo
+func (s *FirebaseService) EnableOutbox(db *sql.DB) *FirebaseService {
+	s.outbox = NewOutbox(db)
+	return s
+}
+
+// send envia a mensagem para um token. Token rejeitado é invalidado; erro
+// transitório vai para a fila (o erro retornado contém ErrQueued).
+func (s *FirebaseService) send(c content, token string, ref outboxRef) (string, error) {
+	response, err := s.client.Send(s.ctx, c.message(token))
+	switch {
+	case err == nil:
+	case IsInvalidTokenError(err):
+		s.invalidate([]string{token})
+	case IsTransientError(err) && s.outbox != nil:
+		if qerr := s.outbox.enqueue(s.ctx, c, token, ref, err); qerr != nil {
+			log.Printf("⚠️ %v", qerr)
+			break
+		}
+		return "", fmt.Errorf("%w: %w", ErrQueued, err)
+	}
+	return response, err
+}
+
+func (s *FirebaseService) invalidate(tokens []string) {
+	if s.tokens == nil || len(tokens) == 0 {
+		return
+	}
+	if err := s.tokens.Invalidate(s.ctx, tokens); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+}
+
+// SendCallNotification dispara o sinal para o App "Ligar" e abrir o WebRTC.
+// agendamentoID (0 = avulsa) permite à fila atualizar o agendamento após um reenvio.
+func (s *FirebaseService) SendCallNotification(agendamentoID int64, deviceToken, sessionID, elderName string) error {
 	if deviceToken == "" {
 		return fmt.Errorf("device token is empty")
 	}
 
+	response, err := s.send(callContent(sessionID, elderName), deviceToken, outboxRef{agendamentoID: agendamentoID})
+	if err != nil {
+		return fmt.Errorf("error sending call push: %w", err)
+	}
+
+	log.Printf("🚀 Ligação iniciada para %s (Session: %s): %s", elderName, sessionID, response)
+	return nil
+}
+
+// callContent chamada da EVA: só vale enquanto o telefone toca
+func callContent(sessionID, elderName string) content {
 	ttl := time.Duration(0)
 
-	message := &messaging.Message{
-		Token: deviceToken,
-		Notification: &messaging.Notification{
+	return content{
+		notification: &messaging.Notification{
 			Title: "🤖 EVA está chamando",
 			Body:  fmt.Sprintf("Olá %s, vamos conversar?", elderName),
 		},
-		Data: map[string]string{
+		data: map[string]string{
 			"type":      "incoming_call",
 			"sessionId": sessionID,
 			"action":    "START_VOICE_CALL",
 			"priority":  "high",
 			"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
 		},
-		Android: &messaging.AndroidConfig{
+		android: &messaging.AndroidConfig{
 			Priority: "high",
 			TTL:      &ttl,
 			Notification: &messaging.AndroidNotification{
@@ -79,19 +136,15 @@ func (s *FirebaseService) SendCallNotification(deviceToken, sessionID, elderName
 				ClickAction:  "OPEN_CALL_ACTIVITY",
 			},
 		},
+		apns: apnsConfig(InterruptionTimeSensitive, "default", "INCOMING_CALL"),
+		ttl:  45 * time.Second,
+		args: map[string]string{"session_id": sessionID, "elder_name": elderName},
 	}
-
-	response, err := s.client.Send(s.ctx, message)
-	if err != nil {
-		return fmt.Errorf("error sending call push: %w", err)
-	}
-
-	log.Printf("🚀 Ligação iniciada para %s (Session: %s): %s", elderName, sessionID, response)
-	return nil
 }
 
-// SendAlertNotification envia alerta crítico para o cuidador
-func (s *FirebaseService) SendAlertNotification(deviceToken, elderName, reason string) (*AlertResult, error) {
+// SendAlertNotification envia alerta crítico para o cuidador, com deep link e
+// ações do alerta ref (AlertID 0 = sem alerta gravado, sem ações de resposta)
+func (s *FirebaseService) SendAlertNotification(ref AlertRef, deviceToken, reason string) (*AlertResult, error) {
 	if deviceToken == "" {
 		return &AlertResult{
 			Success:      false,
@@ -101,32 +154,7 @@ func (s *FirebaseService) SendAlertNotification(deviceToken, elderName, reason s
 		}, fmt.Errorf("device token is empty")
 	}
 
-	message := &messaging.Message{
-		Token: deviceToken,
-		Notification: &messaging.Notification{
-			Title: "⚠️ ALERTA CRÍTICO: EVA",
-			Body:  fmt.Sprintf("%s precisa de ajuda: %s", elderName, reason),
-		},
-		Data: map[string]string{
-			"type":      "emergency_alert",
-			"reason":    reason,
-			"priority":  "high",
-			"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
-			"alert_id":  fmt.Sprintf("alert-%d", time.Now().UnixNano()),
-		},
-		Android: &messaging.AndroidConfig{
-			Priority: "high",
-			Notification: &messaging.AndroidNotification{
-				Sound:        "alert",
-				Priority:     messaging.PriorityHigh,
-				ChannelID:    "eva_alerts",
-				DefaultSound: true,
-				Color:        "#FF0000",
-			},
-		},
-	}
-
-	response, err := s.client.Send(s.ctx, message)
+	response, err := s.send(alertContent(ref, reason), deviceToken, outboxRef{alertaID: ref.AlertID})
 
 	result := &AlertResult{
 		Success:      err == nil,
@@ -145,18 +173,51 @@ func (s *FirebaseService) SendAlertNotification(deviceToken, elderName, reason s
 	return result, nil
 }
 
-// SendAlertNotificationMultiple envia alertas para múltiplos tokens
-func (s *FirebaseService) SendAlertNotificationMultiple(tokens []string, elderName, reason string) []*AlertResult {
-	results := make([]*AlertResult, 0, len(tokens))
+// alertContent alerta de emergência: toca mesmo no silencioso (critical no iOS)
+func alertContent(ref AlertRef, reason string) content {
+	return content{
+		notification: &messaging.Notification{
+			Title: "⚠️ ALERTA CRÍTICO: EVA",
+			Body:  fmt.Sprintf("%s precisa de ajuda: %s", ref.ElderName, reason),
+		},
+		data: map[string]string{
+			"type":      "emergency_alert",
+			"reason":    reason,
+			"priority":  "high",
+			"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
+		},
+		android: &messaging.AndroidConfig{
+			Priority: "high",
+			Notification: &messaging.AndroidNotification{
+				Sound:        "alert",
+				Priority:     messaging.PriorityHigh,
+				ChannelID:    "eva_alerts",
+				DefaultSound: true,
+				Color:        "#FF0000",
+				ClickAction:  "OPEN_ALERT",
+			},
+		},
+		apns:    apnsConfig(InterruptionCritical, "alert.caf", "EMERGENCY_ALERT"),
+		urgency: UrgencyHigh,
+		ttl:     time.Hour,
+		args:    map[string]string{"reason": reason},
+	}.withAlert(ref)
+}
 
-	for _, token := range tokens {
-		result, err := s.SendAlertNotification(token, elderName, reason)
-		if err != nil {
-			log.Printf("❌ Falha ao enviar para token: %v", err)
-		}
-		results = append(results, result)
+// SendAlertNotificationMultiple envia o alerta para vários tokens em multicast
+func (s *FirebaseService) SendAlertNotificationMultiple(tokens []string, elderName, reason string) []*AlertResult {
+	multi := s.SendAlertMulticast(AlertRef{ElderName: elderName}, tokens, reason)
+
+	results := make([]*AlertResult, 0, len(multi.Results))
+	for _, r := range multi.Results {
+		results = append(results, &AlertResult{
+			Success:      r.Error == nil,
+			MessageID:    r.MessageID,
+			Error:        r.Error,
+			SentAt:       multi.SentAt,
+			DeliveryType: "push",
+		})
 	}
-
 	return results
 }
 
@@ -166,18 +227,28 @@ func (s *FirebaseService) SendMedicationConfirmation(deviceToken, elderName, med
 		return fmt.Errorf("device token is empty")
 	}
 
-	message := &messaging.Message{
-		Token: deviceToken,
-		Notification: &messaging.Notification{
+	response, err := s.send(medicationContent(elderName, medicationName), deviceToken, outboxRef{})
+	if err != nil {
+		return fmt.Errorf("error sending medication push: %w", err)
+	}
+
+	log.Printf("✅ Confirmação de medicação enviada: %s", response)
+	return nil
+}
+
+// medicationContent confirmação de medicamento tomado
+func medicationContent(elderName, medicationName string) content {
+	return content{
+		notification: &messaging.Notification{
 			Title: "✅ Medicamento Confirmado",
 			Body:  fmt.Sprintf("%s tomou o remédio: %s", elderName, medicationName),
 		},
-		Data: map[string]string{
+		data: map[string]string{
 			"type":       "medication_confirmed",
 			"medication": medicationName,
 			"timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
 		},
-		Android: &messaging.AndroidConfig{
+		android: &messaging.AndroidConfig{
 			Priority: "normal",
 			Notification: &messaging.AndroidNotification{
 				Sound:        "default",
@@ -186,36 +257,82 @@ func (s *FirebaseService) SendMedicationConfirmation(deviceToken, elderName, med
 				Color:        "#00FF00",
 			},
 		},
+		apns: apnsConfig(InterruptionActive, "default", ""),
+		ttl:  6 * time.Hour,
+		args: map[string]string{"elder_name": elderName, "medication": medicationName},
+	}
+}
+
+// SendNotificationDigest entrega as notificações adiadas (horário de silêncio ou modo resumo)
+func (s *FirebaseService) SendNotificationDigest(deviceToken, elderName string, count int, body string) error {
+	if deviceToken == "" {
+		return fmt.Errorf("device token is empty")
 	}
 
-	response, err := s.client.Send(s.ctx, message)
+	response, err := s.send(digestContent(elderName, count, body), deviceToken, outboxRef{})
 	if err != nil {
-		return fmt.Errorf("error sending medication push: %w", err)
+		return fmt.Errorf("error sending digest push: %w", err)
 	}
 
-	log.Printf("✅ Confirmação de medicação enviada: %s", response)
+	log.Printf("🗒️ Resumo de notificações enviado: %s", response)
 	return nil
 }
 
+// digestContent resumo das notificações adiadas
+func digestContent(elderName string, count int, body string) content {
+	return content{
+		notification: &messaging.Notification{
+			Title: fmt.Sprintf("🗒️ EVA: %d aviso(s) sobre %s", count, elderName),
+			Body:  body,
+		},
+		data: map[string]string{
+			"type":       "notification_digest",
+			"elder_name": elderName,
+			"count":      fmt.Sprintf("%d", count),
+			"timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
+		},
+		android: &messaging.AndroidConfig{
+			Priority: "normal",
+			Notification: &messaging.AndroidNotification{
+				Sound:        "default",
+				ChannelID:    "eva_digest",
+				DefaultSound: true,
+			},
+		},
+		apns: apnsConfig(InterruptionPassive, "", ""),
+		ttl:  12 * time.Hour,
+		args: map[string]string{"elder_name": elderName, "count": fmt.Sprintf("%d", count), "body": body},
+	}
+}
+
 // SendMissedCallAlert notifica o cuidador quando o idoso não atende uma chamada agendada
-func (s *FirebaseService) SendMissedCallAlert(deviceToken, elderName string) error {
+func (s *FirebaseService) SendMissedCallAlert(ref AlertRef, deviceToken string) error {
 	if deviceToken == "" {
 		return fmt.Errorf("device token is empty")
 	}
 
-	message := &messaging.Message{
-		Token: deviceToken,
-		Notification: &messaging.Notification{
+	response, err := s.send(missedCallContent(ref), deviceToken, outboxRef{alertaID: ref.AlertID})
+	if err != nil {
+		return fmt.Errorf("error sending missed call alert: %w", err)
+	}
+
+	log.Printf("📵 Alerta de chamada perdida enviado: %s", response)
+	return nil
+}
+
+// missedCallContent chamada não atendida (time-sensitive no iOS)
+func missedCallContent(ref AlertRef) content {
+	return content{
+		notification: &messaging.Notification{
 			Title: "⚠️ Chamada Não Atendida",
-			Body:  fmt.Sprintf("%s não atendeu a chamada programada da EVA. Verifique se está tudo bem.", elderName),
+			Body:  fmt.Sprintf("%s não atendeu a chamada programada da EVA. Verifique se está tudo bem.", ref.ElderName),
 		},
-		Data: map[string]string{
-			"type":       "missed_call_alert",
-			"elder_name": elderName,
-			"priority":   "high",
-			"timestamp":  fmt.Sprintf("%d", time.Now().Unix()),
+		data: map[string]string{
+			"type":      "missed_call_alert",
+			"priority":  "high",
+			"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
 		},
-		Android: &messaging.AndroidConfig{
+		android: &messaging.AndroidConfig{
 			Priority: "high",
 			Notification: &messaging.AndroidNotification{
 				Sound:        "alert",
@@ -223,49 +340,66 @@ func (s *FirebaseService) SendMissedCallAlert(deviceToken, elderName string) err
 				ChannelID:    "eva_alerts",
 				DefaultSound: true,
 				Color:        "#FF0000",
+				ClickAction:  "OPEN_ALERT",
 			},
 		},
-	}
-
-	response, err := s.client.Send(s.ctx, message)
-	if err != nil {
-		return fmt.Errorf("error sending missed call alert: %w", err)
-	}
-
-	log.Printf("📵 Alerta de chamada perdida enviado: %s", response)
-	return nil
+		apns:    apnsConfig(InterruptionTimeSensitive, "alert.caf", "MISSED_CALL"),
+		urgency: UrgencyHigh,
+		ttl:     time.Hour,
+		args:    map[string]string{},
+	}.withAlert(ref)
 }
 
 // ValidateToken verifica se um device token é válido
 func (s *FirebaseService) ValidateToken(deviceToken string) bool {
+	invalid, err := s.CheckToken(deviceToken)
+	if err != nil && len(deviceToken) >= 10 {
+		log.Printf("❌ ValidateToken failed for token %s...: %v", deviceToken[:10], err)
+	}
+	return !invalid && err == nil
+}
+
+// CheckToken revalida o token com um envio dry-run (nada chega ao aparelho).
+// invalid indica que o FCM recusou o token; err, qualquer outra falha.
+func (s *FirebaseService) CheckToken(deviceToken string) (invalid bool, err error) {
 	if deviceToken == "" {
-		return false
+		return true, fmt.Errorf("device token is empty")
 	}
 
-	// Tenta enviar uma mensagem de teste silenciosa
 	message := &messaging.Message{
 		Token: deviceToken,
 		Data: map[string]string{
 			"type": "token_validation",
 		},
-		Android: &messaging.AndroidConfig{
-			Priority: "normal",
-		},
 	}
 
-	response, err := s.client.Send(s.ctx, message)
-	if err != nil {
-		log.Printf("❌ ValidateToken failed for token %s...: %v", deviceToken[:10], err)
-		return false
+	if _, err := s.client.SendDryRun(s.ctx, message); err != nil {
+		if IsInvalidTokenError(err) || messaging.IsInvalidArgument(err) {
+			return true, err
+		}
+		return false, fmt.Errorf("error validating token: %w", err)
 	}
-	_ = response // Ignorar response ID
-	return true
+	return false, nil
 }
 
 // GetClient e GetContext para flexibilidade em outros módulos
 func (s *FirebaseService) GetClient() *messaging.Client { return s.client }
 func (s *FirebaseService) GetContext() context.Context  { return s.ctx }
 
+// IsTransientError erros que valem nova tentativa: FCM indisponível, erro
+// interno, cota excedida e falhas de rede (timeout, conexão recusada).
+// Percorre os erros embrulhados, já que o SDK só reconhece o erro original.
+func IsTransientError(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		if errorutils.IsUnavailable(err) || errorutils.IsInternal(err) ||
+			errorutils.IsDeadlineExceeded(err) || errorutils.IsUnknown(err) ||
+			messaging.IsQuotaExceeded(err) || messaging.IsMessageRateExceeded(err) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsInvalidTokenError verifica se o erro retornado pelo Firebase indica que o token é inválido
 func IsInvalidTokenError(err error) bool {
 	if messaging.IsRegistrationTokenNotRegistered(err) || messaging.IsSenderIDMismatch(err) {
@@ -0,0 +1,135 @@
+package push
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// maxMulticastTokens limite de tokens por requisição do FCM
+const maxMulticastTokens = 500
+
+// TokenResult resultado do envio para um token
+type TokenResult struct {
+	Token     string
+	MessageID string
+	Error     error
+}
+
+// Invalid informa se o FCM rejeitou o token (não registrado / sender diferente)
+func (r TokenResult) Invalid() bool {
+	return r.Error != nil && IsInvalidTokenError(r.Error)
+}
+
+// MulticastResult resultado por token, na mesma ordem dos tokens enviados
+type MulticastResult struct {
+	Results []TokenResult
+	Success int
+	Failure int
+	Invalid int
+	Queued  int
+	SentAt  time.Time
+}
+
+// SendAlertMulticast envia o alerta de emergência a todos os tokens de uma vez.
+// ref.AlertID (0 = sem alerta) identifica o envio no resumo e nas ações.
+func (s *FirebaseService) SendAlertMulticast(ref AlertRef, tokens []string, reason string) *MulticastResult {
+	return s.multicast(ref.AlertID, "emergency_alert", tokens, alertContent(ref, reason))
+}
+
+// SendMissedCallMulticast avisa todos os tokens que o idoso não atendeu a chamada
+func (s *FirebaseService) SendMissedCallMulticast(ref AlertRef, tokens []string) *MulticastResult {
+	return s.multicast(ref.AlertID, "missed_call_alert", tokens, missedCallContent(ref))
+}
+
+// SendMedicationMulticast confirma a todos os tokens que o idoso tomou o remédio
+func (s *FirebaseService) SendMedicationMulticast(tokens []string, elderName, medicationName string) *MulticastResult {
+	return s.multicast(0, "medication_confirmed", tokens, medicationContent(elderName, medicationName))
+}
+
+// multicast envia em lotes de até 500 tokens, invalida os tokens rejeitados,
+// põe na fila os que falharam por erro transitório e grava o resumo. Tokens
+// vazios falham sem ir ao FCM.
+func (s *FirebaseService) multicast(alertID int64, tipo string, tokens []string, c content) *MulticastResult {
+	start := time.Now()
+	result := &MulticastResult{Results: make([]TokenResult, len(tokens)), SentAt: start}
+
+	var pending []int
+	for i, token := range tokens {
+		result.Results[i].Token = token
+		if token == "" {
+			result.Results[i].Error = fmt.Errorf("device token is empty")
+			continue
+		}
+		pending = append(pending, i)
+	}
+
+	for len(pending) > 0 {
+		n := len(pending)
+		if n > maxMulticastTokens {
+			n = maxMulticastTokens
+		}
+		chunk := pending[:n]
+		pending = pending[n:]
+
+		chunkTokens := make([]string, len(chunk))
+		for j, i := range chunk {
+			chunkTokens[j] = tokens[i]
+		}
+		msg := c.multicast(chunkTokens)
+
+		batch, err := s.client.SendEachForMulticast(s.ctx, msg)
+		for j, i := range chunk {
+			switch {
+			case err != nil:
+				result.Results[i].Error = fmt.Errorf("error sending multicast push: %w", err)
+			case j < len(batch.Responses) && batch.Responses[j].Success:
+				result.Results[i].MessageID = batch.Responses[j].MessageID
+			case j < len(batch.Responses):
+				result.Results[i].Error = batch.Responses[j].Error
+			default:
+				result.Results[i].Error = fmt.Errorf("missing multicast response")
+			}
+		}
+	}
+
+	var invalid []string
+	for i, r := range result.Results {
+		switch {
+		case r.Error == nil:
+			result.Success++
+		case r.Invalid():
+			result.Failure++
+			result.Invalid++
+			invalid = append(invalid, r.Token)
+		case r.Token != "" && IsTransientError(r.Error) && s.outbox != nil:
+			result.Failure++
+			if err := s.outbox.enqueue(s.ctx, c, r.Token, outboxRef{alertaID: alertID}, r.Error); err != nil {
+				log.Printf("⚠️ %v", err)
+				continue
+			}
+			result.Queued++
+			result.Results[i].Error = fmt.Errorf("%w: %w", ErrQueued, r.Error)
+		default:
+			result.Failure++
+		}
+	}
+	s.invalidate(invalid)
+
+	if s.tokens != nil {
+		if err := s.tokens.Record(s.ctx, SendSummary{
+			AlertaID:  alertID,
+			Tipo:      tipo,
+			Total:     len(tokens),
+			Sucesso:   result.Success,
+			Falha:     result.Failure,
+			Invalidos: result.Invalid,
+			Duracao:   time.Since(start),
+		}); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+
+	log.Printf("📲 Multicast %s: %d/%d entregue(s), %d token(s) inválido(s), %d na fila", tipo, result.Success, len(tokens), result.Invalid, result.Queued)
+	return result
+}
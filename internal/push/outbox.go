@@ -0,0 +1,328 @@
+package push
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Status possíveis de um envio em push_outbox
+const (
+	OutboxPendente        = "pendente"
+	OutboxEnviando        = "enviando"
+	OutboxEnviado         = "enviado"
+	OutboxExpirado        = "expirado"
+	OutboxFalhaDefinitiva = "falha_definitiva"
+)
+
+const (
+	outboxBaseBackoff = 5 * time.Second
+	outboxMaxBackoff  = 5 * time.Minute
+
+	// Envios presos em "enviando" por mais tempo que isso são retomados
+	outboxStaleTimeout = time.Minute
+)
+
+// ErrQueued o envio falhou por erro transitório e ficou na fila para reenvio
+var ErrQueued = errors.New("push queued for retry")
+
+// outboxRef liga o envio ao alerta ou ao agendamento que o originou
+type outboxRef struct {
+	alertaID      int64
+	agendamentoID int64
+}
+
+// OutboxItem envio reservado para reenvio
+type OutboxItem struct {
+	ID            int64
+	Tipo          string
+	Token         string
+	AlertaID      int64
+	AgendamentoID int64
+	Tentativas    int
+	MaxTentativas int
+	ExpiraEm      time.Time
+
+	args map[string]string
+}
+
+// OutboxStats profundidade e resultado recente da fila
+type OutboxStats struct {
+	Pendentes      int            `json:"pendentes"`
+	PorTipo        map[string]int `json:"por_tipo"`
+	MaisAntigaEm   *time.Time     `json:"mais_antiga_em,omitempty"`
+	AtrasoSegundos int            `json:"atraso_segundos"`
+	Enviados24h    int            `json:"enviados_24h"`
+	Expirados24h   int            `json:"expirados_24h"`
+	FalhasDef24h   int            `json:"falhas_definitivas_24h"`
+}
+
+// Outbox fila persistente de push
+type Outbox struct {
+	db *sql.DB
+}
+
+// NewOutbox cria a fila de push
+func NewOutbox(db *sql.DB) *Outbox {
+	return &Outbox{db: db}
+}
+
+// enqueue guarda o envio com o TTL do tipo; a primeira tentativa já falhou
+func (o *Outbox) enqueue(ctx context.Context, c content, token string, ref outboxRef, cause error) error {
+	dados, err := json.Marshal(c.args)
+	if err != nil {
+		return fmt.Errorf("failed to encode push args: %w", err)
+	}
+
+	var alerta, agendamento interface{}
+	if ref.alertaID != 0 {
+		alerta = ref.alertaID
+	}
+	if ref.agendamentoID != 0 {
+		agendamento = ref.agendamentoID
+	}
+
+	var id int64
+	err = o.db.QueryRowContext(ctx, `
+		INSERT INTO push_outbox (tipo, token, dados, alerta_id, agendamento_id, tentativas,
+		                         proxima_tentativa, expira_em, ultimo_erro)
+		VALUES ($1, $2, $3, $4, $5, 1, NOW() + make_interval(secs => $6), NOW() + make_interval(secs => $7), $8)
+		RETURNING id
+	`, c.data["type"], token, string(dados), alerta, agendamento,
+		outboxBackoff(1).Seconds(), c.ttl.Seconds(), cause.Error()).Scan(&id)
+	if err != nil {
+		return fmt.Errorf("failed to enqueue push: %w", err)
+	}
+
+	log.Printf("📥 Push %s na fila para reenvio (#%d, válido por %v): %v", c.data["type"], id, c.ttl, cause)
+	return nil
+}
+
+// Claim expira os envios vencidos e reserva até limit envios prontos
+func (o *Outbox) Claim(ctx context.Context, limit int) ([]OutboxItem, error) {
+	if err := o.expire(ctx); err != nil {
+		return nil, err
+	}
+
+	rows, err := o.db.QueryContext(ctx, `
+		UPDATE push_outbox
+		SET status = 'enviando',
+		    tentativas = tentativas + 1,
+		    iniciado_em = NOW(),
+		    atualizado_em = NOW()
+		WHERE id IN (
+			SELECT id FROM push_outbox
+			WHERE expira_em > NOW()
+			  AND ((status = 'pendente' AND proxima_tentativa <= NOW())
+			    OR (status = 'enviando' AND iniciado_em < NOW() - make_interval(secs => $2)))
+			ORDER BY proxima_tentativa ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, tipo, token, dados::text, COALESCE(alerta_id, 0), COALESCE(agendamento_id, 0),
+		          tentativas, max_tentativas, expira_em
+	`, limit, outboxStaleTimeout.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim queued pushes: %w", err)
+	}
+	defer rows.Close()
+
+	var list []OutboxItem
+	for rows.Next() {
+		var item OutboxItem
+		var dados string
+		if err := rows.Scan(&item.ID, &item.Tipo, &item.Token, &dados, &item.AlertaID, &item.AgendamentoID,
+			&item.Tentativas, &item.MaxTentativas, &item.ExpiraEm); err != nil {
+			return nil, fmt.Errorf("failed to scan queued push: %w", err)
+		}
+		if err := json.Unmarshal([]byte(dados), &item.args); err != nil {
+			return nil, fmt.Errorf("failed to decode push args: %w", err)
+		}
+		list = append(list, item)
+	}
+	return list, rows.Err()
+}
+
+// expire marca como expirados os envios que passaram do TTL. A chamada que
+// não chegou a tocar deixa o agendamento em falha_envio.
+func (o *Outbox) expire(ctx context.Context) error {
+	rows, err := o.db.QueryContext(ctx, `
+		UPDATE push_outbox
+		SET status = 'expirado', atualizado_em = NOW()
+		WHERE status IN ('pendente', 'enviando') AND expira_em <= NOW()
+		RETURNING id, tipo, COALESCE(agendamento_id, 0)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to expire queued pushes: %w", err)
+	}
+	defer rows.Close()
+
+	var agendamentos []int64
+	for rows.Next() {
+		var id, agendamentoID int64
+		var tipo string
+		if err := rows.Scan(&id, &tipo, &agendamentoID); err != nil {
+			return err
+		}
+		log.Printf("⌛ Push %s #%d expirou na fila sem ser entregue", tipo, id)
+		if agendamentoID != 0 {
+			agendamentos = append(agendamentos, agendamentoID)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, id := range agendamentos {
+		o.updateSchedule(ctx, id, "falha_envio")
+	}
+	return nil
+}
+
+// DeliverQueued reenvia um push da fila com a validade restante. Erros
+// transitórios voltam para a fila com backoff enquanto houver tempo e tentativas.
+func (s *FirebaseService) DeliverQueued(ctx context.Context, o *Outbox, item OutboxItem) error {
+	c, err := rebuild(item.Tipo, item.args)
+	if err != nil {
+		return o.finish(ctx, item, OutboxFalhaDefinitiva, "", err)
+	}
+
+	response, err := s.client.Send(ctx, c.expiring(item.ExpiraEm).message(item.Token))
+	switch {
+	case err == nil:
+		log.Printf("📤 Push %s #%d entregue na tentativa %d", item.Tipo, item.ID, item.Tentativas)
+		if item.AgendamentoID != 0 {
+			o.updateSchedule(ctx, item.AgendamentoID, "em_andamento")
+		}
+		return o.finish(ctx, item, OutboxEnviado, response, nil)
+
+	case IsInvalidTokenError(err):
+		s.invalidate([]string{item.Token})
+		if item.AgendamentoID != 0 {
+			o.updateSchedule(ctx, item.AgendamentoID, "falha_token_invalido")
+		}
+		return o.finish(ctx, item, OutboxFalhaDefinitiva, "", err)
+
+	case IsTransientError(err) && item.Tentativas < item.MaxTentativas:
+		next := outboxBackoff(item.Tentativas)
+		if time.Now().Add(next).Before(item.ExpiraEm) {
+			_, uerr := o.db.ExecContext(ctx, `
+				UPDATE push_outbox
+				SET status = 'pendente', ultimo_erro = $2,
+				    proxima_tentativa = NOW() + make_interval(secs => $3), atualizado_em = NOW()
+				WHERE id = $1
+			`, item.ID, err.Error(), next.Seconds())
+			if uerr != nil {
+				return fmt.Errorf("failed to reschedule queued push: %w", uerr)
+			}
+			return nil
+		}
+		if item.AgendamentoID != 0 {
+			o.updateSchedule(ctx, item.AgendamentoID, "falha_envio")
+		}
+		return o.finish(ctx, item, OutboxExpirado, "", err)
+	}
+
+	if item.AgendamentoID != 0 {
+		o.updateSchedule(ctx, item.AgendamentoID, "falha_envio")
+	}
+	return o.finish(ctx, item, OutboxFalhaDefinitiva, "", err)
+}
+
+// finish grava o status final do envio
+func (o *Outbox) finish(ctx context.Context, item OutboxItem, status, messageID string, cause error) error {
+	erro := ""
+	if cause != nil {
+		erro = cause.Error()
+		log.Printf("❌ Push %s #%d: %s após %d tentativa(s): %v", item.Tipo, item.ID, status, item.Tentativas, cause)
+	}
+
+	_, err := o.db.ExecContext(ctx, `
+		UPDATE push_outbox
+		SET status = $2, message_id = NULLIF($3, ''), ultimo_erro = NULLIF($4, ''),
+		    enviado_em = CASE WHEN $2 = 'enviado' THEN NOW() END, atualizado_em = NOW()
+		WHERE id = $1
+	`, item.ID, status, messageID, erro)
+	if err != nil {
+		return fmt.Errorf("failed to finish queued push: %w", err)
+	}
+	return nil
+}
+
+// updateSchedule atualiza o agendamento que esperava o push da chamada
+func (o *Outbox) updateSchedule(ctx context.Context, agendamentoID int64, status string) {
+	_, err := o.db.ExecContext(ctx, `
+		UPDATE agendamentos
+		SET status = $2,
+		    ultima_tentativa = CASE WHEN $2 = 'em_andamento' THEN NOW() ELSE ultima_tentativa END,
+		    atualizado_em = NOW()
+		WHERE id = $1 AND status = 'aguardando_push'
+	`, agendamentoID, status)
+	if err != nil {
+		log.Printf("❌ Erro ao atualizar agendamento %d: %v", agendamentoID, err)
+	}
+}
+
+// Stats profundidade da fila e resultado das últimas 24h
+func (o *Outbox) Stats(ctx context.Context) (*OutboxStats, error) {
+	stats := &OutboxStats{PorTipo: map[string]int{}}
+
+	rows, err := o.db.QueryContext(ctx, `
+		SELECT tipo, COUNT(*), MIN(criado_em)
+		FROM push_outbox
+		WHERE status IN ('pendente', 'enviando')
+		GROUP BY tipo
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query push outbox: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var tipo string
+		var n int
+		var oldest time.Time
+		if err := rows.Scan(&tipo, &n, &oldest); err != nil {
+			return nil, err
+		}
+		stats.PorTipo[tipo] = n
+		stats.Pendentes += n
+		if stats.MaisAntigaEm == nil || oldest.Before(*stats.MaisAntigaEm) {
+			stats.MaisAntigaEm = &oldest
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if stats.MaisAntigaEm != nil {
+		stats.AtrasoSegundos = int(time.Since(*stats.MaisAntigaEm).Seconds())
+	}
+
+	err = o.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FILTER (WHERE status = 'enviado'),
+		       COUNT(*) FILTER (WHERE status = 'expirado'),
+		       COUNT(*) FILTER (WHERE status = 'falha_definitiva')
+		FROM push_outbox
+		WHERE criado_em > NOW() - INTERVAL '24 hours'
+	`).Scan(&stats.Enviados24h, &stats.Expirados24h, &stats.FalhasDef24h)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query push outbox: %w", err)
+	}
+	return stats, nil
+}
+
+// outboxBackoff espera antes da próxima tentativa (5s, 10s, 20s... até 5min)
+func outboxBackoff(attempt int) time.Duration {
+	d := outboxBaseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return d
+}
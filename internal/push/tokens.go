@@ -0,0 +1,93 @@
+package push
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// TokenStore marca device tokens inválidos e registra o resumo dos envios
+type TokenStore struct {
+	db *sql.DB
+}
+
+// NewTokenStore cria o registro de tokens
+func NewTokenStore(db *sql.DB) *TokenStore {
+	return &TokenStore{db: db}
+}
+
+// Invalidate marca os tokens como inválidos nos dispositivos e em cuidadores e
+// idosos. Quem tiver outro dispositivo válido passa a usá-lo.
+func (t *TokenStore) Invalidate(ctx context.Context, tokens []string) error {
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	if _, err := t.db.ExecContext(ctx, `
+		UPDATE dispositivos SET valido = false, invalidado_em = NOW()
+		WHERE token = ANY($1) AND valido
+	`, pq.Array(tokens)); err != nil {
+		return fmt.Errorf("failed to invalidate devices: %w", err)
+	}
+
+	for _, p := range []struct{ table, tipo string }{{"cuidadores", "cuidador"}, {"idosos", "idoso"}} {
+		if _, err := t.db.ExecContext(ctx, `
+			UPDATE `+p.table+` t
+			SET device_token = d.token, device_token_valido = true, device_token_atualizado_em = NOW()
+			FROM (
+				SELECT DISTINCT ON (pessoa_id) pessoa_id, token
+				FROM dispositivos
+				WHERE tipo = $2 AND valido AND plataforma <> 'web'
+				ORDER BY pessoa_id, ultimo_registro_em DESC
+			) d
+			WHERE t.id = d.pessoa_id AND t.device_token = ANY($1)
+		`, pq.Array(tokens), p.tipo); err != nil {
+			return fmt.Errorf("failed to promote %s devices: %w", p.table, err)
+		}
+
+		res, err := t.db.ExecContext(ctx, `
+			UPDATE `+p.table+`
+			SET device_token_valido = false, device_token_atualizado_em = NOW()
+			WHERE device_token = ANY($1) AND COALESCE(device_token_valido, true)
+		`, pq.Array(tokens))
+		if err != nil {
+			return fmt.Errorf("failed to invalidate %s tokens: %w", p.table, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			log.Printf("🧹 %d device token(s) inválido(s) em %s", n, p.table)
+		}
+	}
+	return nil
+}
+
+// SendSummary resumo de um envio multicast
+type SendSummary struct {
+	AlertaID  int64
+	Tipo      string
+	Total     int
+	Sucesso   int
+	Falha     int
+	Invalidos int
+	Duracao   time.Duration
+}
+
+// Record grava o resumo em envios_push
+func (t *TokenStore) Record(ctx context.Context, s SendSummary) error {
+	var alerta interface{}
+	if s.AlertaID != 0 {
+		alerta = s.AlertaID
+	}
+
+	_, err := t.db.ExecContext(ctx, `
+		INSERT INTO envios_push (alerta_id, tipo, total, sucesso, falha, invalidos, duracao_ms)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, alerta, s.Tipo, s.Total, s.Sucesso, s.Falha, s.Invalidos, int(s.Duracao/time.Millisecond))
+	if err != nil {
+		return fmt.Errorf("failed to record push summary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+package push
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hkdf"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// webPushTTL tempo que o serviço de push guarda a mensagem com o navegador offline
+const webPushTTL = 12 * time.Hour
+
+// ErrSubscriptionGone o serviço de push respondeu 404/410: a assinatura expirou
+var ErrSubscriptionGone = errors.New("web push subscription expired")
+
+// Subscription assinatura Web Push do navegador (PushSubscription.toJSON())
+type Subscription struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256dh string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// ParseSubscription valida a assinatura enviada pelo painel web
+func ParseSubscription(raw string) (*Subscription, error) {
+	var sub Subscription
+	if err := json.Unmarshal([]byte(raw), &sub); err != nil {
+		return nil, fmt.Errorf("assinatura web push inválida: %w", err)
+	}
+
+	u, err := url.Parse(sub.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("assinatura web push inválida: endpoint deve ser https")
+	}
+	key, err := decodeKey(sub.Keys.P256dh)
+	if err != nil || len(key) != 65 || key[0] != 4 {
+		return nil, fmt.Errorf("assinatura web push inválida: p256dh")
+	}
+	auth, err := decodeKey(sub.Keys.Auth)
+	if err != nil || len(auth) != 16 {
+		return nil, fmt.Errorf("assinatura web push inválida: auth")
+	}
+
+	// Sem padding, para o mesmo navegador gerar sempre o mesmo token
+	sub.Keys.P256dh = base64.RawURLEncoding.EncodeToString(key)
+	sub.Keys.Auth = base64.RawURLEncoding.EncodeToString(auth)
+	return &sub, nil
+}
+
+// String JSON canônico da assinatura (é o token gravado em dispositivos)
+func (s *Subscription) String() string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// WebPushService envia Web Push padrão (VAPID) para o painel web dos cuidadores
+type WebPushService struct {
+	client    *http.Client
+	key       *ecdsa.PrivateKey
+	publicKey string
+	subject   string
+	tokens    *TokenStore
+}
+
+// NewWebPushService cria o serviço a partir da chave privada VAPID (P-256, base64url)
+// e do contato do servidor (mailto: ou https:)
+func NewWebPushService(privateKey, subject string) (*WebPushService, error) {
+	d, err := decodeKey(privateKey)
+	if err != nil || len(d) != 32 {
+		return nil, fmt.Errorf("invalid VAPID private key")
+	}
+	priv, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+	}
+	if subject == "" {
+		return nil, fmt.Errorf("VAPID subject is required")
+	}
+
+	pub := priv.PublicKey().Bytes()
+	key := &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(pub[1:33]),
+			Y:     new(big.Int).SetBytes(pub[33:]),
+		},
+		D: new(big.Int).SetBytes(d),
+	}
+
+	return &WebPushService{
+		client:    &http.Client{Timeout: 15 * time.Second},
+		key:       key,
+		publicKey: base64.RawURLEncoding.EncodeToString(pub),
+		subject:   subject,
+	}, nil
+}
+
+// GenerateVAPIDKeys gera um par de chaves VAPID (base64url)
+func GenerateVAPIDKeys() (privateKey, publicKey string, err error) {
+	priv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return "", "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(priv.Bytes()),
+		base64.RawURLEncoding.EncodeToString(priv.PublicKey().Bytes()), nil
+}
+
+// PublicKey chave pública VAPID (applicationServerKey do pushManager.subscribe)
+func (w *WebPushService) PublicKey() string { return w.publicKey }
+
+// EnableTokenCleanup marca como inválidas as assinaturas expiradas (404/410)
+func (w *WebPushService) EnableTokenCleanup(db *sql.DB) *WebPushService {
+	w.tokens = NewTokenStore(db)
+	return w
+}
+
+// SendAlert envia o alerta de emergência para as assinaturas; um erro por assinatura
+func (w *WebPushService) SendAlert(ctx context.Context, subscriptions []string, ref AlertRef, reason string) []error {
+	return w.sendAll(ctx, subscriptions, alertContent(ref, reason))
+}
+
+// SendMissedCall avisa as assinaturas que o idoso não atendeu a chamada
+func (w *WebPushService) SendMissedCall(ctx context.Context, subscriptions []string, ref AlertRef) []error {
+	return w.sendAll(ctx, subscriptions, missedCallContent(ref))
+}
+
+// SendMedication confirma às assinaturas que o idoso tomou o remédio
+func (w *WebPushService) SendMedication(ctx context.Context, subscriptions []string, elderName, medicationName string) []error {
+	return w.sendAll(ctx, subscriptions, medicationContent(elderName, medicationName))
+}
+
+func (w *WebPushService) sendAll(ctx context.Context, subscriptions []string, c content) []error {
+	errs := make([]error, len(subscriptions))
+	var gone []string
+	for i, raw := range subscriptions {
+		errs[i] = w.send(ctx, raw, c)
+		if errors.Is(errs[i], ErrSubscriptionGone) {
+			gone = append(gone, raw)
+		}
+	}
+
+	if len(gone) > 0 && w.tokens != nil {
+		if err := w.tokens.Invalidate(ctx, gone); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+	return errs
+}
+
+// send cifra o payload (RFC 8291) e o entrega ao serviço de push do navegador
+func (w *WebPushService) send(ctx context.Context, raw string, c content) error {
+	sub, err := ParseSubscription(raw)
+	if err != nil {
+		return err
+	}
+
+	payload, err := c.webPayload()
+	if err != nil {
+		return fmt.Errorf("failed to encode web push payload: %w", err)
+	}
+	body, err := encrypt(sub, payload)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt web push payload: %w", err)
+	}
+	auth, err := w.vapidAuthorization(sub.Endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to sign VAPID token: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", fmt.Sprintf("%d", int(webPushTTL/time.Second)))
+	req.Header.Set("Urgency", c.webUrgency())
+	req.Header.Set("Authorization", auth)
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error sending web push: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return ErrSubscriptionGone
+	case resp.StatusCode >= 300:
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("web push rejected (%d): %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	log.Printf("🌐 Web push %s entregue a %s", c.data["type"], req.URL.Host)
+	return nil
+}
+
+// vapidAuthorization cabeçalho "vapid t=<JWT ES256>, k=<chave pública>" (RFC 8292)
+func (w *WebPushService) vapidAuthorization(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	header, _ := json.Marshal(map[string]string{"typ": "JWT", "alg": "ES256"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"aud": u.Scheme + "://" + u.Host,
+		"exp": time.Now().Add(12 * time.Hour).Unix(),
+		"sub": w.subject,
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+
+	digest := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, w.key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+
+	return fmt.Sprintf("vapid t=%s.%s, k=%s", unsigned, base64.RawURLEncoding.EncodeToString(sig), w.publicKey), nil
+}
+
+// encrypt cifra o payload em aes128gcm com um único registro (RFC 8188/8291)
+func encrypt(sub *Subscription, payload []byte) ([]byte, error) {
+	uaPublic, _ := decodeKey(sub.Keys.P256dh)
+	authSecret, _ := decodeKey(sub.Keys.Auth)
+
+	uaKey, err := ecdh.P256().NewPublicKey(uaPublic)
+	if err != nil {
+		return nil, err
+	}
+	asKey, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := asKey.ECDH(uaKey)
+	if err != nil {
+		return nil, err
+	}
+	asPublic := asKey.PublicKey().Bytes()
+
+	keyInfo := "WebPush: info\x00" + string(uaPublic) + string(asPublic)
+	ikm, err := hkdf.Key(sha256.New, shared, authSecret, keyInfo, 32)
+	if err != nil {
+		return nil, err
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	prk, err := hkdf.Extract(sha256.New, ikm, salt)
+	if err != nil {
+		return nil, err
+	}
+	cek, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: aes128gcm\x00", 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdf.Expand(sha256.New, prk, "Content-Encoding: nonce\x00", 12)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+
+	// 0x02 = delimitador do último (e único) registro, sem padding
+	record := append(append([]byte{}, payload...), 0x02)
+	const recordSize = 4096
+	if len(record)+16 > recordSize {
+		return nil, fmt.Errorf("payload too large (%d bytes)", len(payload))
+	}
+
+	header := make([]byte, 0, 16+4+1+len(asPublic))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, recordSize)
+	header = append(header, byte(len(asPublic)))
+	header = append(header, asPublic...)
+
+	return gcm.Seal(header, nonce, record, nil), nil
+}
+
+// decodeKey aceita base64url com ou sem padding
+func decodeKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
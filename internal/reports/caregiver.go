@@ -0,0 +1,215 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/email"
+	"eva-mind/internal/metrics"
+
+	"github.com/lib/pq"
+)
+
+// moodThreshold variação mínima da fração de ligações com humor bom para
+// considerar que o humor mudou de uma semana para outra
+const moodThreshold = 0.15
+
+// CaregiverTarget cuidador ativo com email que ainda não recebeu o resumo da semana
+type CaregiverTarget struct {
+	CuidadorID int64
+	IdosoID    int64
+	Email      string
+}
+
+// PendingCaregivers retorna os cuidadores ativos com email sem resumo da semana iniciada em weekStart
+func (s *Service) PendingCaregivers(ctx context.Context, weekStart time.Time) ([]CaregiverTarget, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT c.id, c.idoso_id, c.email
+		FROM cuidadores c
+		JOIN idosos i ON i.id = c.idoso_id
+		WHERE c.ativo = true AND i.ativo = true
+		  AND COALESCE(c.email, '') <> ''
+		  AND NOT EXISTS (
+			SELECT 1 FROM resumos_semanais_cuidador r
+			WHERE r.cuidador_id = c.id AND r.semana_inicio = $1
+		  )
+		ORDER BY c.idoso_id, c.id
+	`, weekStart)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query caregivers for weekly digest: %w", err)
+	}
+	defer rows.Close()
+
+	var list []CaregiverTarget
+	for rows.Next() {
+		var t CaregiverTarget
+		if err := rows.Scan(&t.CuidadorID, &t.IdosoID, &t.Email); err != nil {
+			return nil, fmt.Errorf("failed to scan caregiver: %w", err)
+		}
+		list = append(list, t)
+	}
+
+	return list, rows.Err()
+}
+
+// CaregiverWeekly monta o resumo da semana do idoso para os cuidadores:
+// ligações, adesão, tendência do humor, alertas em aberto e padrões e
+// predições novos na semana. Retorna também o nome do idoso.
+func (s *Service) CaregiverWeekly(ctx context.Context, idosoID int64, weekStart time.Time) (*email.CaregiverWeekly, string, error) {
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	var nome string
+	if err := s.db.QueryRowContext(ctx, `SELECT COALESCE(nome, '') FROM idosos WHERE id = $1`, idosoID).Scan(&nome); err != nil {
+		return nil, "", fmt.Errorf("failed to query idoso: %w", err)
+	}
+
+	// Semana anterior + semana do resumo, para comparar o humor
+	series, err := s.metrics.ElderSeries(ctx, idosoID, weekStart.AddDate(0, 0, -7), weekEnd, metrics.BucketWeek)
+	if err != nil {
+		return nil, "", err
+	}
+	previous, current := series.Pontos[0], series.Pontos[len(series.Pontos)-1]
+
+	weekly := &email.CaregiverWeekly{
+		Start:          weekStart,
+		End:            weekEnd.AddDate(0, 0, -1),
+		CallsCompleted: current.ChamadasConcluidas,
+		CallsMissed:    current.ChamadasNaoAtendidas,
+		Adherence:      current.AdesaoMedicacao,
+		Mood:           moodTrend(previous, current),
+	}
+
+	if err := s.loadOpenAlerts(ctx, idosoID, weekly); err != nil {
+		return nil, "", err
+	}
+	if err := s.loadNewPatterns(ctx, idosoID, weekStart, weekEnd, weekly); err != nil {
+		return nil, "", err
+	}
+	if err := s.loadNewRisks(ctx, idosoID, weekStart, weekEnd, weekly); err != nil {
+		return nil, "", err
+	}
+
+	return weekly, nome, nil
+}
+
+// MarkCaregiverSent registra o resumo enviado ao cuidador (um por semana)
+func (s *Service) MarkCaregiverSent(ctx context.Context, t CaregiverTarget, weekly *email.CaregiverWeekly) error {
+	conteudo, err := json.Marshal(weekly)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO resumos_semanais_cuidador (cuidador_id, idoso_id, semana_inicio, conteudo)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (cuidador_id, semana_inicio) DO NOTHING
+	`, t.CuidadorID, t.IdosoID, weekly.Start, conteudo)
+	if err != nil {
+		return fmt.Errorf("failed to save caregiver weekly digest: %w", err)
+	}
+	return nil
+}
+
+// moodTrend compara a fração de ligações com humor feliz ou neutro entre as
+// semanas. Sem humor registrado em alguma delas não há tendência.
+func moodTrend(previous, current *metrics.Point) string {
+	before, ok1 := goodMoodShare(previous)
+	now, ok2 := goodMoodShare(current)
+	if !ok1 || !ok2 {
+		return ""
+	}
+
+	switch {
+	case now-before >= moodThreshold:
+		return email.MoodImproved
+	case before-now >= moodThreshold:
+		return email.MoodWorse
+	}
+	return email.MoodStable
+}
+
+func goodMoodShare(p *metrics.Point) (float64, bool) {
+	total := 0
+	for _, n := range p.Humor {
+		total += n
+	}
+	if total == 0 {
+		return 0, false
+	}
+	return float64(p.Humor["feliz"]+p.Humor["neutro"]) / float64(total), true
+}
+
+func (s *Service) loadOpenAlerts(ctx context.Context, idosoID int64, weekly *email.CaregiverWeekly) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COALESCE(NULLIF(mensagem, ''), tipo, ''), criado_em
+		FROM alertas
+		WHERE idoso_id = $1 AND status = ANY($2)
+		ORDER BY criado_em DESC
+		LIMIT 10
+	`, idosoID, pq.Array(alerts.OpenStatuses))
+	if err != nil {
+		return fmt.Errorf("failed to query open alerts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item email.DigestItem
+		if err := rows.Scan(&item.Text, &item.At); err != nil {
+			return fmt.Errorf("failed to scan open alert: %w", err)
+		}
+		weekly.OpenAlerts = append(weekly.OpenAlerts, item)
+	}
+
+	return rows.Err()
+}
+
+func (s *Service) loadNewPatterns(ctx context.Context, idosoID int64, from, to time.Time, weekly *email.CaregiverWeekly) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT descricao
+		FROM padroes_comportamento
+		WHERE idoso_id = $1 AND criado_em >= $2 AND criado_em < $3
+		  AND COALESCE(descricao, '') <> ''
+		ORDER BY confianca DESC
+		LIMIT 5
+	`, idosoID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query behavior patterns: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var descricao string
+		if err := rows.Scan(&descricao); err != nil {
+			return fmt.Errorf("failed to scan behavior pattern: %w", err)
+		}
+		weekly.Patterns = append(weekly.Patterns, descricao)
+	}
+
+	return rows.Err()
+}
+
+func (s *Service) loadNewRisks(ctx context.Context, idosoID int64, from, to time.Time, weekly *email.CaregiverWeekly) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (tipo_emergencia) tipo_emergencia, nivel_risco, probabilidade
+		FROM predicoes_emergencia
+		WHERE idoso_id = $1 AND criado_em >= $2 AND criado_em < $3
+		ORDER BY tipo_emergencia, probabilidade DESC
+	`, idosoID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query predictions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r email.WeeklyRisk
+		if err := rows.Scan(&r.Type, &r.Level, &r.Probability); err != nil {
+			return fmt.Errorf("failed to scan prediction: %w", err)
+		}
+		weekly.Risks = append(weekly.Risks, r)
+	}
+
+	return rows.Err()
+}
@@ -0,0 +1,245 @@
+package reports
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"eva-mind/internal/email"
+)
+
+// maxDeliveryAttempts tentativas de envio por assinante; depois disso o
+// assinante é dado como perdido e não segura mais o relatório
+const maxDeliveryAttempts = 5
+
+// Recipient destinatário do relatório (cuidador ou médico)
+type Recipient struct {
+	Nome       string
+	Email      string
+	Papel      string
+	CuidadorID int64 // 0 para quem não é cuidador (médico)
+}
+
+// Recipients retorna os assinantes ativos do relatório do idoso
+func (s *Service) Recipients(ctx context.Context, idosoID int64) ([]Recipient, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT r.nome, r.email, r.papel, COALESCE(r.cuidador_id, 0)
+		FROM relatorio_assinantes r
+		LEFT JOIN cuidadores c ON c.id = r.cuidador_id
+		WHERE r.idoso_id = $1
+		  AND r.ativo = true
+		  AND (r.cuidador_id IS NULL OR c.ativo = true)
+	`, idosoID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query report recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []Recipient
+	for rows.Next() {
+		var r Recipient
+		if err := rows.Scan(&r.Nome, &r.Email, &r.Papel, &r.CuidadorID); err != nil {
+			return nil, fmt.Errorf("failed to scan recipient: %w", err)
+		}
+		recipients = append(recipients, r)
+	}
+
+	return recipients, rows.Err()
+}
+
+// Send envia o relatório por email aos assinantes que ainda não receberam e
+// registra cada entrega em relatorio_envios. Só marca o relatório como enviado
+// quando todos receberam ou esgotaram as tentativas; os que falharam voltam na
+// próxima execução do worker.
+func (s *Service) Send(ctx context.Context, emailService *email.EmailService, report *Report) error {
+	var stats WeeklyStats
+	if err := json.Unmarshal(report.Estatisticas, &stats); err != nil {
+		return fmt.Errorf("estatísticas inválidas no relatório %d: %w", report.ID, err)
+	}
+
+	recipients, err := s.Recipients(ctx, report.IdosoID)
+	if err != nil {
+		return err
+	}
+
+	done, err := s.deliveries(ctx, report.ID)
+	if err != nil {
+		return err
+	}
+
+	tables := Tables(&stats)
+
+	sent, failed, exhausted := 0, 0, 0
+	for _, r := range recipients {
+		d, ok := done[r.Email]
+		switch {
+		case ok && d.status == "enviado":
+			continue
+		case ok && d.tentativas >= maxDeliveryAttempts:
+			exhausted++
+			continue
+		}
+
+		env := email.Envelope{To: r.Email, Name: r.Nome, IdosoID: report.IdosoID, CuidadorID: r.CuidadorID}
+		sendErr := emailService.SendWeeklyReport(ctx, env, stats.NomeIdoso, report.SemanaInicio, report.SemanaFim, report.Narrativa, tables)
+		if sendErr != nil {
+			log.Printf("❌ Relatório %d não enviado para %s: %v", report.ID, r.Email, sendErr)
+			failed++
+		} else {
+			sent++
+		}
+
+		if err := s.recordDelivery(ctx, report.ID, r, sendErr); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("relatório %d: %d de %d assinante(s) sem envio, nova tentativa na próxima execução", report.ID, failed, len(recipients))
+	}
+
+	if exhausted > 0 {
+		log.Printf("⚠️ Relatório %d: %d assinante(s) sem envio após %d tentativas", report.ID, exhausted, maxDeliveryAttempts)
+	}
+	log.Printf("📋 Relatório %d enviado para %d assinante(s) (%d já tinham recebido)", report.ID, sent, len(recipients)-sent-exhausted)
+	return s.MarkSent(ctx, report.ID)
+}
+
+type delivery struct {
+	status     string
+	tentativas int
+}
+
+// deliveries situação do envio do relatório por email
+func (s *Service) deliveries(ctx context.Context, reportID int64) (map[string]delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT email, status, tentativas FROM relatorio_envios WHERE relatorio_id = $1
+	`, reportID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query report deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	done := make(map[string]delivery)
+	for rows.Next() {
+		var addr string
+		var d delivery
+		if err := rows.Scan(&addr, &d.status, &d.tentativas); err != nil {
+			return nil, fmt.Errorf("failed to scan report delivery: %w", err)
+		}
+		done[addr] = d
+	}
+	return done, rows.Err()
+}
+
+// recordDelivery grava o resultado do envio para o assinante
+func (s *Service) recordDelivery(ctx context.Context, reportID int64, r Recipient, sendErr error) error {
+	status, erro := "enviado", ""
+	if sendErr != nil {
+		status, erro = "falhou", sendErr.Error()
+	}
+	var cuidador interface{}
+	if r.CuidadorID != 0 {
+		cuidador = r.CuidadorID
+	}
+
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO relatorio_envios (relatorio_id, email, cuidador_id, status, tentativas, ultimo_erro, enviado_em)
+		VALUES ($1, $2, $3, $4, 1, NULLIF($5, ''), CASE WHEN $4 = 'enviado' THEN NOW() END)
+		ON CONFLICT (relatorio_id, email) DO UPDATE SET
+			status = EXCLUDED.status,
+			tentativas = relatorio_envios.tentativas + 1,
+			ultimo_erro = EXCLUDED.ultimo_erro,
+			enviado_em = EXCLUDED.enviado_em,
+			atualizado_em = NOW()
+	`, reportID, r.Email, cuidador, status, erro)
+	if err != nil {
+		return fmt.Errorf("failed to record report delivery: %w", err)
+	}
+	return nil
+}
+
+// Tables converte as estatísticas nas tabelas determinísticas do relatório
+func Tables(stats *WeeklyStats) []email.ReportTable {
+	l := stats.Ligacoes
+	if l == nil {
+		return nil
+	}
+
+	tables := []email.ReportTable{
+		{
+			Title: "Ligações",
+			Rows: [][2]string{
+				{"Chamadas agendadas", fmt.Sprintf("%d", l.ChamadasAgendadas)},
+				{"Chamadas concluídas", fmt.Sprintf("%d", l.ChamadasConcluidas)},
+				{"Chamadas não atendidas", fmt.Sprintf("%d", l.ChamadasNaoAtendidas)},
+				{"Taxa de conclusão", percent(l.TaxaConclusao)},
+				{"Ligações analisadas", fmt.Sprintf("%d", l.LigacoesAnalisadas)},
+			},
+		},
+		{
+			Title: "Saúde",
+			Rows: [][2]string{
+				{"Dor média (0-10)", decimal(l.DorMedia)},
+				{"Ligações com dor", fmt.Sprintf("%d", l.EpisodiosDor)},
+				{"Episódios de confusão", fmt.Sprintf("%d", l.EpisodiosConfusao)},
+				{"Episódios de solidão", fmt.Sprintf("%d", l.EpisodiosSolidao)},
+			},
+		},
+	}
+
+	if len(l.Humor) > 0 {
+		humor := email.ReportTable{Title: "Humor"}
+		for _, h := range []string{"feliz", "neutro", "ansioso", "triste", "apatico", "confuso", "irritado"} {
+			if n := l.Humor[h]; n > 0 {
+				humor.Rows = append(humor.Rows, [2]string{h, fmt.Sprintf("%d", n)})
+			}
+		}
+		tables = append(tables, humor)
+	}
+
+	med := email.ReportTable{
+		Title: "Medicação",
+		Rows:  [][2]string{{"Adesão aos lembretes", percent(l.AdesaoMedicacao)}},
+	}
+	for _, m := range stats.Medicacoes {
+		med.Rows = append(med.Rows, [2]string{m.Medicamento, fmt.Sprintf("%d confirmação(ões) em %d dia(s)", m.Confirmacoes, m.DiasComTomada)})
+	}
+	tables = append(tables, med)
+
+	alerts := email.ReportTable{Title: "Alertas"}
+	for _, sev := range []string{"critica", "alta", "media", "aviso", "baixa"} {
+		if n := stats.AlertasPorNivel[sev]; n > 0 {
+			alerts.Rows = append(alerts.Rows, [2]string{sev, fmt.Sprintf("%d", n)})
+		}
+	}
+	if len(alerts.Rows) == 0 {
+		alerts.Rows = [][2]string{{"Nenhum alerta", "-"}}
+	}
+	tables = append(tables, alerts)
+
+	if len(stats.Predicoes) > 0 {
+		preds := email.ReportTable{Title: "Riscos previstos"}
+		for _, p := range stats.Predicoes {
+			preds.Rows = append(preds.Rows, [2]string{p.TipoEmergencia, fmt.Sprintf("%s (%.0f%%)", p.NivelRisco, p.Probabilidade*100)})
+		}
+		tables = append(tables, preds)
+	}
+
+	return tables
+}
+
+func percent(v *float64) string {
+	if v == nil {
+		return "sem dados"
+	}
+	return fmt.Sprintf("%.0f%%", *v*100)
+}
+
+func decimal(v *float64) string {
+	if v == nil {
+		return "sem dados"
+	}
+	return fmt.Sprintf("%.1f", *v)
+}
@@ -0,0 +1,349 @@
+package reports
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/gemini"
+	"eva-mind/internal/metrics"
+)
+
+// AlertSummary alerta relevante da semana
+type AlertSummary struct {
+	Severidade string    `json:"severidade"`
+	Tipo       string    `json:"tipo"`
+	Mensagem   string    `json:"mensagem"`
+	CriadoEm   time.Time `json:"criado_em"`
+}
+
+// MedicationCount confirmações de um medicamento na semana (historico_medicamentos)
+type MedicationCount struct {
+	Medicamento   string `json:"medicamento"`
+	Confirmacoes  int    `json:"confirmacoes"`
+	DiasComTomada int    `json:"dias_com_tomada"`
+}
+
+// PredictionSummary predição vigente durante a semana
+type PredictionSummary struct {
+	TipoEmergencia string  `json:"tipo_emergencia"`
+	NivelRisco     string  `json:"nivel_risco"`
+	Probabilidade  float64 `json:"probabilidade"`
+}
+
+// WeeklyStats estatísticas determinísticas do relatório
+type WeeklyStats struct {
+	IdosoID         int64               `json:"idoso_id"`
+	NomeIdoso       string              `json:"nome_idoso"`
+	SemanaInicio    time.Time           `json:"semana_inicio"`
+	SemanaFim       time.Time           `json:"semana_fim"`
+	Ligacoes        *metrics.Point      `json:"ligacoes"`
+	AlertasPorNivel map[string]int      `json:"alertas_por_severidade"`
+	AlertasRecentes []AlertSummary      `json:"alertas_recentes"`
+	Medicacoes      []MedicationCount   `json:"medicacoes"`
+	Predicoes       []PredictionSummary `json:"predicoes"`
+	ResumosLigacoes []string            `json:"-"`
+}
+
+// Report relatório semanal armazenado
+type Report struct {
+	ID           int64           `json:"id"`
+	IdosoID      int64           `json:"idoso_id"`
+	SemanaInicio time.Time       `json:"semana_inicio"`
+	SemanaFim    time.Time       `json:"semana_fim"`
+	Estatisticas json.RawMessage `json:"estatisticas"`
+	Narrativa    string          `json:"narrativa"`
+	Modelo       string          `json:"modelo"`
+	EnviadoEm    *time.Time      `json:"enviado_em,omitempty"`
+	CriadoEm     time.Time       `json:"criado_em"`
+}
+
+// Service compila, armazena e consulta os relatórios semanais
+type Service struct {
+	cfg     *config.Config
+	db      *sql.DB
+	metrics *metrics.Service
+}
+
+// NewService cria o serviço de relatórios
+func NewService(cfg *config.Config, db *sql.DB) *Service {
+	return &Service{
+		cfg:     cfg,
+		db:      db,
+		metrics: metrics.NewService(db),
+	}
+}
+
+// LastCompleteWeek retorna o início (segunda-feira 00:00) da última semana encerrada
+func LastCompleteWeek(now time.Time) time.Time {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset-7)
+}
+
+// CollectStats reúne as estatísticas da semana iniciada em weekStart
+func (s *Service) CollectStats(ctx context.Context, idosoID int64, weekStart time.Time) (*WeeklyStats, error) {
+	weekEnd := weekStart.AddDate(0, 0, 7)
+
+	stats := &WeeklyStats{
+		IdosoID:         idosoID,
+		SemanaInicio:    weekStart,
+		SemanaFim:       weekEnd.AddDate(0, 0, -1),
+		AlertasPorNivel: make(map[string]int),
+	}
+
+	if err := s.db.QueryRowContext(ctx, `SELECT nome FROM idosos WHERE id = $1`, idosoID).Scan(&stats.NomeIdoso); err != nil {
+		return nil, fmt.Errorf("failed to query idoso: %w", err)
+	}
+
+	series, err := s.metrics.ElderSeries(ctx, idosoID, weekStart, weekEnd, metrics.BucketWeek)
+	if err != nil {
+		return nil, err
+	}
+	stats.Ligacoes = series.Resumo
+
+	if err := s.loadAlerts(ctx, stats, weekStart, weekEnd); err != nil {
+		return nil, err
+	}
+	if err := s.loadMedications(ctx, stats, weekStart, weekEnd); err != nil {
+		return nil, err
+	}
+	if err := s.loadPredictions(ctx, stats, weekStart, weekEnd); err != nil {
+		return nil, err
+	}
+	if err := s.loadCallSummaries(ctx, stats, weekStart, weekEnd); err != nil {
+		return nil, err
+	}
+
+	return stats, nil
+}
+
+func (s *Service) loadAlerts(ctx context.Context, stats *WeeklyStats, from, to time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT COALESCE(severidade, ''), COALESCE(tipo, ''), COALESCE(mensagem, ''), criado_em
+		FROM alertas
+		WHERE idoso_id = $1 AND criado_em >= $2 AND criado_em < $3
+		ORDER BY criado_em DESC
+	`, stats.IdosoID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query alerts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a AlertSummary
+		if err := rows.Scan(&a.Severidade, &a.Tipo, &a.Mensagem, &a.CriadoEm); err != nil {
+			return fmt.Errorf("failed to scan alert: %w", err)
+		}
+		stats.AlertasPorNivel[a.Severidade]++
+		if len(stats.AlertasRecentes) < 10 {
+			stats.AlertasRecentes = append(stats.AlertasRecentes, a)
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s *Service) loadMedications(ctx context.Context, stats *WeeklyStats, from, to time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT medicamento, COUNT(*), COUNT(DISTINCT DATE(tomado_em))
+		FROM historico_medicamentos
+		WHERE idoso_id = $1 AND tomado_em >= $2 AND tomado_em < $3
+		GROUP BY medicamento
+		ORDER BY medicamento
+	`, stats.IdosoID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query medications: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var m MedicationCount
+		if err := rows.Scan(&m.Medicamento, &m.Confirmacoes, &m.DiasComTomada); err != nil {
+			return fmt.Errorf("failed to scan medication: %w", err)
+		}
+		stats.Medicacoes = append(stats.Medicacoes, m)
+	}
+
+	return rows.Err()
+}
+
+func (s *Service) loadPredictions(ctx context.Context, stats *WeeklyStats, from, to time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT DISTINCT ON (tipo_emergencia) tipo_emergencia, nivel_risco, probabilidade
+		FROM predicoes_emergencia
+		WHERE idoso_id = $1
+		  AND validade_ate >= $2
+		  AND validade_ate < $3
+		ORDER BY tipo_emergencia, validade_ate DESC
+	`, stats.IdosoID, from, to.AddDate(0, 0, 7))
+	if err != nil {
+		return fmt.Errorf("failed to query predictions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var p PredictionSummary
+		if err := rows.Scan(&p.TipoEmergencia, &p.NivelRisco, &p.Probabilidade); err != nil {
+			return fmt.Errorf("failed to scan prediction: %w", err)
+		}
+		stats.Predicoes = append(stats.Predicoes, p)
+	}
+
+	return rows.Err()
+}
+
+func (s *Service) loadCallSummaries(ctx context.Context, stats *WeeklyStats, from, to time.Time) error {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT transcricao_resumo
+		FROM historico_ligacoes
+		WHERE idoso_id = $1 AND inicio_chamada >= $2 AND inicio_chamada < $3
+		  AND transcricao_resumo IS NOT NULL AND transcricao_resumo <> ''
+		ORDER BY inicio_chamada
+		LIMIT 30
+	`, stats.IdosoID, from, to)
+	if err != nil {
+		return fmt.Errorf("failed to query call summaries: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var resumo string
+		if err := rows.Scan(&resumo); err != nil {
+			return fmt.Errorf("failed to scan call summary: %w", err)
+		}
+		stats.ResumosLigacoes = append(stats.ResumosLigacoes, resumo)
+	}
+
+	return rows.Err()
+}
+
+// DraftNarrative pede ao modelo de análise um resumo clínico baseado apenas nas estatísticas
+func (s *Service) DraftNarrative(stats *WeeklyStats) (string, error) {
+	statsJSON, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	prompt := fmt.Sprintf(`Você é um médico geriatra escrevendo o resumo clínico semanal de um idoso acompanhado pela assistente virtual EVA.
+O texto será lido pela família e pelo médico responsável.
+
+ESTATÍSTICAS DA SEMANA (JSON):
+%s
+
+RESUMOS DAS LIGAÇÕES:
+%s
+
+Escreva em português do Brasil, em 3 a 5 parágrafos curtos, sem markdown:
+1. Visão geral da semana (humor, engajamento nas ligações).
+2. Saúde física (dor, sintomas, alertas).
+3. Medicação e adesão.
+4. Riscos identificados e recomendações práticas.
+
+Use APENAS os dados fornecidos. Não invente números, diagnósticos ou eventos. Se um dado não estiver disponível, diga isso.`,
+		statsJSON, strings.Join(stats.ResumosLigacoes, "\n- "))
+
+	return gemini.GenerateText(s.cfg, prompt)
+}
+
+// Generate compila e armazena o relatório da semana. Se já existir, retorna o existente.
+func (s *Service) Generate(ctx context.Context, idosoID int64, weekStart time.Time) (*Report, error) {
+	existing, err := s.GetByWeek(ctx, idosoID, weekStart)
+	switch {
+	case err == nil:
+		return existing, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		return nil, fmt.Errorf("failed to query report: %w", err)
+	}
+
+	stats, err := s.CollectStats(ctx, idosoID, weekStart)
+	if err != nil {
+		return nil, err
+	}
+
+	narrative, err := s.DraftNarrative(stats)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao redigir narrativa: %w", err)
+	}
+
+	statsJSON, err := json.Marshal(stats)
+	if err != nil {
+		return nil, err
+	}
+
+	var id int64
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO relatorios_semanais (idoso_id, semana_inicio, semana_fim, estatisticas, narrativa, modelo)
+		VALUES ($1, $2, $3, $4::jsonb, $5, $6)
+		ON CONFLICT (idoso_id, semana_inicio) DO NOTHING
+		RETURNING id
+	`, idosoID, stats.SemanaInicio, stats.SemanaFim, string(statsJSON), narrative, s.cfg.GeminiAnalysisModel).Scan(&id)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to save report: %w", err)
+	}
+
+	return s.GetByWeek(ctx, idosoID, weekStart)
+}
+
+const reportColumns = `id, idoso_id, semana_inicio, semana_fim, estatisticas, narrativa, COALESCE(modelo, ''), enviado_em, criado_em`
+
+func scanReport(row interface{ Scan(...interface{}) error }) (*Report, error) {
+	var r Report
+	var enviado sql.NullTime
+	var stats []byte
+
+	if err := row.Scan(&r.ID, &r.IdosoID, &r.SemanaInicio, &r.SemanaFim, &stats, &r.Narrativa, &r.Modelo, &enviado, &r.CriadoEm); err != nil {
+		return nil, err
+	}
+
+	r.Estatisticas = stats
+	if enviado.Valid {
+		r.EnviadoEm = &enviado.Time
+	}
+	return &r, nil
+}
+
+// GetByWeek busca o relatório de uma semana
+func (s *Service) GetByWeek(ctx context.Context, idosoID int64, weekStart time.Time) (*Report, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+reportColumns+` FROM relatorios_semanais WHERE idoso_id = $1 AND semana_inicio = $2`,
+		idosoID, weekStart)
+	return scanReport(row)
+}
+
+// Get busca um relatório pelo ID
+func (s *Service) Get(ctx context.Context, id int64) (*Report, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT `+reportColumns+` FROM relatorios_semanais WHERE id = $1`, id)
+	return scanReport(row)
+}
+
+// List lista os relatórios mais recentes de um idoso
+func (s *Service) List(ctx context.Context, idosoID int64, limit int) ([]*Report, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT `+reportColumns+` FROM relatorios_semanais
+		WHERE idoso_id = $1 ORDER BY semana_inicio DESC LIMIT $2`, idosoID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reports: %w", err)
+	}
+	defer rows.Close()
+
+	var reports []*Report
+	for rows.Next() {
+		r, err := scanReport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan report: %w", err)
+		}
+		reports = append(reports, r)
+	}
+
+	return reports, rows.Err()
+}
+
+// MarkSent registra o envio do relatório por email
+func (s *Service) MarkSent(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE relatorios_semanais SET enviado_em = NOW() WHERE id = $1`, id)
+	return err
+}
@@ -3,48 +3,49 @@ package scheduler
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/calls"
 	"eva-mind/internal/config"
-	"eva-mind/internal/email"
-	"eva-mind/internal/gemini"
+	"eva-mind/internal/notify"
 	"eva-mind/internal/push"
+	"eva-mind/internal/webhooks"
 )
 
 type Scheduler struct {
-	cfg          *config.Config
-	db           *sql.DB
-	pushService  *push.FirebaseService
-	emailService *email.EmailService
-	stopChan     chan struct{}
+	cfg         *config.Config
+	db          *sql.DB
+	pushService *push.FirebaseService
+	alerts      *alerts.Service
+	escalator   *alerts.Escalator
+	dispatcher  *notify.Dispatcher
+	hooks       *webhooks.Service
+	calls       *calls.Service
+	stopChan    chan struct{}
 }
 
-func NewScheduler(cfg *config.Config, db *sql.DB) (*Scheduler, error) {
-	pushService, err := push.NewFirebaseService(cfg.FirebaseCredentialsPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize Firebase: %w", err)
-	}
-
-	// Inicializar serviço de email
-	var emailService *email.EmailService
-	if cfg.EnableEmailFallback {
-		emailService, err = email.NewEmailService(cfg)
-		if err != nil {
-			log.Printf("⚠️ Email service not configured: %v", err)
-			emailService = nil
-		} else {
-			log.Println("✅ Email service initialized")
-		}
+// NewScheduler cria o scheduler; o FCM e o dispatcher são os mesmos do restante
+// do servidor. Sem FCM não há como tocar as chamadas.
+func NewScheduler(cfg *config.Config, db *sql.DB, pushService *push.FirebaseService, dispatcher *notify.Dispatcher) (*Scheduler, error) {
+	if pushService == nil {
+		return nil, fmt.Errorf("firebase is not initialized")
 	}
 
 	return &Scheduler{
-		cfg:          cfg,
-		db:           db,
-		pushService:  pushService,
-		emailService: emailService,
-		stopChan:     make(chan struct{}),
+		cfg:         cfg,
+		db:          db,
+		pushService: pushService,
+		alerts:      alerts.NewService(cfg, db),
+		hooks:       webhooks.NewService(db),
+		calls:       calls.NewService(db),
+		escalator:   alerts.NewEscalator(cfg, db, dispatcher),
+		dispatcher:  dispatcher,
+		stopChan:    make(chan struct{}),
 	}, nil
 }
 
@@ -80,7 +81,8 @@ func (s *Scheduler) Stop() {
 func (s *Scheduler) checkAndTriggerCalls() {
 	now := time.Now()
 	query := `
-		SELECT a.id, a.idoso_id, a.data_hora_agendada, i.device_token, i.nome
+		SELECT a.id, a.idoso_id, a.data_hora_agendada, i.device_token, i.nome,
+		       COALESCE(i.device_token_valido, true)
 		FROM agendamentos a
 		JOIN idosos i ON i.id = a.idoso_id
 		WHERE a.status = 'agendado'
@@ -108,40 +110,55 @@ func (s *Scheduler) checkAndTriggerCalls() {
 	defer rows.Close()
 
 	found := false
+	ctx := context.Background()
 
 	for rows.Next() {
 		var agendamentoID, idosoID int64
 		var dataHora time.Time
 		var deviceToken sql.NullString
 		var nome string
+		var tokenValido bool
 
-		rows.Scan(&agendamentoID, &idosoID, &dataHora, &deviceToken, &nome)
+		rows.Scan(&agendamentoID, &idosoID, &dataHora, &deviceToken, &nome, &tokenValido)
 
 		if !deviceToken.Valid || deviceToken.String == "" {
 			log.Printf("⚠️  Sem device_token: %s", nome)
 			s.updateStatus(agendamentoID, "falha_sem_token")
 			continue
 		}
+		if !tokenValido {
+			log.Printf("⚠️  Token marcado como inválido, aguardando novo registro do app: %s", nome)
+			s.updateStatus(agendamentoID, "falha_token_invalido")
+			continue
+		}
 
-		sessionID := fmt.Sprintf("call-%d-%d", agendamentoID, time.Now().Unix())
+		// O sessao_id vai no push e o app o devolve no start_call do WebSocket
+		call, err := s.calls.Ring(ctx, agendamentoID, idosoID)
+		if err != nil {
+			log.Printf("❌ Erro ao registrar chamada: %s - %v", nome, err)
+			continue
+		}
 
-		err := s.pushService.SendCallNotification(deviceToken.String, sessionID, nome)
+		err = s.pushService.SendCallNotification(agendamentoID, deviceToken.String, call.SessaoID, nome)
 		if err != nil {
-			if push.IsInvalidTokenError(err) {
+			if errors.Is(err, push.ErrQueued) {
+				// A fila reenvia e passa o agendamento para em_andamento ou falha_envio
+				log.Printf("📥 FCM indisponível, push da chamada na fila: %s (%v)", nome, err)
+				s.updateStatus(agendamentoID, "aguardando_push")
+				continue
+			}
+
+			if push.IsInvalidTokenError(errors.Unwrap(err)) {
+				// O token já foi marcado como inválido pelo push service
 				log.Printf("⚠️  Token inválido para: %s (%v)", nome, err)
 				s.updateStatus(agendamentoID, "falha_token_invalido")
-
-				// Marcar que o token precisa ser atualizado
-				_, _ = s.db.Exec(`
-					UPDATE idosos 
-					SET device_token_valido = false, 
-					    device_token_atualizado_em = NOW()
-					WHERE id = $1
-				`, idosoID)
 			} else {
 				log.Printf("❌ Erro ao enviar push: %s - %v", nome, err)
 				s.updateStatus(agendamentoID, "falha_envio")
 			}
+			if err := s.calls.Fail(ctx, idosoID, call.SessaoID, "push não enviado"); err != nil {
+				log.Printf("⚠️ Erro ao encerrar chamada %s: %v", call.SessaoID, err)
+			}
 			continue
 		}
 
@@ -155,44 +172,38 @@ func (s *Scheduler) checkAndTriggerCalls() {
 	}
 }
 
-// checkMissedCalls verifica chamadas que ficaram "penduradas" (tocaram mas ninguém atendeu)
+// checkMissedCalls processa as chamadas que tocaram 45s sem atendimento ou que
+// o idoso recusou no app: histórico, alerta, webhook, timeline e cuidadores
 func (s *Scheduler) checkMissedCalls() {
-	query := `
-		SELECT a.id, a.idoso_id, i.nome, c.device_token, c.telefone, c.email
-		FROM agendamentos a
-		JOIN idosos i ON i.id = a.idoso_id
-		LEFT JOIN cuidadores c ON c.idoso_id = i.id AND c.ativo = true AND c.prioridade = 1
-		WHERE a.status = 'em_andamento' 
-		  AND a.data_hora_agendada < (NOW() - INTERVAL '45 seconds')
-	`
+	ctx := context.Background()
 
-	rows, err := s.db.Query(query)
+	unanswered, err := s.calls.Unanswered(ctx)
 	if err != nil {
 		log.Printf("❌ Erro ao verificar chamadas perdidas: %v", err)
 		return
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var agendamentoID, idosoID int64
-		var nomeIdoso string
-		var tokenCuidador, phoneCuidador, emailCuidador sql.NullString
+	for _, call := range unanswered {
+		agendamentoID, idosoID, nomeIdoso := call.AgendamentoID, call.IdosoID, call.NomeIdoso
+		declined := call.Status == calls.StatusRecusada
 
-		if err := rows.Scan(&agendamentoID, &idosoID, &nomeIdoso, &tokenCuidador, &phoneCuidador, &emailCuidador); err != nil {
-			log.Printf("❌ Erro ao fazer scan de chamada perdida: %v", err)
-			continue
+		statusAgendamento, motivo, titulo, subtipo := "nao_atendido",
+			"Chamada não atendida pelo idoso após 45 segundos", "Chamada Não Atendida", "nao_atendida"
+		if declined {
+			statusAgendamento, motivo, titulo, subtipo = "recusado",
+				"Chamada recusada pelo idoso no aplicativo", "Chamada Recusada", "recusada"
 		}
 
-		log.Printf("⚠️ CHAMADA PERDIDA detectada para Idoso: %s (ID: %d)", nomeIdoso, idosoID)
+		log.Printf("⚠️ CHAMADA %s para Idoso: %s (ID: %d, sessão %s)", strings.ToUpper(call.Status), nomeIdoso, idosoID, call.SessaoID)
 
 		// 1. Atualizar status do agendamento
 		_, errUpdate := s.db.Exec(`
 			UPDATE agendamentos 
-			SET status = 'nao_atendido', 
+			SET status = $2, 
 			    ultima_tentativa = NOW(),
 			    tentativas_realizadas = tentativas_realizadas + 1
 			WHERE id = $1
-		`, agendamentoID)
+		`, agendamentoID, statusAgendamento)
 
 		if errUpdate != nil {
 			log.Printf("❌ Erro ao atualizar agendamento: %v", errUpdate)
@@ -212,11 +223,12 @@ func (s *Scheduler) checkMissedCalls() {
 				motivo_falha,
 				transcricao_completa,
 				criado_em
-			) VALUES ($1, $2, NOW() - INTERVAL '45 seconds', NOW(), 45, false, $3, $4, NOW())
+			)
+			SELECT $1, $2, c.tocando_em, NOW(), EXTRACT(EPOCH FROM NOW() - c.tocando_em)::int, false, $4, $5, NOW()
+			FROM chamadas c WHERE c.id = $3
 			RETURNING id
-		`, agendamentoID, idosoID,
-			"Chamada não atendida pelo idoso após 45 segundos",
-			fmt.Sprintf("Push notification enviado mas não houve resposta do dispositivo. Idoso: %s", nomeIdoso),
+		`, agendamentoID, idosoID, call.ID, motivo,
+			fmt.Sprintf("Push notification enviado (sessão %s) mas a chamada terminou como %s. Idoso: %s", call.SessaoID, call.Status, nomeIdoso),
 		).Scan(&historicoID)
 
 		if errHistorico != nil {
@@ -226,31 +238,45 @@ func (s *Scheduler) checkMissedCalls() {
 		}
 
 		// 3. Criar alerta no sistema
-		var alertID int64
-		errAlerta := s.db.QueryRow(`
-			INSERT INTO alertas (
-				idoso_id,
-				ligacao_id,
-				tipo,
-				severidade,
-				mensagem,
-				destinatarios,
-				enviado,
-				visualizado,
-				data_envio,
-				criado_em
-			) VALUES ($1, $2, 'nao_atende_telefone', 'aviso', $3, $4, false, false, NOW(), NOW())
-			RETURNING id
-		`, idosoID, historicoID,
-			fmt.Sprintf("%s não atendeu a chamada programada da EVA às %s",
+		alert := &alerts.Alert{
+			IdosoID:    idosoID,
+			NomeIdoso:  nomeIdoso,
+			Tipo:       "nao_atende_telefone",
+			Severidade: "aviso",
+			Mensagem: fmt.Sprintf("%s não atendeu a chamada programada da EVA às %s",
 				nomeIdoso, time.Now().Format("15:04")),
-			`["cuidador"]`).Scan(&alertID)
+			Destinatarios: `["cuidador"]`,
+		}
+		if declined {
+			alert.Mensagem = fmt.Sprintf("%s recusou a chamada programada da EVA às %s",
+				nomeIdoso, time.Now().Format("15:04"))
+		}
+		if historicoID != 0 {
+			alert.LigacaoID = &historicoID
+		}
 
+		// Tentativas repetidas dentro da janela de deduplicação viram ocorrências do mesmo alerta
+		shouldNotify, errAlerta := s.alerts.Raise(ctx, alert)
 		if errAlerta != nil {
 			log.Printf("⚠️ Erro ao criar alerta: %v", errAlerta)
 		}
 
-		// 4. Registrar na timeline
+		// 4. Avisar as integrações da entidade
+		dados := map[string]interface{}{
+			"agendamento_id": agendamentoID,
+			"sessao_id":      call.SessaoID,
+			"resultado":      call.Status,
+			"nome_idoso":     nomeIdoso,
+			"alerta_id":      alert.ID,
+		}
+		if historicoID != 0 {
+			dados["historico_id"] = historicoID
+		}
+		if err := s.hooks.Publish(ctx, idosoID, webhooks.EventoChamadaPerdida, dados); err != nil {
+			log.Printf("⚠️ Erro ao publicar webhook de chamada perdida: %v", err)
+		}
+
+		// 5. Registrar na timeline
 		_, errTimeline := s.db.Exec(`
 			INSERT INTO timeline (
 				idoso_id,
@@ -260,56 +286,118 @@ func (s *Scheduler) checkMissedCalls() {
 				descricao,
 				data,
 				criado_em
-			) VALUES ($1, 'ligacao', 'nao_atendida', 'Chamada Não Atendida', $2, NOW(), NOW())
-		`, idosoID,
-			fmt.Sprintf("EVA tentou contato com %s mas a chamada não foi atendida.", nomeIdoso))
+			) VALUES ($1, 'ligacao', $2, $3, $4, NOW(), NOW())
+		`, idosoID, subtipo, titulo,
+			fmt.Sprintf("EVA tentou contato com %s mas a chamada foi %s.", nomeIdoso, call.Status))
 
 		if errTimeline != nil {
 			log.Printf("⚠️ Erro ao registrar timeline: %v", errTimeline)
 		}
 
-		// 5. Notificar o cuidador via push notification
-		if tokenCuidador.Valid && tokenCuidador.String != "" {
-			errPush := s.pushService.SendMissedCallAlert(tokenCuidador.String, nomeIdoso)
-			if errPush != nil {
-				log.Printf("❌ Erro ao enviar push para cuidador: %v", errPush)
-
-				// Marcar alerta para envio por outros meios
-				_, _ = s.db.Exec(`
-					UPDATE alertas 
-					SET necessita_escalamento = true,
-					    tempo_escalamento = NOW() + INTERVAL '5 minutes'
-					WHERE id = $1
-				`, alertID)
-			} else {
-				log.Printf("📵 Cuidador notificado sobre chamada perdida de %s", nomeIdoso)
-
-				// Marcar alerta como enviado
-				_, _ = s.db.Exec(`
-					UPDATE alertas SET enviado = true WHERE id = $1
-				`, alertID)
-			}
+		// 6. Notificar o cuidador principal pelo canal preferido (push, SMS, email...)
+		if shouldNotify {
+			s.notifyMissedCall(ctx, alert)
 		} else {
-			log.Printf("⚠️ Sem token de cuidador para notificar sobre %s", nomeIdoso)
+			log.Printf("🔁 Chamada %s de %s agrupada no alerta %d, sem nova notificação", call.Status, nomeIdoso, alert.ID)
+		}
 
-			// TODO: Tentar outros meios (SMS, Email)
-			if phoneCuidador.Valid && phoneCuidador.String != "" {
-				log.Printf("📞 TODO: Enviar SMS para %s", phoneCuidador.String)
-			}
-			if emailCuidador.Valid && emailCuidador.String != "" {
-				log.Printf("📧 TODO: Enviar email para %s", emailCuidador.String)
-			}
+		log.Printf("✅ Chamada %s processada completamente para %s", call.Status, nomeIdoso)
+	}
+}
+
+// notifyMissedCall avisa os cuidadores de prioridade 1 com fallback entre canais.
+// Se ninguém for alcançado, o alerta entra no escalonamento imediatamente.
+func (s *Scheduler) notifyMissedCall(ctx context.Context, alert *alerts.Alert) {
+	caregivers, err := s.alerts.Caregivers(ctx, alert.IdosoID, 1)
+	if err != nil {
+		log.Printf("❌ Erro ao buscar cuidador principal: %v", err)
+		return
+	}
+	if len(caregivers) == 0 {
+		log.Printf("⚠️ Sem cuidador principal para notificar sobre %s", alert.NomeIdoso)
+	}
+
+	var targets []alerts.Recipient
+	for _, cg := range caregivers {
+		if s.throttled(ctx, alert.ID, cg) {
+			log.Printf("🔕 Limite de notificações do %s atingido", cg.Label())
+			continue
 		}
+		targets = append(targets, cg)
+	}
 
-		log.Printf("✅ Chamada perdida processada completamente para %s", nomeIdoso)
+	notified, deferred := 0, 0
+	for _, res := range s.dispatcher.DispatchAll(ctx, alert, targets) {
+		switch {
+		case errors.Is(res.Err, notify.ErrOptedOut):
+			log.Printf("🔕 %s não recebe avisos de chamada perdida", res.Recipient.Label())
+		case res.Err != nil:
+			log.Printf("❌ Erro ao notificar %s: %v", res.Recipient.Label(), res.Err)
+		case res.Canal == notify.CanalResumo:
+			deferred++
+		default:
+			notified++
+			log.Printf("📵 Cuidador notificado via %s sobre chamada perdida de %s", res.Canal, alert.NomeIdoso)
+		}
 	}
+
+	if alert.ID == 0 {
+		return
+	}
+
+	// Adiado pelo horário de silêncio: o resumo entrega depois, sem escalonar
+	if notified == 0 && deferred > 0 {
+		log.Printf("🌙 Chamada perdida de %s adiada para o resumo", alert.NomeIdoso)
+		return
+	}
+
+	if notified == 0 {
+		if err := s.alerts.ScheduleEscalation(ctx, alert.ID, time.Now()); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+		return
+	}
+
+	if alert.Status == alerts.StatusCriado {
+		if err := s.alerts.Transition(ctx, alert.ID, alerts.StatusDespachado, alerts.Event{
+			Sucesso:  true,
+			Detalhes: fmt.Sprintf("%d cuidador(es) notificado(s)", notified),
+		}); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+}
+
+// throttled verifica o limite horário do cuidador e registra a supressão no alerta
+func (s *Scheduler) throttled(ctx context.Context, alertID int64, r alerts.Recipient) bool {
+	if alertID == 0 || r.CuidadorID == 0 {
+		return false
+	}
+
+	throttled, err := s.alerts.Throttled(ctx, r, "aviso")
+	if err != nil || !throttled {
+		return false
+	}
+
+	if err := s.alerts.RecordSuppressed(ctx, alertID, r, "push"); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+	return true
 }
 
-// checkUnacknowledgedAlerts verifica alertas críticos não visualizados
+// checkUnacknowledgedAlerts executa as políticas de escalonamento e expira alertas antigos
 func (s *Scheduler) checkUnacknowledgedAlerts() {
-	if err := gemini.CheckUnacknowledgedAlerts(s.db, s.pushService); err != nil {
+	ctx := context.Background()
+
+	if err := s.escalator.Run(ctx); err != nil {
 		log.Printf("❌ Erro ao verificar alertas não visualizados: %v", err)
 	}
+
+	if expired, err := s.alerts.ExpireStale(ctx, alerts.DefaultExpiry); err != nil {
+		log.Printf("⚠️ %v", err)
+	} else if expired > 0 {
+		log.Printf("⌛ %d alerta(s) expirado(s) sem confirmação", expired)
+	}
 }
 
 func (s *Scheduler) updateStatus(id int64, status string) {
@@ -12,9 +12,10 @@ import (
 	"sync"
 	"time"
 
+	"eva-mind/internal/analysis"
 	"eva-mind/internal/config"
 	"eva-mind/internal/gemini"
-	"eva-mind/internal/push"
+	"eva-mind/internal/notify"
 
 	"github.com/gorilla/websocket"
 )
@@ -40,18 +41,22 @@ type WebSocketSession struct {
 }
 
 type SignalingServer struct {
-	cfg         *config.Config
-	db          *sql.DB
-	pushService *push.FirebaseService
-	sessions    sync.Map
-	clients     sync.Map
+	cfg        *config.Config
+	db         *sql.DB
+	dispatcher *notify.Dispatcher
+	sessions   sync.Map
+	clients    sync.Map
+
+	analysisQueue *analysis.Queue
 }
 
-func NewSignalingServer(cfg *config.Config, db *sql.DB, pushService *push.FirebaseService) *SignalingServer {
+func NewSignalingServer(cfg *config.Config, db *sql.DB, dispatcher *notify.Dispatcher) *SignalingServer {
 	server := &SignalingServer{
-		cfg:         cfg,
-		db:          db,
-		pushService: pushService,
+		cfg:        cfg,
+		db:         db,
+		dispatcher: dispatcher,
+
+		analysisQueue: analysis.NewQueue(db, cfg.AnalysisMaxAttempts),
 	}
 	go server.cleanupDeadSessions()
 	return server
@@ -277,7 +282,7 @@ func (s *SignalingServer) executeTool(session *WebSocketSession, fnCall map[stri
 		reason, _ := args["reason"].(string)
 		log.Printf("🚨 Alerta enviado: %s", reason)
 
-		if err := gemini.AlertFamily(s.db, s.pushService, session.IdosoID, reason); err != nil {
+		if err := gemini.AlertFamily(s.cfg, s.db, s.dispatcher, session.IdosoID, reason); err != nil {
 			log.Printf("❌ Erro ao enviar alerta")
 		}
 
@@ -285,7 +290,7 @@ func (s *SignalingServer) executeTool(session *WebSocketSession, fnCall map[stri
 		medication, _ := args["medication_name"].(string)
 		log.Printf("💊 Medicamento confirmado: %s", medication)
 
-		if err := gemini.ConfirmMedication(s.db, s.pushService, session.IdosoID, medication); err != nil {
+		if err := gemini.ConfirmMedication(s.cfg, s.db, s.dispatcher, session.IdosoID, medication); err != nil {
 			log.Printf("❌ Erro ao confirmar medicamento")
 		}
 	}
@@ -395,153 +400,24 @@ func (s *SignalingServer) cleanupSession(sessionID string) {
 		session.GeminiClient.Close()
 	}
 
-	// 🧠 ANALISAR CONVERSA AUTOMATICAMENTE
-	go s.analyzeAndSaveConversation(session.IdosoID)
+	// 🧠 ENFILEIRAR ANÁLISE DA CONVERSA
+	go s.enqueueAnalysis(session.IdosoID)
 }
 
-// analyzeAndSaveConversation analisa a conversa usando dados já no banco
-func (s *SignalingServer) analyzeAndSaveConversation(idosoID int64) {
-	log.Printf("🔍 [ANÁLISE] Iniciando análise para idoso %d", idosoID)
-
-	// Buscar última transcrição sem fim_chamada
-	query := `
-		SELECT id, transcricao_completa
-		FROM historico_ligacoes
-		WHERE idoso_id = $1 
-		  AND fim_chamada IS NULL
-		  AND transcricao_completa IS NOT NULL
-		  AND LENGTH(transcricao_completa) > 50
-		ORDER BY inicio_chamada DESC
-		LIMIT 1
-	`
-
-	var historyID int64
-	var transcript string
-	err := s.db.QueryRow(query, idosoID).Scan(&historyID, &transcript)
-	if err == sql.ErrNoRows {
-		log.Printf("⚠️ [ANÁLISE] Nenhuma transcrição encontrada para idoso %d", idosoID)
-		return
-	}
-	if err != nil {
-		log.Printf("❌ [ANÁLISE] Erro ao buscar transcrição: %v", err)
-		return
-	}
-
-	log.Printf("📝 [ANÁLISE] Transcrição: %d caracteres", len(transcript))
-
-	// Mostrar prévia
-	preview := transcript
-	if len(preview) > 200 {
-		preview = preview[:200] + "..."
-	}
-	log.Printf("📄 [ANÁLISE] Prévia:\n%s", preview)
-
-	log.Printf("🧠 [ANÁLISE] Enviando para Gemini API REST...")
-
-	// Chamar análise do Gemini (REST API)
-	analysis, err := gemini.AnalyzeConversation(s.cfg, transcript)
-	if err != nil {
-		log.Printf("❌ [ANÁLISE] Erro no Gemini: %v", err)
-		return
-	}
-
-	log.Printf("✅ [ANÁLISE] Análise recebida!")
-	log.Printf("   📊 Urgência: %s", analysis.UrgencyLevel)
-	log.Printf("   😊 Humor: %s", analysis.MoodState)
-	if analysis.ReportedPain {
-		log.Printf("   🩺 Dor: %s (intensidade %d/10)", analysis.PainLocation, analysis.PainIntensity)
-	}
-	if analysis.EmergencySymptoms {
-		log.Printf("   🚨 EMERGÊNCIA: %s", analysis.EmergencyType)
-	}
-
-	// Converter para JSON
-	analysisJSON, err := json.Marshal(analysis)
+// enqueueAnalysis encerra a última ligação aberta do idoso e a coloca na fila
+// de análise. A análise em si é feita pelo AnalysisWorker, com retentativas.
+func (s *SignalingServer) enqueueAnalysis(idosoID int64) {
+	historyID, err := s.analysisQueue.EnqueueClosed(context.Background(), idosoID)
 	if err != nil {
-		log.Printf("❌ [ANÁLISE] Erro ao serializar: %v", err)
+		log.Printf("❌ [ANÁLISE] %v", err)
 		return
 	}
-
-	log.Printf("💾 [ANÁLISE] Salvando no banco...")
-
-	// Atualizar banco com análise NOS CAMPOS CORRETOS
-	updateQuery := `
-		UPDATE historico_ligacoes 
-		SET 
-			fim_chamada = CURRENT_TIMESTAMP,
-			analise_gemini = $2::jsonb,
-			urgencia = $3,
-			sentimento = $4,
-			transcricao_resumo = $5
-		WHERE id = $1
-	`
-
-	result, err := s.db.Exec(
-		updateQuery,
-		historyID,
-		string(analysisJSON),  // analise_gemini (JSON completo)
-		analysis.UrgencyLevel, // urgencia
-		analysis.MoodState,    // sentimento
-		analysis.Summary,      // transcricao_resumo
-	)
-
-	if err != nil {
-		log.Printf("❌ [ANÁLISE] Erro ao salvar: %v", err)
+	if historyID == 0 {
+		log.Printf("⚠️ [ANÁLISE] Nenhuma transcrição encontrada para idoso %d", idosoID)
 		return
 	}
 
-	rows, _ := result.RowsAffected()
-	log.Printf("✅ [ANÁLISE] Salvo com sucesso! (%d linha atualizada)", rows)
-
-	// 🚨 ALERTA CRÍTICO OU ALTO
-	if analysis.UrgencyLevel == "CRITICO" || analysis.UrgencyLevel == "ALTO" {
-		log.Printf("🚨 ALERTA DE URGÊNCIA: %s", analysis.UrgencyLevel)
-		log.Printf("   Motivo: %s", analysis.RecommendedAction)
-		log.Printf("   Preocupações: %v", analysis.KeyConcerns)
-
-		alertMsg := fmt.Sprintf(
-			"URGÊNCIA %s: %s. %s",
-			analysis.UrgencyLevel,
-			strings.Join(analysis.KeyConcerns, ", "),
-			analysis.RecommendedAction,
-		)
-
-		err := gemini.AlertFamily(s.db, s.pushService, idosoID, alertMsg)
-		if err != nil {
-			log.Printf("❌ [ANÁLISE] Erro ao alertar família: %v", err)
-		} else {
-			log.Printf("✅ [ANÁLISE] Família alertada com sucesso!")
-		}
-	}
-}
-
-// getSentimentIntensity converte análise em escala 1-10
-func getSentimentIntensity(analysis *gemini.ConversationAnalysis) int {
-	intensity := 5 // neutro
-
-	if analysis.EmergencySymptoms {
-		return 10
-	}
-
-	if analysis.Depression {
-		intensity = 8
-	} else if analysis.MoodState == "triste" {
-		intensity = 7
-	} else if analysis.MoodState == "ansioso" {
-		intensity = 6
-	} else if analysis.MoodState == "feliz" {
-		intensity = 3
-	}
-
-	if analysis.ReportedPain {
-		intensity += analysis.PainIntensity / 3
-	}
-
-	if intensity > 10 {
-		intensity = 10
-	}
-
-	return intensity
+	log.Printf("📥 [ANÁLISE] Histórico %d enfileirado para análise", historyID)
 }
 
 func (s *SignalingServer) cleanupDeadSessions() {
@@ -0,0 +1,173 @@
+package sms
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FakeUndeliveredSuffix números terminados assim simulam SMS não entregue (código 30003)
+const FakeUndeliveredSuffix = "9999"
+
+// FakeMessage mensagem recebida pelo servidor falso
+type FakeMessage struct {
+	SID            string    `json:"sid"`
+	To             string    `json:"to"`
+	From           string    `json:"from"`
+	Body           string    `json:"body"`
+	Status         string    `json:"status"`
+	StatusCallback string    `json:"status_callback,omitempty"`
+	CriadoEm       time.Time `json:"criado_em"`
+}
+
+// FakeServer imita a API de mensagens do Twilio para testes sem rede:
+// aceita POST /2010-04-01/Accounts/{sid}/Messages.json, responde como o Twilio
+// e, depois de CallbackDelay, chama o StatusCallback assinado com o AuthToken.
+// GET /messages lista o que foi "enviado".
+type FakeServer struct {
+	AccountSID    string
+	AuthToken     string
+	CallbackDelay time.Duration
+
+	mu       sync.Mutex
+	messages []FakeMessage
+	client   *http.Client
+}
+
+// NewFakeServer cria o servidor falso com as mesmas credenciais da configuração
+func NewFakeServer(accountSID, authToken string) *FakeServer {
+	return &FakeServer{
+		AccountSID:    accountSID,
+		AuthToken:     authToken,
+		CallbackDelay: 2 * time.Second,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Messages mensagens recebidas até agora
+func (f *FakeServer) Messages() []FakeMessage {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]FakeMessage(nil), f.messages...)
+}
+
+func (f *FakeServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet && r.URL.Path == "/messages" {
+		writeFakeJSON(w, http.StatusOK, f.Messages())
+		return
+	}
+
+	if r.Method != http.MethodPost || r.URL.Path != "/2010-04-01/Accounts/"+f.AccountSID+"/Messages.json" {
+		writeFakeJSON(w, http.StatusNotFound, APIError{Status: 404, Code: 20404, Message: "The requested resource was not found"})
+		return
+	}
+
+	if user, pass, ok := r.BasicAuth(); !ok || user != f.AccountSID || pass != f.AuthToken {
+		writeFakeJSON(w, http.StatusUnauthorized, APIError{Status: 401, Code: 20003, Message: "Authenticate"})
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		writeFakeJSON(w, http.StatusBadRequest, APIError{Status: 400, Code: 21602, Message: err.Error()})
+		return
+	}
+
+	to := r.PostForm.Get("To")
+	if _, err := NormalizeE164(to); err != nil || !strings.HasPrefix(to, "+") {
+		writeFakeJSON(w, http.StatusBadRequest, APIError{Status: 400, Code: 21211, Message: "The 'To' number " + to + " is not a valid phone number."})
+		return
+	}
+	if r.PostForm.Get("Body") == "" {
+		writeFakeJSON(w, http.StatusBadRequest, APIError{Status: 400, Code: 21602, Message: "Message body is required."})
+		return
+	}
+
+	msg := FakeMessage{
+		SID:            fakeSID(),
+		To:             to,
+		From:           r.PostForm.Get("From"),
+		Body:           r.PostForm.Get("Body"),
+		Status:         "queued",
+		StatusCallback: r.PostForm.Get("StatusCallback"),
+		CriadoEm:       time.Now(),
+	}
+
+	f.mu.Lock()
+	f.messages = append(f.messages, msg)
+	f.mu.Unlock()
+
+	log.Printf("📨 [fake] SMS %s para %s: %s", msg.SID, msg.To, msg.Body)
+
+	if msg.StatusCallback != "" {
+		go f.deliver(msg)
+	}
+
+	writeFakeJSON(w, http.StatusCreated, Message{SID: msg.SID, Status: msg.Status, To: msg.To})
+}
+
+// deliver simula sent → delivered (ou undelivered) chamando o callback
+func (f *FakeServer) deliver(msg FakeMessage) {
+	final, code := "delivered", ""
+	if strings.HasSuffix(msg.To, FakeUndeliveredSuffix) {
+		final, code = "undelivered", "30003"
+	}
+
+	for _, status := range []string{"sent", final} {
+		time.Sleep(f.CallbackDelay)
+		f.setStatus(msg.SID, status)
+
+		params := url.Values{}
+		params.Set("AccountSid", f.AccountSID)
+		params.Set("MessageSid", msg.SID)
+		params.Set("MessageStatus", status)
+		params.Set("To", msg.To)
+		params.Set("From", msg.From)
+		if status == final && code != "" {
+			params.Set("ErrorCode", code)
+		}
+
+		req, err := http.NewRequest(http.MethodPost, msg.StatusCallback, strings.NewReader(params.Encode()))
+		if err != nil {
+			log.Printf("❌ [fake] callback inválido: %v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("X-Twilio-Signature", Signature(f.AuthToken, msg.StatusCallback, params))
+
+		resp, err := f.client.Do(req)
+		if err != nil {
+			log.Printf("❌ [fake] callback %s falhou: %v", msg.SID, err)
+			return
+		}
+		resp.Body.Close()
+		log.Printf("📨 [fake] SMS %s → %s (callback %d)", msg.SID, status, resp.StatusCode)
+	}
+}
+
+func (f *FakeServer) setStatus(sid, status string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := range f.messages {
+		if f.messages[i].SID == sid {
+			f.messages[i].Status = status
+		}
+	}
+}
+
+func fakeSID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "SM" + hex.EncodeToString(b)
+}
+
+func writeFakeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,68 @@
+package sms
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// NormalizeE164 converte os telefones cadastrados em cuidadores (com ou sem DDI,
+// zero de tronco, código de operadora, máscara) para E.164: +5511987654321
+func NormalizeE164(phone string) (string, error) {
+	phone = strings.TrimSpace(phone)
+	international := strings.HasPrefix(phone, "+")
+
+	digits := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) {
+			return r
+		}
+		return -1
+	}, phone)
+
+	if !international && strings.HasPrefix(digits, "00") {
+		digits = digits[2:]
+		international = true
+	}
+
+	if !international {
+		switch {
+		// 0 + operadora + DDD + número (ex.: 0 15 11 98765-4321)
+		case strings.HasPrefix(digits, "0") && (len(digits) == 13 || len(digits) == 14):
+			digits = "55" + digits[3:]
+		// 0 + DDD + número (ex.: 011 98765-4321)
+		case strings.HasPrefix(digits, "0") && (len(digits) == 11 || len(digits) == 12):
+			digits = "55" + digits[1:]
+		// Já com DDI 55 (55 + DDD + número)
+		case strings.HasPrefix(digits, "55") && (len(digits) == 12 || len(digits) == 13):
+		// DDD + número. Com 10 ou 11 dígitos "55..." só pode ser o DDD 55 (RS):
+		// DDI + DDD deixaria o número curto demais
+		case len(digits) == 10 || len(digits) == 11:
+			if strings.HasPrefix(digits, "55") && !validSubscriber(digits[2:]) {
+				return "", fmt.Errorf("telefone inválido: %s", phone)
+			}
+			digits = "55" + digits
+		case len(digits) == 8 || len(digits) == 9:
+			return "", fmt.Errorf("telefone sem DDD: %s", phone)
+		default:
+			return "", fmt.Errorf("telefone inválido: %s", phone)
+		}
+	}
+
+	if len(digits) < 8 || len(digits) > 15 || digits[0] == '0' {
+		return "", fmt.Errorf("telefone inválido: %s", phone)
+	}
+
+	return "+" + digits, nil
+}
+
+// validSubscriber número sem DDD: celular (9 + 8 dígitos) ou fixo (8 dígitos
+// iniciados em 2 a 5)
+func validSubscriber(n string) bool {
+	switch len(n) {
+	case 9:
+		return n[0] == '9'
+	case 8:
+		return n[0] >= '2' && n[0] <= '5'
+	}
+	return false
+}
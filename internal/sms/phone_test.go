@@ -0,0 +1,43 @@
+package sms
+
+import "testing"
+
+func TestNormalizeE164(t *testing.T) {
+	tests := []struct {
+		name    string
+		phone   string
+		want    string
+		wantErr bool
+	}{
+		{"internacional com máscara", "+55 (11) 98765-4321", "+5511987654321", false},
+		{"internacional estrangeiro", "+1 415 555 0100", "+14155550100", false},
+		{"prefixo 00", "00 55 11 98765-4321", "+5511987654321", false},
+		{"DDI sem mais", "5511987654321", "+5511987654321", false},
+		{"DDI com fixo", "551134567890", "+551134567890", false},
+		{"DDD e celular", "(11) 98765-4321", "+5511987654321", false},
+		{"DDD e fixo", "11 3456-7890", "+551134567890", false},
+		{"zero de tronco", "011 98765-4321", "+5511987654321", false},
+		{"zero de tronco com fixo", "011 3456-7890", "+551134567890", false},
+		{"código de operadora", "0 15 11 98765-4321", "+5511987654321", false},
+		{"código de operadora com fixo", "0 21 11 3456-7890", "+551134567890", false},
+		{"DDD 55 e celular", "55 98765-4321", "+5555987654321", false},
+		{"DDD 55 e fixo", "55 3222-1234", "+555532221234", false},
+		{"DDD 55 com DDI", "55 55 98765-4321", "+5555987654321", false},
+		{"11 dígitos com 55 que não é celular", "55113456789", "", true},
+		{"sem DDD", "98765-4321", "", true},
+		{"curto demais", "12345", "", true},
+		{"vazio", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeE164(tt.phone)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeE164(%q) error = %v, wantErr %v", tt.phone, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeE164(%q) = %q, want %q", tt.phone, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package sms
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/config"
+)
+
+// StatusPath rota que recebe os callbacks de entrega do Twilio
+const StatusPath = "/api/sms/status"
+
+// Service envia SMS de alertas e acompanha a entrega pelos callbacks
+type Service struct {
+	client      *Client
+	db          *sql.DB
+	alerts      *alerts.Service
+	callbackURL string
+}
+
+// NewService cria o serviço de SMS. Sem SERVICE_DOMAIN não há callbacks de entrega.
+func NewService(cfg *config.Config, db *sql.DB) (*Service, error) {
+	client, err := NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Service{
+		client: client,
+		db:     db,
+		alerts: alerts.NewService(cfg, db),
+	}
+	if cfg.ServiceDomain != "" {
+		s.callbackURL = cfg.PublicURL(StatusPath)
+	}
+
+	return s, nil
+}
+
+// SendSMS normaliza o telefone, envia a mensagem e guarda o SID para o callback
+func (s *Service) SendSMS(ctx context.Context, alertID int64, to, body string) error {
+	phone, err := NormalizeE164(to)
+	if err != nil {
+		return err
+	}
+
+	msg, err := s.client.Send(ctx, phone, body, s.callbackURL)
+	if err != nil {
+		return err
+	}
+
+	var alerta interface{}
+	if alertID != 0 {
+		alerta = alertID
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO mensagens_sms (sid, alerta_id, telefone, corpo, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (sid) DO NOTHING
+	`, msg.SID, alerta, phone, body, msg.Status)
+	if err != nil {
+		// A mensagem já saiu; só perdemos o acompanhamento da entrega
+		log.Printf("⚠️ Erro ao registrar SMS %s: %v", msg.SID, err)
+	}
+
+	log.Printf("📱 SMS %s enviado para %s (%s)", msg.SID, phone, msg.Status)
+	return nil
+}
+
+// ValidSignature confere a assinatura de um callback do Twilio
+func (s *Service) ValidSignature(params url.Values, signature string) bool {
+	return s.callbackURL != "" && s.client.ValidSignature(s.callbackURL, params, signature)
+}
+
+// HandleStatus aplica um callback de entrega. delivered marca o alerta como
+// entregue; undelivered/failed registra a falha e antecipa o escalonamento.
+func (s *Service) HandleStatus(ctx context.Context, sid, status, errorCode string) error {
+	var alertID sql.NullInt64
+	var phone string
+
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE mensagens_sms
+		SET status = $2, erro_codigo = NULLIF($3, ''), atualizado_em = NOW()
+		WHERE sid = $1
+		RETURNING alerta_id, telefone
+	`, sid, status, errorCode).Scan(&alertID, &phone)
+	if err == sql.ErrNoRows {
+		log.Printf("⚠️ Callback de SMS desconhecido: %s", sid)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update SMS %s: %w", sid, err)
+	}
+
+	if !alertID.Valid {
+		return nil
+	}
+
+	switch status {
+	case "delivered":
+		err := s.alerts.Transition(ctx, alertID.Int64, alerts.StatusEntregue, alerts.Event{
+			Canal:        alerts.CanalSMS,
+			Destinatario: phone,
+			Sucesso:      true,
+			Detalhes:     "SMS " + sid + " entregue",
+		})
+		var te *alerts.TransitionError
+		if errors.As(err, &te) {
+			// Já entregue por outro canal, reconhecido ou encerrado
+			return nil
+		}
+		return err
+
+	case "undelivered", "failed":
+		log.Printf("❌ SMS %s não entregue para %s (código %s)", sid, phone, errorCode)
+
+		if err := s.alerts.RecordAttempt(ctx, alertID.Int64, alerts.Event{
+			Canal:        alerts.CanalSMS,
+			Destinatario: phone,
+			Sucesso:      false,
+			Erro:         fmt.Sprintf("SMS %s não entregue (%s, código %s)", sid, status, errorCode),
+		}); err != nil {
+			return err
+		}
+
+		alert, err := s.alerts.Get(ctx, alertID.Int64)
+		if err != nil {
+			return err
+		}
+		if alerts.IsOpen(alert.Status) {
+			return s.alerts.ScheduleEscalation(ctx, alert.ID, time.Now())
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package sms
+
+import (
+	"bytes"
+	"text/template"
+
+	"eva-mind/internal/alerts"
+)
+
+// MaxLength limite do texto (dois segmentos UCS-2, já que o português tem acentos)
+const MaxLength = 134
+
+var templates = template.Must(template.New("sms").Parse(`
+{{define "nao_atende_telefone"}}EVA: {{.NomeIdoso}} não atendeu a ligação agendada. Por favor, entre em contato.{{end}}
+{{define "critica"}}EVA URGENTE: {{.NomeIdoso}} precisa de ajuda agora. {{.Mensagem}}{{end}}
+{{define "alta"}}EVA: {{.NomeIdoso}} precisa de atenção. {{.Mensagem}}{{end}}
+{{define "padrao"}}EVA: aviso sobre {{.NomeIdoso}}. {{.Mensagem}}{{end}}
+{{define "repetido"}} ({{.Ocorrencias}}x){{end}}
+`))
+
+// Text renderiza o SMS do alerta conforme o tipo e a severidade
+func Text(alert *alerts.Alert) string {
+	name := "padrao"
+	switch {
+	case alert.Tipo == "nao_atende_telefone":
+		name = alert.Tipo
+	case alert.Severidade == "critica" || alert.Severidade == "alta":
+		name = alert.Severidade
+	}
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, alert); err != nil {
+		return "EVA: " + alert.Mensagem
+	}
+
+	var suffix bytes.Buffer
+	if alert.Ocorrencias > 1 {
+		templates.ExecuteTemplate(&suffix, "repetido", alert)
+	}
+
+	return truncate(buf.String(), MaxLength-len([]rune(suffix.String()))) + suffix.String()
+}
+
+func truncate(s string, max int) string {
+	r := []rune(s)
+	if len(r) <= max {
+		return s
+	}
+	return string(r[:max-1]) + "…"
+}
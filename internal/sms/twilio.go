@@ -0,0 +1,134 @@
+package sms
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"eva-mind/internal/config"
+)
+
+// Client cliente mínimo da API REST de mensagens do Twilio
+type Client struct {
+	accountSID string
+	authToken  string
+	from       string
+	baseURL    string
+	http       *http.Client
+}
+
+// Message resposta do Twilio ao criar uma mensagem
+type Message struct {
+	SID          string `json:"sid"`
+	Status       string `json:"status"`
+	To           string `json:"to"`
+	ErrorCode    *int   `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+// APIError erro retornado pela API do Twilio
+type APIError struct {
+	Status   int    `json:"status"`
+	Code     int    `json:"code"`
+	Message  string `json:"message"`
+	MoreInfo string `json:"more_info"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("twilio %d (código %d): %s", e.Status, e.Code, e.Message)
+}
+
+// NewClient cria o cliente com as credenciais da configuração
+func NewClient(cfg *config.Config) (*Client, error) {
+	if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.TwilioPhoneNumber == "" {
+		return nil, fmt.Errorf("Twilio credentials not configured")
+	}
+
+	from, err := NormalizeE164(cfg.TwilioPhoneNumber)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TWILIO_PHONE_NUMBER: %w", err)
+	}
+
+	return &Client{
+		accountSID: cfg.TwilioAccountSID,
+		authToken:  cfg.TwilioAuthToken,
+		from:       from,
+		baseURL:    strings.TrimRight(cfg.TwilioAPIURL, "/"),
+		http:       &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Send cria a mensagem; callbackURL recebe as mudanças de status da entrega
+func (c *Client) Send(ctx context.Context, to, body, callbackURL string) (*Message, error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", c.from)
+	form.Set("Body", body)
+	if callbackURL != "" {
+		form.Set("StatusCallback", callbackURL)
+	}
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", c.baseURL, c.accountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.accountSID, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &APIError{Status: resp.StatusCode}
+		json.NewDecoder(resp.Body).Decode(apiErr)
+		return nil, apiErr
+	}
+
+	var msg Message
+	if err := json.NewDecoder(resp.Body).Decode(&msg); err != nil {
+		return nil, fmt.Errorf("failed to decode Twilio response: %w", err)
+	}
+
+	return &msg, nil
+}
+
+// ValidSignature confere o cabeçalho X-Twilio-Signature de um callback
+func (c *Client) ValidSignature(callbackURL string, params url.Values, signature string) bool {
+	expected := Signature(c.authToken, callbackURL, params)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// Signature assinatura do Twilio: HMAC-SHA1 da URL seguida dos parâmetros
+// POST ordenados (nome+valor), em base64
+func Signature(authToken, callbackURL string, params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString(callbackURL)
+	for _, k := range keys {
+		for _, v := range params[k] {
+			b.WriteString(k)
+			b.WriteString(v)
+		}
+	}
+
+	mac := hmac.New(sha1.New, []byte(authToken))
+	mac.Write([]byte(b.String()))
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,100 @@
+package voice
+
+import (
+	"context"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/sms"
+)
+
+// Client cliente mínimo da API de chamadas do Twilio
+type Client struct {
+	accountSID string
+	authToken  string
+	from       string
+	baseURL    string
+	http       *http.Client
+}
+
+// Call resposta do Twilio ao criar uma chamada
+type Call struct {
+	SID    string `json:"sid"`
+	Status string `json:"status"`
+	To     string `json:"to"`
+}
+
+// NewClient cria o cliente com as credenciais da configuração
+func NewClient(cfg *config.Config) (*Client, error) {
+	if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.TwilioPhoneNumber == "" {
+		return nil, fmt.Errorf("Twilio credentials not configured")
+	}
+	if cfg.ServiceDomain == "" {
+		return nil, fmt.Errorf("SERVICE_DOMAIN required for voice callbacks")
+	}
+
+	from, err := sms.NormalizeE164(cfg.TwilioPhoneNumber)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TWILIO_PHONE_NUMBER: %w", err)
+	}
+
+	return &Client{
+		accountSID: cfg.TwilioAccountSID,
+		authToken:  cfg.TwilioAuthToken,
+		from:       from,
+		baseURL:    strings.TrimRight(cfg.TwilioAPIURL, "/"),
+		http:       &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// Dial inicia a ligação; o Twilio busca o roteiro (TwiML) em twimlURL e avisa
+// o fim da chamada em statusURL
+func (c *Client) Dial(ctx context.Context, to, twimlURL, statusURL string) (*Call, error) {
+	form := url.Values{}
+	form.Set("To", to)
+	form.Set("From", c.from)
+	form.Set("Url", twimlURL)
+	form.Set("Method", http.MethodPost)
+	form.Set("StatusCallback", statusURL)
+	form.Set("StatusCallbackMethod", http.MethodPost)
+	form.Set("Timeout", "30")
+
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Calls.json", c.baseURL, c.accountSID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.accountSID, c.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach Twilio: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &sms.APIError{Status: resp.StatusCode}
+		json.NewDecoder(resp.Body).Decode(apiErr)
+		return nil, apiErr
+	}
+
+	var call Call
+	if err := json.NewDecoder(resp.Body).Decode(&call); err != nil {
+		return nil, fmt.Errorf("failed to decode Twilio response: %w", err)
+	}
+
+	return &call, nil
+}
+
+// ValidSignature confere o cabeçalho X-Twilio-Signature de um webhook
+func (c *Client) ValidSignature(webhookURL string, params url.Values, signature string) bool {
+	expected := sms.Signature(c.authToken, webhookURL, params)
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
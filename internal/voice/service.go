@@ -0,0 +1,311 @@
+package voice
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/config"
+	"eva-mind/internal/sms"
+)
+
+// CallbackPath prefixo dos webhooks das ligações (/{id}/twiml, /{id}/gather, /{id}/status)
+const CallbackPath = "/api/voice/chamadas"
+
+// repeats quantas vezes o aviso é repetido na mesma ligação sem resposta
+const repeats = 2
+
+// finalStatuses status do Twilio que encerram a ligação
+var finalStatuses = map[string]bool{"completed": true, "busy": true, "no-answer": true, "failed": true, "canceled": true}
+
+// Service liga para cuidadores, lê o alerta e confirma quem pressiona 1.
+// Sem confirmação, repete a ligação e depois desce a lista de destinatários.
+type Service struct {
+	client      *Client
+	db          *sql.DB
+	alerts      *alerts.Service
+	cfg         *config.Config
+	maxAttempts int
+}
+
+type chamada struct {
+	id         int64
+	alertaID   int64
+	cuidadorID sql.NullInt64
+	contatoID  sql.NullInt64
+	telefone   string
+	tentativa  int
+	confirmada bool
+}
+
+func (c *chamada) recipient() alerts.Recipient {
+	return alerts.Recipient{
+		CuidadorID: c.cuidadorID.Int64,
+		ContatoID:  c.contatoID.Int64,
+		Telefone:   c.telefone,
+	}
+}
+
+// NewService cria o serviço de ligações. Exige credenciais Twilio e SERVICE_DOMAIN.
+func NewService(cfg *config.Config, db *sql.DB) (*Service, error) {
+	client, err := NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	maxAttempts := cfg.CallMaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = 1
+	}
+
+	return &Service{
+		client:      client,
+		db:          db,
+		alerts:      alerts.NewService(cfg, db),
+		cfg:         cfg,
+		maxAttempts: maxAttempts,
+	}, nil
+}
+
+// PlaceCall implementa notify.CallPlacer: primeira ligação para o destinatário
+func (s *Service) PlaceCall(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error {
+	_, err := s.dial(ctx, alert.ID, r, 1)
+	return err
+}
+
+func (s *Service) dial(ctx context.Context, alertID int64, r alerts.Recipient, tentativa int) (int64, error) {
+	if alertID == 0 {
+		return 0, fmt.Errorf("ligação exige um alerta registrado")
+	}
+
+	phone, err := sms.NormalizeE164(r.Telefone)
+	if err != nil {
+		return 0, err
+	}
+
+	var callID int64
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO chamadas_alerta (alerta_id, cuidador_id, contato_id, telefone, tentativa)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0), $4, $5)
+		RETURNING id
+	`, alertID, r.CuidadorID, r.ContatoID, phone, tentativa).Scan(&callID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to register call: %w", err)
+	}
+
+	call, err := s.client.Dial(ctx, phone, s.url(callID, "twiml"), s.url(callID, "status"))
+	if err != nil {
+		s.db.ExecContext(ctx, `UPDATE chamadas_alerta SET status = 'falhou', atualizado_em = NOW() WHERE id = $1`, callID)
+		return callID, err
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		UPDATE chamadas_alerta SET sid = $2, status = $3, atualizado_em = NOW() WHERE id = $1
+	`, callID, call.SID, call.Status)
+	if err != nil {
+		log.Printf("⚠️ Erro ao registrar SID da ligação %d: %v", callID, err)
+	}
+
+	log.Printf("📞 Ligando para %s (alerta %d, tentativa %d)", r.Label(), alertID, tentativa)
+	return callID, nil
+}
+
+func (s *Service) url(callID int64, action string) string {
+	return s.cfg.PublicURL(fmt.Sprintf("%s/%d/%s", CallbackPath, callID, action))
+}
+
+// ValidSignature confere a assinatura de um webhook recebido em requestURI
+func (s *Service) ValidSignature(requestURI string, params url.Values, signature string) bool {
+	return s.client.ValidSignature(s.cfg.PublicURL(requestURI), params, signature)
+}
+
+func (s *Service) load(ctx context.Context, callID int64) (*chamada, error) {
+	c := &chamada{id: callID}
+	err := s.db.QueryRowContext(ctx, `
+		SELECT alerta_id, cuidador_id, contato_id, telefone, tentativa, confirmada
+		FROM chamadas_alerta WHERE id = $1
+	`, callID).Scan(&c.alertaID, &c.cuidadorID, &c.contatoID, &c.telefone, &c.tentativa, &c.confirmada)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load call %d: %w", callID, err)
+	}
+	return c, nil
+}
+
+// TwiML roteiro da ligação; repeat conta quantas vezes o aviso já foi lido
+func (s *Service) TwiML(ctx context.Context, callID int64, repeat int) ([]byte, error) {
+	c, err := s.load(ctx, callID)
+	if err != nil {
+		return nil, err
+	}
+
+	alert, err := s.alerts.Get(ctx, c.alertaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !alerts.IsOpen(alert.Status) {
+		return messageTwiML(s.cfg.TwilioVoice, fmt.Sprintf("O alerta sobre %s já foi atendido por outra pessoa. Obrigado.", alert.NomeIdoso)), nil
+	}
+
+	repeatURL := ""
+	if repeat < repeats {
+		repeatURL = fmt.Sprintf("%s?repeticao=%d", s.url(callID, "twiml"), repeat+1)
+	}
+
+	return promptTwiML(s.cfg.TwilioVoice, alert, s.url(callID, "gather"), repeatURL), nil
+}
+
+// Gather trata o dígito pressionado: 1 confirma o alerta, o resto repete o aviso
+func (s *Service) Gather(ctx context.Context, callID int64, digits string) ([]byte, error) {
+	c, err := s.load(ctx, callID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.db.ExecContext(ctx, `UPDATE chamadas_alerta SET digitos = $2, atualizado_em = NOW() WHERE id = $1`, callID, digits)
+
+	if digits != "1" {
+		return s.TwiML(ctx, callID, repeats)
+	}
+
+	alert, err := s.alerts.Get(ctx, c.alertaID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := c.recipient()
+	if c.cuidadorID.Valid {
+		err = s.alerts.Acknowledge(ctx, alert.ID, c.cuidadorID.Int64, time.Now(), alerts.CanalLigacao)
+	} else {
+		err = s.alerts.Transition(ctx, alert.ID, alerts.StatusReconhecido, alerts.Event{
+			Canal:        alerts.CanalLigacao,
+			Destinatario: r.Label(),
+			Sucesso:      true,
+			Detalhes:     "confirmado por telefone (tecla 1)",
+		})
+	}
+
+	var te *alerts.TransitionError
+	if errors.As(err, &te) {
+		return messageTwiML(s.cfg.TwilioVoice, fmt.Sprintf("O alerta sobre %s já tinha sido confirmado. Obrigado.", alert.NomeIdoso)), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.db.ExecContext(ctx, `UPDATE chamadas_alerta SET confirmada = true WHERE id = $1`, callID); err != nil {
+		log.Printf("⚠️ Erro ao marcar ligação %d como confirmada: %v", callID, err)
+	}
+
+	log.Printf("✅ Alerta %d confirmado por telefone por %s", alert.ID, r.Label())
+	return messageTwiML(s.cfg.TwilioVoice, fmt.Sprintf("Obrigado. Alerta confirmado. Por favor, verifique %s o quanto antes.", alert.NomeIdoso)), nil
+}
+
+// HandleStatus trata o fim da ligação: sem confirmação, liga de novo ou passa
+// ao próximo da lista; com a lista esgotada, antecipa o escalonamento
+func (s *Service) HandleStatus(ctx context.Context, callID int64, status string) error {
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE chamadas_alerta SET status = $2, atualizado_em = NOW() WHERE id = $1
+	`, callID, status)
+	if err != nil {
+		return fmt.Errorf("failed to update call %d: %w", callID, err)
+	}
+
+	if !finalStatuses[status] {
+		return nil
+	}
+
+	c, err := s.load(ctx, callID)
+	if err != nil {
+		return err
+	}
+	if c.confirmada {
+		return nil
+	}
+
+	alert, err := s.alerts.Get(ctx, c.alertaID)
+	if err != nil {
+		return err
+	}
+	if !alerts.IsOpen(alert.Status) {
+		return nil
+	}
+
+	r := c.recipient()
+	if err := s.alerts.RecordAttempt(ctx, alert.ID, alerts.Event{
+		Canal:        alerts.CanalLigacao,
+		Destinatario: r.Label(),
+		Sucesso:      false,
+		Erro:         fmt.Sprintf("ligação sem confirmação (%s)", status),
+		Detalhes:     fmt.Sprintf("tentativa %d", c.tentativa),
+	}); err != nil {
+		log.Printf("⚠️ %v", err)
+	}
+
+	return s.next(ctx, alert, c)
+}
+
+// next repete a ligação para o mesmo destinatário até maxAttempts e depois
+// segue para o próximo da lista (cuidadores ou contatos de emergência)
+func (s *Service) next(ctx context.Context, alert *alerts.Alert, c *chamada) error {
+	if c.tentativa < s.maxAttempts {
+		if err := s.redial(ctx, alert, c.recipient(), c.tentativa+1); err == nil {
+			return nil
+		}
+	}
+
+	var list []alerts.Recipient
+	var err error
+	switch {
+	case c.cuidadorID.Valid:
+		list, err = s.alerts.Caregivers(ctx, alert.IdosoID, 0)
+	case c.contatoID.Valid:
+		list, err = s.alerts.EmergencyContacts(ctx, alert.IdosoID)
+	}
+	if err != nil {
+		return err
+	}
+
+	current := c.recipient()
+	found := false
+	for _, r := range list {
+		if !found {
+			found = r.CuidadorID == current.CuidadorID && r.ContatoID == current.ContatoID
+			continue
+		}
+		if r.Telefone == "" {
+			continue
+		}
+		if err := s.redial(ctx, alert, r, 1); err == nil {
+			return nil
+		}
+	}
+
+	log.Printf("⚠️ Alerta %d: ninguém confirmou por telefone, antecipando escalonamento", alert.ID)
+	return s.alerts.ScheduleEscalation(ctx, alert.ID, time.Now())
+}
+
+func (s *Service) redial(ctx context.Context, alert *alerts.Alert, r alerts.Recipient, tentativa int) error {
+	_, err := s.dial(ctx, alert.ID, r, tentativa)
+
+	ev := alerts.Event{
+		Canal:        alerts.CanalLigacao,
+		Destinatario: r.Label(),
+		Sucesso:      err == nil,
+		Detalhes:     fmt.Sprintf("nova ligação, tentativa %d", tentativa),
+	}
+	if err != nil {
+		ev.Erro = err.Error()
+		log.Printf("❌ Alerta %d: falha ao ligar para %s: %v", alert.ID, r.Label(), err)
+	}
+	if recErr := s.alerts.RecordAttempt(ctx, alert.ID, ev); recErr != nil {
+		log.Printf("⚠️ %v", recErr)
+	}
+
+	return err
+}
@@ -0,0 +1,95 @@
+package voice
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+
+	"eva-mind/internal/alerts"
+)
+
+const language = "pt-BR"
+
+type say struct {
+	XMLName  xml.Name `xml:"Say"`
+	Voice    string   `xml:"voice,attr,omitempty"`
+	Language string   `xml:"language,attr"`
+	Text     string   `xml:",chardata"`
+}
+
+type gather struct {
+	XMLName   xml.Name `xml:"Gather"`
+	Input     string   `xml:"input,attr"`
+	NumDigits int      `xml:"numDigits,attr"`
+	Timeout   int      `xml:"timeout,attr"`
+	Action    string   `xml:"action,attr"`
+	Method    string   `xml:"method,attr"`
+	Say       say
+}
+
+type redirect struct {
+	XMLName xml.Name `xml:"Redirect"`
+	Method  string   `xml:"method,attr"`
+	URL     string   `xml:",chardata"`
+}
+
+type response struct {
+	XMLName xml.Name `xml:"Response"`
+	Verbs   []interface{}
+}
+
+func (r *response) say(voice, text string) {
+	r.Verbs = append(r.Verbs, say{Voice: voice, Language: language, Text: text})
+}
+
+func (r *response) hangup() {
+	r.Verbs = append(r.Verbs, struct {
+		XMLName xml.Name `xml:"Hangup"`
+	}{})
+}
+
+func (r *response) bytes() []byte {
+	out, _ := xml.Marshal(r)
+	return append([]byte(xml.Header), out...)
+}
+
+// Script texto lido na ligação: idoso, motivo e horário do alerta
+func Script(alert *alerts.Alert) string {
+	at := alert.CriadoEm.In(time.Local)
+	return fmt.Sprintf(
+		"Atenção. Esta é uma ligação de emergência da EVA. %s precisa de ajuda. Motivo: %s. O alerta foi registrado às %d horas e %d minutos.",
+		alert.NomeIdoso, alert.Mensagem, at.Hour(), at.Minute(),
+	)
+}
+
+// promptTwiML lê o alerta e espera o dígito 1; sem resposta, volta para repeatURL
+func promptTwiML(voice string, alert *alerts.Alert, gatherURL, repeatURL string) []byte {
+	r := &response{}
+	r.Verbs = append(r.Verbs, gather{
+		Input:     "dtmf",
+		NumDigits: 1,
+		Timeout:   8,
+		Action:    gatherURL,
+		Method:    "POST",
+		Say: say{
+			Voice:    voice,
+			Language: language,
+			Text:     Script(alert) + " Pressione 1 para confirmar que você vai cuidar da situação.",
+		},
+	})
+	if repeatURL != "" {
+		r.Verbs = append(r.Verbs, redirect{Method: "POST", URL: repeatURL})
+	} else {
+		r.say(voice, "Não recebemos sua confirmação. Vamos tentar outro contato. Até logo.")
+		r.hangup()
+	}
+	return r.bytes()
+}
+
+// messageTwiML fala uma mensagem e desliga
+func messageTwiML(voice, text string) []byte {
+	r := &response{}
+	r.say(voice, text)
+	r.hangup()
+	return r.bytes()
+}
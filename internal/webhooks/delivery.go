@@ -0,0 +1,324 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Status possíveis de uma entrega em webhook_entregas
+const (
+	StatusPendente        = "pendente"
+	StatusEnviando        = "enviando"
+	StatusEntregue        = "entregue"
+	StatusErro            = "erro"
+	StatusFalhaDefinitiva = "falha_definitiva"
+)
+
+const (
+	baseBackoff = 1 * time.Minute
+	maxBackoff  = 6 * time.Hour
+
+	// Entregas presas em "enviando" por mais tempo que isso são retomadas
+	staleTimeout = 2 * time.Minute
+
+	// maxResponse bytes da resposta do integrador guardados no log
+	maxResponse = 2048
+)
+
+// Delivery entrega de um evento para uma assinatura
+type Delivery struct {
+	ID               int64      `json:"id"`
+	AssinaturaID     int64      `json:"assinatura_id"`
+	EventoID         string     `json:"evento_id"`
+	Evento           string     `json:"evento"`
+	Status           string     `json:"status"`
+	Tentativas       int        `json:"tentativas"`
+	MaxTentativas    int        `json:"max_tentativas"`
+	ProximaTentativa time.Time  `json:"proxima_tentativa"`
+	UltimoStatusHTTP *int       `json:"ultimo_status_http,omitempty"`
+	UltimoErro       string     `json:"ultimo_erro,omitempty"`
+	EntregueEm       *time.Time `json:"entregue_em,omitempty"`
+	CriadoEm         time.Time  `json:"criado_em"`
+	Payload          string     `json:"payload,omitempty"`
+	Log              []Attempt  `json:"log,omitempty"`
+
+	url     string
+	segredo string
+}
+
+// Attempt linha do log de tentativas de uma entrega
+type Attempt struct {
+	Tentativa  int       `json:"tentativa"`
+	StatusHTTP *int      `json:"status_http,omitempty"`
+	Erro       string    `json:"erro,omitempty"`
+	Resposta   string    `json:"resposta,omitempty"`
+	DuracaoMs  int       `json:"duracao_ms"`
+	CriadoEm   time.Time `json:"criado_em"`
+}
+
+// Claim reserva até limit entregas prontas de assinaturas ativas
+func (s *Service) Claim(ctx context.Context, limit int) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		UPDATE webhook_entregas e
+		SET status = 'enviando',
+		    tentativas = e.tentativas + 1,
+		    iniciado_em = NOW(),
+		    atualizado_em = NOW()
+		FROM webhook_assinaturas a
+		WHERE a.id = e.assinatura_id
+		  AND e.id IN (
+			SELECT en.id FROM webhook_entregas en
+			JOIN webhook_assinaturas ass ON ass.id = en.assinatura_id AND ass.ativo
+			WHERE (en.status IN ('pendente', 'erro') AND en.proxima_tentativa <= NOW())
+			   OR (en.status = 'enviando' AND en.iniciado_em < NOW() - make_interval(secs => $2))
+			ORDER BY en.proxima_tentativa ASC
+			LIMIT $1
+			FOR UPDATE OF en SKIP LOCKED
+		)
+		RETURNING e.id, e.assinatura_id, e.evento_id, e.evento, e.payload::text,
+		          e.tentativas, e.max_tentativas, a.url, a.segredo
+	`, limit, staleTimeout.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var list []Delivery
+	for rows.Next() {
+		var d Delivery
+		if err := rows.Scan(&d.ID, &d.AssinaturaID, &d.EventoID, &d.Evento, &d.Payload,
+			&d.Tentativas, &d.MaxTentativas, &d.url, &d.segredo); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		list = append(list, d)
+	}
+	return list, rows.Err()
+}
+
+// Deliver envia a entrega assinada, registra a tentativa no log e agenda a
+// próxima com backoff exponencial em caso de falha. Qualquer 2xx é sucesso.
+func (s *Service) Deliver(ctx context.Context, d Delivery) error {
+	body := []byte(d.Payload)
+	start := time.Now()
+
+	var statusHTTP *int
+	var resposta string
+	cause := s.post(ctx, d, body, &statusHTTP, &resposta)
+	duracao := int(time.Since(start) / time.Millisecond)
+
+	erro := ""
+	if cause != nil {
+		erro = cause.Error()
+	}
+	if _, err := s.db.ExecContext(ctx, `
+		INSERT INTO webhook_tentativas (entrega_id, tentativa, status_http, erro, resposta, duracao_ms)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), $6)
+	`, d.ID, d.Tentativas, statusHTTP, erro, resposta, duracao); err != nil {
+		log.Printf("⚠️ Erro ao registrar tentativa do webhook %d: %v", d.ID, err)
+	}
+
+	if cause == nil {
+		_, err := s.db.ExecContext(ctx, `
+			UPDATE webhook_entregas
+			SET status = 'entregue', ultimo_status_http = $2, ultimo_erro = NULL,
+			    entregue_em = NOW(), atualizado_em = NOW()
+			WHERE id = $1
+		`, d.ID, statusHTTP)
+		if err != nil {
+			return fmt.Errorf("failed to complete webhook delivery: %w", err)
+		}
+		return nil
+	}
+
+	status := StatusErro
+	if d.Tentativas >= d.MaxTentativas {
+		status = StatusFalhaDefinitiva
+	}
+	_, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_entregas
+		SET status = $2, ultimo_status_http = $3, ultimo_erro = $4,
+		    proxima_tentativa = NOW() + make_interval(secs => $5),
+		    atualizado_em = NOW()
+		WHERE id = $1
+	`, d.ID, status, statusHTTP, erro, Backoff(d.Tentativas).Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to record webhook failure: %w", err)
+	}
+
+	if status == StatusFalhaDefinitiva {
+		log.Printf("❌ Webhook %d (%s) desistiu após %d tentativas: %v", d.ID, d.Evento, d.Tentativas, cause)
+	} else {
+		log.Printf("⚠️ Webhook %d (%s) falhou (tentativa %d/%d): %v", d.ID, d.Evento, d.Tentativas, d.MaxTentativas, cause)
+	}
+	return nil
+}
+
+func (s *Service) post(ctx context.Context, d Delivery, body []byte, statusHTTP **int, resposta *string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "EVA-Mind-Webhooks/"+strconv.Itoa(Version))
+	req.Header.Set(HeaderEvent, d.Evento)
+	req.Header.Set(HeaderDelivery, d.EventoID)
+	req.Header.Set(HeaderVersion, strconv.Itoa(Version))
+	req.Header.Set(HeaderSignature, Sign(d.segredo, body, time.Now()))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	code := resp.StatusCode
+	*statusHTTP = &code
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponse))
+	*resposta = string(b)
+
+	if code < 200 || code >= 300 {
+		return fmt.Errorf("integração respondeu %d", code)
+	}
+	return nil
+}
+
+// Redeliver reenvia manualmente uma entrega (inclusive já entregue ou em
+// falha_definitiva), zerando as tentativas. O log anterior é mantido.
+func (s *Service) Redeliver(ctx context.Context, entidade string, id int64) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_entregas e
+		SET status = 'pendente', tentativas = 0, proxima_tentativa = NOW(),
+		    ultimo_erro = NULL, atualizado_em = NOW()
+		FROM webhook_assinaturas a
+		WHERE e.id = $1 AND a.id = e.assinatura_id AND a.entidade_nome = $2
+		  AND e.status <> 'enviando'
+	`, id, entidade)
+	if err != nil {
+		return fmt.Errorf("failed to redeliver webhook %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	log.Printf("🔁 Webhook %d reenfileirado manualmente", id)
+	return nil
+}
+
+// Deliveries últimas entregas de uma assinatura da entidade (status pode ser vazio)
+func (s *Service) Deliveries(ctx context.Context, entidade string, assinaturaID int64, status string, limit int) ([]Delivery, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT e.id, e.assinatura_id, e.evento_id, e.evento, e.status, e.tentativas, e.max_tentativas,
+		       e.proxima_tentativa, e.ultimo_status_http, COALESCE(e.ultimo_erro, ''), e.entregue_em, e.criado_em
+		FROM webhook_entregas e
+		JOIN webhook_assinaturas a ON a.id = e.assinatura_id
+		WHERE e.assinatura_id = $1 AND a.entidade_nome = $2
+		  AND ($3 = '' OR e.status = $3)
+		ORDER BY e.criado_em DESC
+		LIMIT $4
+	`, assinaturaID, entidade, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Delivery{}
+	for rows.Next() {
+		d, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *d)
+	}
+	return list, rows.Err()
+}
+
+// Get entrega com o payload enviado e o log de tentativas
+func (s *Service) Get(ctx context.Context, entidade string, id int64) (*Delivery, error) {
+	row := s.db.QueryRowContext(ctx, `
+		SELECT e.id, e.assinatura_id, e.evento_id, e.evento, e.status, e.tentativas, e.max_tentativas,
+		       e.proxima_tentativa, e.ultimo_status_http, COALESCE(e.ultimo_erro, ''), e.entregue_em, e.criado_em
+		FROM webhook_entregas e
+		JOIN webhook_assinaturas a ON a.id = e.assinatura_id
+		WHERE e.id = $1 AND a.entidade_nome = $2
+	`, id, entidade)
+	d, err := scanDelivery(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.db.QueryRowContext(ctx, `SELECT payload::text FROM webhook_entregas WHERE id = $1`, id).Scan(&d.Payload); err != nil {
+		return nil, fmt.Errorf("failed to load webhook payload: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT tentativa, status_http, COALESCE(erro, ''), COALESCE(resposta, ''), COALESCE(duracao_ms, 0), criado_em
+		FROM webhook_tentativas
+		WHERE entrega_id = $1
+		ORDER BY id
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var a Attempt
+		var code sql.NullInt64
+		if err := rows.Scan(&a.Tentativa, &code, &a.Erro, &a.Resposta, &a.DuracaoMs, &a.CriadoEm); err != nil {
+			return nil, err
+		}
+		if code.Valid {
+			c := int(code.Int64)
+			a.StatusHTTP = &c
+		}
+		d.Log = append(d.Log, a)
+	}
+	return d, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDelivery(row scanner) (*Delivery, error) {
+	var d Delivery
+	var code sql.NullInt64
+	var entregue sql.NullTime
+	if err := row.Scan(&d.ID, &d.AssinaturaID, &d.EventoID, &d.Evento, &d.Status, &d.Tentativas, &d.MaxTentativas,
+		&d.ProximaTentativa, &code, &d.UltimoErro, &entregue, &d.CriadoEm); err != nil {
+		return nil, err
+	}
+	if code.Valid {
+		c := int(code.Int64)
+		d.UltimoStatusHTTP = &c
+	}
+	if entregue.Valid {
+		d.EntregueEm = &entregue.Time
+	}
+	return &d, nil
+}
+
+// Backoff espera antes da próxima tentativa (1min, 2min, 4min... até 6h)
+func Backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	d := baseBackoff
+	for i := 1; i < attempt; i++ {
+		d *= 2
+		if d >= maxBackoff {
+			return maxBackoff
+		}
+	}
+	return d
+}
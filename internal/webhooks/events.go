@@ -0,0 +1,116 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Version versão do formato do envelope. Mudanças incompatíveis sobem o número.
+const Version = 1
+
+// Eventos enviados às integrações
+const (
+	EventoAlertaCriado          = "alerta.criado"
+	EventoAlertaReconhecido     = "alerta.reconhecido"
+	EventoChamadaFinalizada     = "chamada.finalizada"
+	EventoChamadaPerdida        = "chamada.perdida"
+	EventoMedicamentoConfirmado = "medicamento.confirmado"
+	EventoPredicaoGerada        = "predicao.gerada"
+
+	// EventoPing enviado apenas pelo teste manual da assinatura
+	EventoPing = "ping"
+)
+
+// Eventos todos os eventos que uma assinatura pode escolher
+var Eventos = []string{
+	EventoAlertaCriado,
+	EventoAlertaReconhecido,
+	EventoChamadaFinalizada,
+	EventoChamadaPerdida,
+	EventoMedicamentoConfirmado,
+	EventoPredicaoGerada,
+}
+
+// Cabeçalhos HTTP das entregas
+const (
+	HeaderSignature = "X-EVA-Signature"
+	HeaderEvent     = "X-EVA-Event"
+	HeaderDelivery  = "X-EVA-Delivery"
+	HeaderVersion   = "X-EVA-Webhook-Version"
+)
+
+// signatureTolerance diferença máxima aceita entre o timestamp da assinatura e o relógio
+const signatureTolerance = 5 * time.Minute
+
+// Envelope corpo JSON de toda entrega
+type Envelope struct {
+	ID       string      `json:"id"`
+	Versao   int         `json:"versao"`
+	Evento   string      `json:"evento"`
+	CriadoEm time.Time   `json:"criado_em"`
+	Entidade string      `json:"entidade"`
+	IdosoID  int64       `json:"idoso_id,omitempty"`
+	Dados    interface{} `json:"dados"`
+}
+
+// ValidEvent informa se o evento pode ser assinado
+func ValidEvent(evento string) bool {
+	for _, e := range Eventos {
+		if e == evento {
+			return true
+		}
+	}
+	return false
+}
+
+// Sign monta o cabeçalho X-EVA-Signature: "t=<unix>,v1=<hex>", com o HMAC-SHA256
+// de "<unix>.<corpo>". O timestamp assinado impede o reenvio do corpo por terceiros.
+func Sign(secret string, body []byte, at time.Time) string {
+	ts := strconv.FormatInt(at.Unix(), 10)
+	return "t=" + ts + ",v1=" + digest(secret, ts, body)
+}
+
+// Verify confere a assinatura do lado de quem recebe (referência para integradores)
+func Verify(secret string, body []byte, header string, now time.Time) bool {
+	var ts, sig string
+	for _, part := range strings.Split(header, ",") {
+		k, v, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch k {
+		case "t":
+			ts = v
+		case "v1":
+			sig = v
+		}
+	}
+
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || sig == "" {
+		return false
+	}
+	if d := now.Sub(time.Unix(sec, 0)); d > signatureTolerance || d < -signatureTolerance {
+		return false
+	}
+	return hmac.Equal([]byte(digest(secret, ts, body)), []byte(sig))
+}
+
+func digest(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func randomID(prefix string, n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%s%d", prefix, time.Now().UnixNano())
+	}
+	return prefix + hex.EncodeToString(b)
+}
@@ -0,0 +1,47 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerify(t *testing.T) {
+	body := []byte(`{"evento":"alerta.criado"}`)
+	signedAt := time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+	header := Sign("segredo", body, signedAt)
+
+	if !strings.HasPrefix(header, "t=1773144000,v1=") {
+		t.Fatalf("Sign() = %q, formato inesperado", header)
+	}
+
+	tests := []struct {
+		name   string
+		secret string
+		body   []byte
+		header string
+		now    time.Time
+		want   bool
+	}{
+		{"válida", "segredo", body, header, signedAt, true},
+		{"dentro da tolerância", "segredo", body, header, signedAt.Add(5 * time.Minute), true},
+		{"relógio atrasado dentro da tolerância", "segredo", body, header, signedAt.Add(-5 * time.Minute), true},
+		{"expirada", "segredo", body, header, signedAt.Add(5*time.Minute + time.Second), false},
+		{"do futuro", "segredo", body, header, signedAt.Add(-5*time.Minute - time.Second), false},
+		{"outro segredo", "outro", body, header, signedAt, false},
+		{"corpo alterado", "segredo", []byte(`{"evento":"ping"}`), header, signedAt, false},
+		{"campos com espaços", "segredo", body, strings.ReplaceAll(header, ",", ", "), signedAt, true},
+		{"timestamp trocado", "segredo", body, strings.Replace(header, "t=1773144000", "t=1773144060", 1), signedAt, false},
+		{"sem assinatura", "segredo", body, "t=1773144000", signedAt, false},
+		{"sem timestamp", "segredo", body, header[strings.Index(header, "v1="):], signedAt, false},
+		{"vazio", "segredo", body, "", signedAt, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Verify(tt.secret, tt.body, tt.header, tt.now); got != tt.want {
+				t.Errorf("Verify(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package webhooks
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	"eva-mind/internal/subscription"
+
+	"github.com/lib/pq"
+)
+
+// Feature recurso do plano que libera os webhooks
+const Feature = "api_integracao"
+
+// ErrNotFound assinatura ou entrega inexistente (ou de outra entidade)
+var ErrNotFound = errors.New("webhook não encontrado")
+
+// Subscription assinatura de webhook de uma entidade
+type Subscription struct {
+	ID        int64     `json:"id"`
+	Entidade  string    `json:"entidade_nome"`
+	URL       string    `json:"url"`
+	Segredo   string    `json:"segredo,omitempty"` // só retornado na criação
+	Eventos   []string  `json:"eventos"`
+	Descricao string    `json:"descricao,omitempty"`
+	Ativo     bool      `json:"ativo"`
+	CriadoEm  time.Time `json:"criado_em"`
+}
+
+// Validate verifica URL e eventos
+func (s *Subscription) Validate() error {
+	if s.Entidade == "" {
+		return fmt.Errorf("entidade_nome obrigatório")
+	}
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("url inválida: use http(s)://host/caminho")
+	}
+	if len(s.Eventos) == 0 {
+		return fmt.Errorf("informe ao menos um evento")
+	}
+	for _, e := range s.Eventos {
+		if !ValidEvent(e) {
+			return fmt.Errorf("evento inválido: %s", e)
+		}
+	}
+	return nil
+}
+
+// Service assinaturas, publicação de eventos e entregas dos webhooks
+type Service struct {
+	db            *sql.DB
+	subscriptions *subscription.SubscriptionService
+	client        *http.Client
+	maxAttempts   int
+}
+
+// NewService cria o serviço de webhooks
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		db:            db,
+		subscriptions: subscription.NewSubscriptionService(db),
+		client:        &http.Client{Timeout: 10 * time.Second},
+		maxAttempts:   10,
+	}
+}
+
+// Create registra a assinatura e gera o segredo de assinatura
+func (s *Service) Create(ctx context.Context, sub *Subscription) error {
+	if err := sub.Validate(); err != nil {
+		return err
+	}
+	sub.Segredo = randomID("whsec_", 24)
+	sub.Ativo = true
+
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_assinaturas (entidade_nome, url, segredo, eventos, descricao)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, criado_em
+	`, sub.Entidade, sub.URL, sub.Segredo, pq.Array(sub.Eventos), sub.Descricao).Scan(&sub.ID, &sub.CriadoEm)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	log.Printf("🔗 Webhook %d criado para %s (%v)", sub.ID, sub.Entidade, sub.Eventos)
+	return nil
+}
+
+// List assinaturas da entidade (sem o segredo)
+func (s *Service) List(ctx context.Context, entidade string) ([]Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, entidade_nome, url, eventos, COALESCE(descricao, ''), ativo, criado_em
+		FROM webhook_assinaturas
+		WHERE entidade_nome = $1
+		ORDER BY id
+	`, entidade)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	list := []Subscription{}
+	for rows.Next() {
+		var sub Subscription
+		if err := rows.Scan(&sub.ID, &sub.Entidade, &sub.URL, pq.Array(&sub.Eventos), &sub.Descricao, &sub.Ativo, &sub.CriadoEm); err != nil {
+			return nil, err
+		}
+		list = append(list, sub)
+	}
+	return list, rows.Err()
+}
+
+// Disable desativa a assinatura; entregas pendentes deixam de ser enviadas
+func (s *Service) Disable(ctx context.Context, entidade string, id int64) error {
+	res, err := s.db.ExecContext(ctx, `
+		UPDATE webhook_assinaturas SET ativo = false, atualizado_em = NOW()
+		WHERE id = $1 AND entidade_nome = $2
+	`, id, entidade)
+	if err != nil {
+		return fmt.Errorf("failed to disable webhook %d: %w", id, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Publish enfileira o evento para as assinaturas ativas da entidade do idoso.
+// Idosos sem entidade, ou de entidades sem api_integracao, não geram entregas.
+func (s *Service) Publish(ctx context.Context, idosoID int64, evento string, dados interface{}) error {
+	var entidade string
+	err := s.db.QueryRowContext(ctx, `
+		SELECT COALESCE(entidade_nome, '') FROM idosos WHERE id = $1
+	`, idosoID).Scan(&entidade)
+	if err != nil {
+		return fmt.Errorf("failed to load entity of elder %d: %w", idosoID, err)
+	}
+	if entidade == "" {
+		return nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id FROM webhook_assinaturas
+		WHERE entidade_nome = $1 AND ativo AND $2 = ANY(eventos)
+	`, entidade, evento)
+	if err != nil {
+		return fmt.Errorf("failed to query webhook subscriptions: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if ok, err := s.subscriptions.CheckFeature(entidade, Feature); err != nil || !ok {
+		return nil
+	}
+
+	env := Envelope{
+		ID:       randomID("evt_", 12),
+		Versao:   Version,
+		Evento:   evento,
+		CriadoEm: time.Now().UTC(),
+		Entidade: entidade,
+		IdosoID:  idosoID,
+		Dados:    dados,
+	}
+	for _, id := range ids {
+		if _, err := s.enqueue(ctx, id, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ping enfileira um evento de teste para a assinatura
+func (s *Service) Ping(ctx context.Context, entidade string, id int64) (int64, error) {
+	var ok bool
+	err := s.db.QueryRowContext(ctx, `
+		SELECT ativo FROM webhook_assinaturas WHERE id = $1 AND entidade_nome = $2
+	`, id, entidade).Scan(&ok)
+	if err == sql.ErrNoRows || (err == nil && !ok) {
+		return 0, ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return s.enqueue(ctx, id, Envelope{
+		ID:       randomID("evt_", 12),
+		Versao:   Version,
+		Evento:   EventoPing,
+		CriadoEm: time.Now().UTC(),
+		Entidade: entidade,
+		Dados:    map[string]string{"mensagem": "pong"},
+	})
+}
+
+func (s *Service) enqueue(ctx context.Context, assinaturaID int64, env Envelope) (int64, error) {
+	payload, err := json.Marshal(env)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	var id int64
+	err = s.db.QueryRowContext(ctx, `
+		INSERT INTO webhook_entregas (assinatura_id, evento_id, evento, payload, max_tentativas)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, assinaturaID, env.ID, env.Evento, string(payload), s.maxAttempts).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook delivery: %w", err)
+	}
+	return id, nil
+}
@@ -0,0 +1,120 @@
+package whatsapp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/sms"
+)
+
+// Client cliente mínimo da WhatsApp Business Cloud API
+type Client struct {
+	token    string
+	phoneID  string
+	baseURL  string
+	language string
+	http     *http.Client
+}
+
+// APIError erro retornado pela Graph API
+type APIError struct {
+	Status    int    `json:"-"`
+	Message   string `json:"message"`
+	Type      string `json:"type"`
+	Code      int    `json:"code"`
+	FBTraceID string `json:"fbtrace_id"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("whatsapp %d (código %d): %s", e.Status, e.Code, e.Message)
+}
+
+// NewClient cria o cliente com as credenciais da configuração
+func NewClient(cfg *config.Config) (*Client, error) {
+	if cfg.WhatsAppToken == "" || cfg.WhatsAppPhoneNumberID == "" {
+		return nil, fmt.Errorf("WhatsApp credentials not configured")
+	}
+
+	return &Client{
+		token:    cfg.WhatsAppToken,
+		phoneID:  cfg.WhatsAppPhoneNumberID,
+		baseURL:  strings.TrimRight(cfg.WhatsAppAPIURL, "/"),
+		language: cfg.WhatsAppTemplateLanguage,
+		http:     &http.Client{Timeout: 15 * time.Second},
+	}, nil
+}
+
+// SendTemplate envia uma mensagem de template aprovado e retorna o id (wamid)
+func (c *Client) SendTemplate(ctx context.Context, to string, t Template) (string, error) {
+	return c.send(ctx, to, map[string]interface{}{
+		"type":     "template",
+		"template": t.payload(c.language),
+	})
+}
+
+// SendText envia texto livre; só é aceito dentro da janela de 24h após a
+// última mensagem do destinatário (ex.: resposta a um botão)
+func (c *Client) SendText(ctx context.Context, to, body string) (string, error) {
+	return c.send(ctx, to, map[string]interface{}{
+		"type": "text",
+		"text": map[string]string{"body": body},
+	})
+}
+
+func (c *Client) send(ctx context.Context, to string, msg map[string]interface{}) (string, error) {
+	phone, err := sms.NormalizeE164(to)
+	if err != nil {
+		return "", err
+	}
+
+	msg["messaging_product"] = "whatsapp"
+	msg["to"] = strings.TrimPrefix(phone, "+")
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return "", err
+	}
+
+	endpoint := fmt.Sprintf("%s/%s/messages", c.baseURL, c.phoneID)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach WhatsApp: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var wrapper struct {
+			Error APIError `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&wrapper)
+		wrapper.Error.Status = resp.StatusCode
+		return "", &wrapper.Error
+	}
+
+	var result struct {
+		Messages []struct {
+			ID string `json:"id"`
+		} `json:"messages"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode WhatsApp response: %w", err)
+	}
+	if len(result.Messages) == 0 {
+		return "", fmt.Errorf("WhatsApp response without message id")
+	}
+
+	return result.Messages[0].ID, nil
+}
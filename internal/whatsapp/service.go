@@ -0,0 +1,321 @@
+package whatsapp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/config"
+)
+
+// Service envia alertas por WhatsApp e trata os webhooks de status e de
+// resposta dos botões "Vi" / "Estou indo"
+type Service struct {
+	client      *Client
+	db          *sql.DB
+	alerts      *alerts.Service
+	appSecret   string
+	verifyToken string
+}
+
+// NewService cria o serviço de WhatsApp
+func NewService(cfg *config.Config, db *sql.DB) (*Service, error) {
+	client, err := NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Service{
+		client:      client,
+		db:          db,
+		alerts:      alerts.NewService(cfg, db),
+		appSecret:   cfg.WhatsAppAppSecret,
+		verifyToken: cfg.WhatsAppVerifyToken,
+	}, nil
+}
+
+// SendAlert envia o template do alerta com os botões de resposta rápida
+func (s *Service) SendAlert(ctx context.Context, alert *alerts.Alert, r alerts.Recipient) error {
+	t := AlertTemplate(alert)
+	return s.sendTemplate(ctx, alert.ID, r, t)
+}
+
+// SendMedicationConfirmation avisa que o idoso tomou o remédio
+func (s *Service) SendMedicationConfirmation(ctx context.Context, r alerts.Recipient, elderName, medication string) error {
+	return s.sendTemplate(ctx, 0, r, MedicationTemplate(elderName, medication))
+}
+
+func (s *Service) sendTemplate(ctx context.Context, alertID int64, r alerts.Recipient, t Template) error {
+	wamid, err := s.client.SendTemplate(ctx, r.Telefone, t)
+	if err != nil {
+		return err
+	}
+
+	var alerta interface{}
+	if alertID != 0 {
+		alerta = alertID
+	}
+
+	_, err = s.db.ExecContext(ctx, `
+		INSERT INTO mensagens_whatsapp (wamid, alerta_id, cuidador_id, contato_id, telefone, template)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), $5, $6)
+		ON CONFLICT (wamid) DO NOTHING
+	`, wamid, alerta, r.CuidadorID, r.ContatoID, r.Telefone, t.Name)
+	if err != nil {
+		// A mensagem já saiu; só perdemos o vínculo com as respostas
+		log.Printf("⚠️ Erro ao registrar mensagem WhatsApp %s: %v", wamid, err)
+	}
+
+	log.Printf("💬 WhatsApp %s enviado para %s", t.Name, r.Label())
+	return nil
+}
+
+// Verify responde ao desafio de verificação do webhook (GET com hub.*)
+func (s *Service) Verify(mode, token, challenge string) (string, bool) {
+	if mode != "subscribe" || s.verifyToken == "" || token != s.verifyToken {
+		return "", false
+	}
+	return challenge, true
+}
+
+// ValidSignature confere o cabeçalho X-Hub-Signature-256 do corpo recebido
+func (s *Service) ValidSignature(body []byte, header string) bool {
+	if s.appSecret == "" {
+		return false
+	}
+	return hmac.Equal([]byte(Signature(s.appSecret, body)), []byte(header))
+}
+
+// Signature assinatura da Meta: "sha256=" + HMAC-SHA256 do corpo com o app secret
+func Signature(appSecret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Webhook corpo das notificações da Cloud API (somente os campos usados)
+type Webhook struct {
+	Entry []struct {
+		Changes []struct {
+			Value struct {
+				Messages []InboundMessage `json:"messages"`
+				Statuses []StatusUpdate   `json:"statuses"`
+			} `json:"value"`
+		} `json:"changes"`
+	} `json:"entry"`
+}
+
+// InboundMessage mensagem recebida de um cuidador
+type InboundMessage struct {
+	From      string `json:"from"`
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	Context   *struct {
+		ID string `json:"id"`
+	} `json:"context,omitempty"`
+	Button *struct {
+		Text    string `json:"text"`
+		Payload string `json:"payload"`
+	} `json:"button,omitempty"`
+	Interactive *struct {
+		ButtonReply *struct {
+			ID    string `json:"id"`
+			Title string `json:"title"`
+		} `json:"button_reply,omitempty"`
+	} `json:"interactive,omitempty"`
+}
+
+// StatusUpdate mudança de status de uma mensagem enviada
+type StatusUpdate struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Errors []struct {
+		Code  int    `json:"code"`
+		Title string `json:"title"`
+	} `json:"errors,omitempty"`
+}
+
+// reply payload do botão pressionado, se a mensagem for uma resposta rápida
+func (m InboundMessage) reply() string {
+	switch {
+	case m.Button != nil:
+		return m.Button.Payload
+	case m.Interactive != nil && m.Interactive.ButtonReply != nil:
+		return m.Interactive.ButtonReply.ID
+	}
+	return ""
+}
+
+func (m InboundMessage) sentAt() time.Time {
+	if sec, err := strconv.ParseInt(m.Timestamp, 10, 64); err == nil {
+		return time.Unix(sec, 0)
+	}
+	return time.Now()
+}
+
+type mensagem struct {
+	alertaID   sql.NullInt64
+	cuidadorID sql.NullInt64
+	contatoID  sql.NullInt64
+	telefone   string
+}
+
+func (m *mensagem) recipient() alerts.Recipient {
+	return alerts.Recipient{CuidadorID: m.cuidadorID.Int64, ContatoID: m.contatoID.Int64, Telefone: m.telefone}
+}
+
+// HandleWebhook processa um corpo de webhook já com a assinatura conferida
+func (s *Service) HandleWebhook(ctx context.Context, body []byte) error {
+	var hook Webhook
+	if err := json.Unmarshal(body, &hook); err != nil {
+		return fmt.Errorf("invalid webhook payload: %w", err)
+	}
+
+	for _, entry := range hook.Entry {
+		for _, change := range entry.Changes {
+			for _, st := range change.Value.Statuses {
+				if err := s.handleStatus(ctx, st); err != nil {
+					log.Printf("⚠️ Status WhatsApp %s: %v", st.ID, err)
+				}
+			}
+			for _, msg := range change.Value.Messages {
+				if err := s.handleMessage(ctx, msg); err != nil {
+					log.Printf("⚠️ Mensagem WhatsApp %s: %v", msg.ID, err)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) update(ctx context.Context, wamid, set string, value string) (*mensagem, error) {
+	var m mensagem
+	err := s.db.QueryRowContext(ctx, `
+		UPDATE mensagens_whatsapp SET `+set+` = $2, atualizado_em = NOW()
+		WHERE wamid = $1
+		RETURNING alerta_id, cuidador_id, contato_id, telefone
+	`, wamid, value).Scan(&m.alertaID, &m.cuidadorID, &m.contatoID, &m.telefone)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update WhatsApp message %s: %w", wamid, err)
+	}
+	return &m, nil
+}
+
+// handleStatus delivered/read marcam o alerta como entregue; failed registra
+// a falha e antecipa o escalonamento
+func (s *Service) handleStatus(ctx context.Context, st StatusUpdate) error {
+	m, err := s.update(ctx, st.ID, "status", st.Status)
+	if err != nil || m == nil || !m.alertaID.Valid {
+		return err
+	}
+	alertID := m.alertaID.Int64
+	r := m.recipient()
+
+	switch st.Status {
+	case "delivered", "read":
+		err := s.alerts.Transition(ctx, alertID, alerts.StatusEntregue, alerts.Event{
+			Canal:        alerts.CanalWhatsApp,
+			Destinatario: r.Label(),
+			Sucesso:      true,
+			Detalhes:     "WhatsApp " + st.Status,
+		})
+		var te *alerts.TransitionError
+		if errors.As(err, &te) {
+			return nil
+		}
+		return err
+
+	case "failed":
+		erro := "WhatsApp não entregue"
+		if len(st.Errors) > 0 {
+			erro = fmt.Sprintf("%s (%d: %s)", erro, st.Errors[0].Code, st.Errors[0].Title)
+		}
+		if err := s.alerts.RecordAttempt(ctx, alertID, alerts.Event{
+			Canal:        alerts.CanalWhatsApp,
+			Destinatario: r.Label(),
+			Sucesso:      false,
+			Erro:         erro,
+		}); err != nil {
+			return err
+		}
+
+		alert, err := s.alerts.Get(ctx, alertID)
+		if err != nil {
+			return err
+		}
+		if alerts.IsOpen(alert.Status) {
+			return s.alerts.ScheduleEscalation(ctx, alertID, time.Now())
+		}
+	}
+
+	return nil
+}
+
+// handleMessage trata as respostas "Vi" e "Estou indo" como confirmação do alerta
+func (s *Service) handleMessage(ctx context.Context, msg InboundMessage) error {
+	reply := msg.reply()
+	if reply == "" || msg.Context == nil {
+		return nil
+	}
+
+	m, err := s.update(ctx, msg.Context.ID, "resposta", reply)
+	if err != nil || m == nil || !m.alertaID.Valid {
+		return err
+	}
+	alertID := m.alertaID.Int64
+	r := m.recipient()
+
+	nota := "Vi (WhatsApp)"
+	if reply == PayloadEstouIndo {
+		nota = "Estou indo (WhatsApp)"
+	}
+
+	if m.cuidadorID.Valid {
+		err = s.alerts.Acknowledge(ctx, alertID, m.cuidadorID.Int64, msg.sentAt(), alerts.CanalWhatsApp)
+	} else {
+		err = s.alerts.Transition(ctx, alertID, alerts.StatusReconhecido, alerts.Event{
+			Canal:        alerts.CanalWhatsApp,
+			Destinatario: r.Label(),
+			Sucesso:      true,
+			Detalhes:     nota,
+		})
+	}
+
+	var te *alerts.TransitionError
+	already := errors.As(err, &te)
+	if err != nil && !already {
+		return err
+	}
+
+	if m.cuidadorID.Valid && reply == PayloadEstouIndo {
+		if err := s.alerts.AddNote(ctx, alertID, m.cuidadorID.Int64, nota); err != nil {
+			log.Printf("⚠️ %v", err)
+		}
+	}
+
+	text := "Obrigado! Alerta confirmado. Os outros cuidadores não serão mais acionados."
+	if already {
+		text = "Obrigado! Este alerta já tinha sido confirmado."
+	} else {
+		log.Printf("✅ Alerta %d confirmado por WhatsApp por %s: %s", alertID, r.Label(), nota)
+	}
+	if _, err := s.client.SendText(ctx, m.telefone, text); err != nil {
+		log.Printf("⚠️ Erro ao responder no WhatsApp: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,271 @@
+package whatsapp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StubFailSuffix números terminados assim simulam mensagem não entregue (131026)
+const StubFailSuffix = "9999"
+
+// approvedTemplates templates que o stub aceita, como se estivessem aprovados
+var approvedTemplates = map[string]bool{
+	TemplateAlerta:         true,
+	TemplateAlertaUrgente:  true,
+	TemplateChamadaPerdida: true,
+	TemplateMedicamento:    true,
+}
+
+// StubMessage mensagem recebida pelo stub
+type StubMessage struct {
+	ID       string          `json:"id"`
+	To       string          `json:"to"`
+	Type     string          `json:"type"`
+	Template json.RawMessage `json:"template,omitempty"`
+	Text     json.RawMessage `json:"text,omitempty"`
+	Status   string          `json:"status"`
+	CriadoEm time.Time       `json:"criado_em"`
+}
+
+// Stub imita a Cloud API para testes sem rede:
+//   - POST .../{phone_id}/messages aceita templates aprovados e texto
+//   - depois de Delay, envia os webhooks de status (sent, delivered ou failed)
+//   - POST /reply {"id": "<wamid>", "payload": "vi"} simula o toque num botão
+//   - GET /messages lista o que foi "enviado"
+//
+// Os webhooks vão para WebhookURL assinados com AppSecret.
+type Stub struct {
+	Token      string
+	AppSecret  string
+	WebhookURL string
+	Delay      time.Duration
+
+	mu       sync.Mutex
+	messages []StubMessage
+	client   *http.Client
+}
+
+// NewStub cria o stub com as mesmas credenciais da configuração
+func NewStub(token, appSecret, webhookURL string) *Stub {
+	return &Stub{
+		Token:      token,
+		AppSecret:  appSecret,
+		WebhookURL: webhookURL,
+		Delay:      2 * time.Second,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Messages mensagens recebidas até agora
+func (s *Stub) Messages() []StubMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]StubMessage(nil), s.messages...)
+}
+
+func (s *Stub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/messages":
+		writeStubJSON(w, http.StatusOK, s.Messages())
+	case r.Method == http.MethodPost && r.URL.Path == "/reply":
+		s.reply(w, r)
+	case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/messages"):
+		s.send(w, r)
+	default:
+		stubError(w, http.StatusNotFound, 100, "Unsupported request")
+	}
+}
+
+func (s *Stub) send(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.Token {
+		stubError(w, http.StatusUnauthorized, 190, "Invalid OAuth access token")
+		return
+	}
+
+	var req struct {
+		Product  string          `json:"messaging_product"`
+		To       string          `json:"to"`
+		Type     string          `json:"type"`
+		Template json.RawMessage `json:"template"`
+		Text     json.RawMessage `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Product != "whatsapp" || req.To == "" {
+		stubError(w, http.StatusBadRequest, 100, "Invalid parameter")
+		return
+	}
+
+	switch req.Type {
+	case "template":
+		var t struct {
+			Name string `json:"name"`
+		}
+		json.Unmarshal(req.Template, &t)
+		if !approvedTemplates[t.Name] {
+			stubError(w, http.StatusNotFound, 132001, "Template name does not exist in the translation")
+			return
+		}
+	case "text":
+	default:
+		stubError(w, http.StatusBadRequest, 131009, "Parameter value is not valid")
+		return
+	}
+
+	msg := StubMessage{
+		ID:       stubID(),
+		To:       req.To,
+		Type:     req.Type,
+		Template: req.Template,
+		Text:     req.Text,
+		Status:   "accepted",
+		CriadoEm: time.Now(),
+	}
+
+	s.mu.Lock()
+	s.messages = append(s.messages, msg)
+	s.mu.Unlock()
+
+	log.Printf("💬 [stub] %s %s para %s", msg.Type, msg.ID, msg.To)
+
+	if s.WebhookURL != "" {
+		go s.deliver(msg)
+	}
+
+	writeStubJSON(w, http.StatusOK, map[string]interface{}{
+		"messaging_product": "whatsapp",
+		"contacts":          []map[string]string{{"input": req.To, "wa_id": req.To}},
+		"messages":          []map[string]string{{"id": msg.ID}},
+	})
+}
+
+// deliver simula sent → delivered (ou failed)
+func (s *Stub) deliver(msg StubMessage) {
+	final := "delivered"
+	if strings.HasSuffix(msg.To, StubFailSuffix) {
+		final = "failed"
+	}
+
+	for _, status := range []string{"sent", final} {
+		time.Sleep(s.Delay)
+		s.setStatus(msg.ID, status)
+
+		st := map[string]interface{}{
+			"id":           msg.ID,
+			"status":       status,
+			"timestamp":    fmt.Sprintf("%d", time.Now().Unix()),
+			"recipient_id": msg.To,
+		}
+		if status == "failed" {
+			st["errors"] = []map[string]interface{}{{"code": 131026, "title": "Message undeliverable"}}
+		}
+		s.webhook(map[string]interface{}{"statuses": []interface{}{st}})
+	}
+}
+
+// reply simula o cuidador tocando num botão de resposta rápida
+func (s *Stub) reply(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID      string `json:"id"`
+		Payload string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.ID == "" || req.Payload == "" {
+		stubError(w, http.StatusBadRequest, 100, `body esperado: {"id": "<wamid>", "payload": "vi"}`)
+		return
+	}
+
+	var to string
+	for _, m := range s.Messages() {
+		if m.ID == req.ID {
+			to = m.To
+		}
+	}
+	if to == "" {
+		stubError(w, http.StatusNotFound, 100, "mensagem desconhecida")
+		return
+	}
+
+	title := "Vi"
+	if req.Payload == PayloadEstouIndo {
+		title = "Estou indo"
+	}
+
+	s.webhook(map[string]interface{}{
+		"contacts": []map[string]interface{}{{"wa_id": to}},
+		"messages": []map[string]interface{}{{
+			"from":      to,
+			"id":        stubID(),
+			"timestamp": fmt.Sprintf("%d", time.Now().Unix()),
+			"type":      "button",
+			"context":   map[string]string{"from": "stub", "id": req.ID},
+			"button":    map[string]string{"text": title, "payload": req.Payload},
+		}},
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Stub) webhook(value map[string]interface{}) {
+	if s.WebhookURL == "" {
+		return
+	}
+
+	value["messaging_product"] = "whatsapp"
+	body, _ := json.Marshal(map[string]interface{}{
+		"object": "whatsapp_business_account",
+		"entry": []interface{}{map[string]interface{}{
+			"id":      "stub",
+			"changes": []interface{}{map[string]interface{}{"field": "messages", "value": value}},
+		}},
+	})
+
+	req, err := http.NewRequest(http.MethodPost, s.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		log.Printf("❌ [stub] webhook inválido: %v", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature-256", Signature(s.AppSecret, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Printf("❌ [stub] webhook falhou: %v", err)
+		return
+	}
+	resp.Body.Close()
+	log.Printf("💬 [stub] webhook entregue (%d)", resp.StatusCode)
+}
+
+func (s *Stub) setStatus(id, status string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.messages {
+		if s.messages[i].ID == id {
+			s.messages[i].Status = status
+		}
+	}
+}
+
+func stubID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "wamid.STUB" + strings.ToUpper(hex.EncodeToString(b))
+}
+
+func stubError(w http.ResponseWriter, status, code int, message string) {
+	writeStubJSON(w, status, map[string]interface{}{
+		"error": map[string]interface{}{"message": message, "type": "OAuthException", "code": code},
+	})
+}
+
+func writeStubJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
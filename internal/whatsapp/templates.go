@@ -0,0 +1,85 @@
+package whatsapp
+
+import (
+	"strings"
+	"time"
+
+	"eva-mind/internal/alerts"
+)
+
+// Templates aprovados no WhatsApp Manager (categoria utilidade, pt_BR).
+// O texto de cada um precisa ter os parâmetros na mesma ordem usada aqui.
+const (
+	// eva_alerta: "⚠️ Alerta da EVA: {{1}} precisa de atenção. Motivo: {{2}}. Registrado às {{3}}." [Vi] [Estou indo]
+	TemplateAlerta = "eva_alerta"
+	// eva_alerta_urgente: "🚨 URGENTE: {{1}} precisa de ajuda agora. Motivo: {{2}}. Registrado às {{3}}." [Vi] [Estou indo]
+	TemplateAlertaUrgente = "eva_alerta_urgente"
+	// eva_chamada_perdida: "📵 {{1}} não atendeu a ligação da EVA das {{2}}." [Vi] [Estou indo]
+	TemplateChamadaPerdida = "eva_chamada_perdida"
+	// eva_medicamento_confirmado: "✅ {{1}} tomou o remédio: {{2}}."
+	TemplateMedicamento = "eva_medicamento_confirmado"
+)
+
+// Payloads dos botões de resposta rápida
+const (
+	PayloadVi        = "vi"
+	PayloadEstouIndo = "estou_indo"
+)
+
+// Template mensagem de template com parâmetros do corpo e botões de resposta rápida
+type Template struct {
+	Name    string
+	Params  []string
+	Buttons []string // payload de cada botão quick reply, na ordem do template
+}
+
+func (t Template) payload(language string) map[string]interface{} {
+	var components []map[string]interface{}
+
+	if len(t.Params) > 0 {
+		params := make([]map[string]string, len(t.Params))
+		for i, p := range t.Params {
+			// A API recusa parâmetros com quebras de linha ou espaços repetidos
+			params[i] = map[string]string{"type": "text", "text": strings.Join(strings.Fields(p), " ")}
+		}
+		components = append(components, map[string]interface{}{
+			"type":       "body",
+			"parameters": params,
+		})
+	}
+
+	for i, payload := range t.Buttons {
+		components = append(components, map[string]interface{}{
+			"type":       "button",
+			"sub_type":   "quick_reply",
+			"index":      i,
+			"parameters": []map[string]string{{"type": "payload", "payload": payload}},
+		})
+	}
+
+	return map[string]interface{}{
+		"name":       t.Name,
+		"language":   map[string]string{"code": language},
+		"components": components,
+	}
+}
+
+// AlertTemplate escolhe o template do alerta pelo tipo e severidade
+func AlertTemplate(alert *alerts.Alert) Template {
+	hora := alert.CriadoEm.In(time.Local).Format("15:04")
+	buttons := []string{PayloadVi, PayloadEstouIndo}
+
+	switch {
+	case alert.Tipo == "nao_atende_telefone":
+		return Template{Name: TemplateChamadaPerdida, Params: []string{alert.NomeIdoso, hora}, Buttons: buttons}
+	case alert.Severidade == "critica":
+		return Template{Name: TemplateAlertaUrgente, Params: []string{alert.NomeIdoso, alert.Mensagem, hora}, Buttons: buttons}
+	default:
+		return Template{Name: TemplateAlerta, Params: []string{alert.NomeIdoso, alert.Mensagem, hora}, Buttons: buttons}
+	}
+}
+
+// MedicationTemplate confirmação de remédio tomado, sem botões
+func MedicationTemplate(elderName, medication string) Template {
+	return Template{Name: TemplateMedicamento, Params: []string{elderName, medication}}
+}
@@ -0,0 +1,97 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"eva-mind/internal/analysis"
+	"eva-mind/internal/config"
+	"eva-mind/internal/notify"
+)
+
+// AnalysisWorker drena a fila persistente de análises pós-chamada
+type AnalysisWorker struct {
+	db        *sql.DB
+	queue     *analysis.Queue
+	processor *analysis.Processor
+	batchSize int
+}
+
+// NewAnalysisWorker cria um novo worker de análise
+func NewAnalysisWorker(cfg *config.Config, db *sql.DB, dispatcher *notify.Dispatcher) *AnalysisWorker {
+	queue := analysis.NewQueue(db, cfg.AnalysisMaxAttempts)
+
+	batchSize := cfg.AnalysisBatchSize
+	if batchSize <= 0 {
+		batchSize = 10
+	}
+
+	return &AnalysisWorker{
+		db:        db,
+		queue:     queue,
+		processor: analysis.NewProcessor(cfg, db, dispatcher, queue),
+		batchSize: batchSize,
+	}
+}
+
+// Name retorna o nome do worker
+func (aw *AnalysisWorker) Name() string {
+	return "Analysis Queue"
+}
+
+// Interval retorna o intervalo de execução (30 segundos)
+func (aw *AnalysisWorker) Interval() time.Duration {
+	return 30 * time.Second
+}
+
+// Run processa os jobs prontos da fila
+func (aw *AnalysisWorker) Run(ctx context.Context) error {
+	// Recuperar ligações que nunca chegaram à fila (ex: restart do servidor)
+	if n, err := aw.queue.EnqueueOrphans(ctx, 15*time.Minute); err != nil {
+		log.Printf("⚠️ Erro ao recuperar ligações sem análise: %v", err)
+	} else if n > 0 {
+		log.Printf("📥 %d ligação(ões) sem análise adicionada(s) à fila", n)
+	}
+
+	// Normalizar métricas de ligações analisadas antes do pipeline unificado
+	if n, err := analysis.BackfillMetrics(ctx, aw.db, 200); err != nil {
+		log.Printf("⚠️ Erro no backfill de métricas: %v", err)
+	} else if n > 0 {
+		log.Printf("📐 Métricas normalizadas para %d ligação(ões) antiga(s)", n)
+	}
+
+	jobs, err := aw.queue.Claim(ctx, aw.batchSize)
+	if err != nil {
+		return err
+	}
+
+	for _, job := range jobs {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if err := aw.processor.Process(ctx, job); err != nil {
+			status, failErr := aw.queue.Fail(ctx, job, err)
+			if failErr != nil {
+				log.Printf("❌ %v", failErr)
+			}
+
+			if status == analysis.StatusFalhaDefinitiva {
+				log.Printf("☠️ Análise do histórico %d falhou definitivamente após %d tentativas: %v",
+					job.HistoricoID, job.Tentativas, err)
+			} else {
+				log.Printf("⚠️ Análise do histórico %d falhou (tentativa %d/%d), nova tentativa em %v: %v",
+					job.HistoricoID, job.Tentativas, job.MaxTentativas, analysis.Backoff(job.Tentativas), err)
+			}
+			continue
+		}
+
+		if err := aw.queue.Complete(ctx, job); err != nil {
+			log.Printf("❌ %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/email"
+	"eva-mind/internal/preferences"
+	"eva-mind/internal/reports"
+)
+
+// CaregiverWeeklyWorker envia a cada cuidador o resumo da última semana:
+// notícias de rotina, não só alarmes
+type CaregiverWeeklyWorker struct {
+	reports      *reports.Service
+	prefs        *preferences.Service
+	emailService *email.EmailService
+}
+
+// NewCaregiverWeeklyWorker cria o worker do resumo semanal dos cuidadores
+func NewCaregiverWeeklyWorker(cfg *config.Config, db *sql.DB, emailService *email.EmailService) *CaregiverWeeklyWorker {
+	return &CaregiverWeeklyWorker{
+		reports:      reports.NewService(cfg, db),
+		prefs:        preferences.NewService(db),
+		emailService: emailService,
+	}
+}
+
+// Name retorna o nome do worker
+func (cw *CaregiverWeeklyWorker) Name() string {
+	return "Caregiver Weekly Digest"
+}
+
+// Interval retorna o intervalo de execução (1 hora). O envio é único por
+// cuidador e semana; quem estava em horário de silêncio recebe na próxima execução.
+func (cw *CaregiverWeeklyWorker) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+// Run envia o resumo da última semana encerrada a quem ainda não recebeu
+func (cw *CaregiverWeeklyWorker) Run(ctx context.Context) error {
+	weekStart := reports.LastCompleteWeek(time.Now())
+
+	targets, err := cw.reports.PendingCaregivers(ctx, weekStart)
+	if err != nil {
+		return err
+	}
+
+	// O conteúdo é o mesmo para todos os cuidadores do idoso
+	type elderWeek struct {
+		weekly *email.CaregiverWeekly
+		nome   string
+	}
+	cache := make(map[int64]*elderWeek)
+
+	now := time.Now()
+	sent := 0
+	for _, t := range targets {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		p, err := cw.prefs.Get(ctx, t.CuidadorID)
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+			continue
+		}
+		if !p.Wants(preferences.EventoResumoSemanal) || p.Quiet(now) {
+			continue
+		}
+
+		week, ok := cache[t.IdosoID]
+		if !ok {
+			weekly, nome, err := cw.reports.CaregiverWeekly(ctx, t.IdosoID, weekStart)
+			if err != nil {
+				log.Printf("❌ Erro ao montar resumo semanal do idoso %d: %v", t.IdosoID, err)
+				continue
+			}
+			week = &elderWeek{weekly: weekly, nome: nome}
+			cache[t.IdosoID] = week
+		}
+
+		env := email.Envelope{To: t.Email, IdosoID: t.IdosoID, CuidadorID: t.CuidadorID}
+		if err := cw.emailService.SendCaregiverWeekly(ctx, env, week.nome, *week.weekly); err != nil {
+			log.Printf("❌ Erro ao enviar resumo semanal ao cuidador %d: %v", t.CuidadorID, err)
+			continue
+		}
+		if err := cw.reports.MarkCaregiverSent(ctx, t, week.weekly); err != nil {
+			log.Printf("⚠️ %v", err)
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		log.Printf("🌿 %d resumo(s) semanal(is) de cuidador enviado(s) (%s)", sent, weekStart.Format("02/01/2006"))
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/config"
+	"eva-mind/internal/email"
+	"eva-mind/internal/preferences"
+	"eva-mind/internal/push"
+)
+
+// DigestWorker entrega as notificações adiadas (horário de silêncio ou modo resumo)
+type DigestWorker struct {
+	db           *sql.DB
+	prefs        *preferences.Service
+	alerts       *alerts.Service
+	pushService  *push.FirebaseService
+	emailService *email.EmailService
+}
+
+// NewDigestWorker cria o worker de resumos. pushService e emailService podem ser nil.
+func NewDigestWorker(cfg *config.Config, db *sql.DB, pushService *push.FirebaseService, emailService *email.EmailService) *DigestWorker {
+	return &DigestWorker{
+		db:           db,
+		prefs:        preferences.NewService(db),
+		alerts:       alerts.NewService(cfg, db),
+		pushService:  pushService,
+		emailService: emailService,
+	}
+}
+
+// Name retorna o nome do worker
+func (dw *DigestWorker) Name() string {
+	return "Notification Digest"
+}
+
+// Interval retorna o intervalo de execução (15 minutos)
+func (dw *DigestWorker) Interval() time.Duration {
+	return 15 * time.Minute
+}
+
+// Run envia o resumo de cada cuidador cujo silêncio acabou ou cujo intervalo venceu
+func (dw *DigestWorker) Run(ctx context.Context) error {
+	ids, err := dw.prefs.CaregiversWithPending(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	sent := 0
+	for _, cuidadorID := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		p, err := dw.prefs.Get(ctx, cuidadorID)
+		if err != nil {
+			log.Printf("⚠️ %v", err)
+			continue
+		}
+
+		items, err := dw.prefs.Pending(ctx, cuidadorID)
+		if err != nil || len(items) == 0 {
+			continue
+		}
+		if !p.DigestDue(now, items[0].CriadoEm) {
+			continue
+		}
+
+		if err := dw.deliver(ctx, cuidadorID, items); err != nil {
+			log.Printf("❌ Resumo do cuidador %d: %v", cuidadorID, err)
+			continue
+		}
+		if err := dw.prefs.MarkSent(ctx, cuidadorID, items); err != nil {
+			log.Printf("⚠️ %v", err)
+			continue
+		}
+		sent++
+	}
+
+	if sent > 0 {
+		log.Printf("🗒️ %d resumo(s) de notificações enviado(s)", sent)
+	}
+	return nil
+}
+
+// deliver envia por push (ou email, sem device token). Sem nenhum canal o
+// resumo é descartado para não acumular para sempre.
+func (dw *DigestWorker) deliver(ctx context.Context, cuidadorID int64, items []preferences.Deferred) error {
+	r, err := dw.alerts.Caregiver(ctx, cuidadorID)
+	if err != nil {
+		return err
+	}
+
+	idosoID, elderName, err := dw.elder(ctx, cuidadorID)
+	if err != nil {
+		return err
+	}
+
+	lines := make([]string, len(items))
+	for i, d := range items {
+		lines[i] = fmt.Sprintf("%s — %s", d.CriadoEm.Format("02/01 15:04"), d.Mensagem)
+	}
+
+	switch {
+	case len(r.Tokens()) > 0 && dw.pushService != nil:
+		return dw.pushService.SendNotificationDigest(r.Tokens()[0], elderName, len(items), strings.Join(lines, "\n"))
+	case r.Email != "" && dw.emailService != nil:
+		digest := make([]email.DigestItem, len(items))
+		for i, d := range items {
+			digest[i] = email.DigestItem{At: d.CriadoEm, Text: d.Mensagem}
+		}
+		env := email.Envelope{To: r.Email, Name: r.Nome, IdosoID: idosoID, CuidadorID: cuidadorID}
+		return dw.emailService.SendNotificationDigest(ctx, env, elderName, digest)
+	default:
+		log.Printf("⚠️ Cuidador %d sem canal para o resumo; %d aviso(s) descartado(s)", cuidadorID, len(items))
+		return nil
+	}
+}
+
+func (dw *DigestWorker) elder(ctx context.Context, cuidadorID int64) (int64, string, error) {
+	var id int64
+	var nome string
+	err := dw.db.QueryRowContext(ctx, `
+		SELECT i.id, COALESCE(i.nome, '')
+		FROM cuidadores c
+		JOIN idosos i ON i.id = c.idoso_id
+		WHERE c.id = $1
+	`, cuidadorID).Scan(&id, &nome)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to load elder of caregiver %d: %w", cuidadorID, err)
+	}
+	return id, nome, nil
+}
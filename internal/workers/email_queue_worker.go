@@ -0,0 +1,56 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"eva-mind/internal/email"
+)
+
+// emailQueueBatchSize emails reservados por execução (uma conexão SMTP por lote)
+const emailQueueBatchSize = 50
+
+// EmailQueueWorker envia os emails da fila com reenvio e backoff
+type EmailQueueWorker struct {
+	email *email.EmailService
+	queue *email.Queue
+}
+
+// NewEmailQueueWorker cria o worker da fila de email
+func NewEmailQueueWorker(db *sql.DB, emailService *email.EmailService) *EmailQueueWorker {
+	return &EmailQueueWorker{email: emailService, queue: email.NewQueue(db)}
+}
+
+// Name retorna o nome do worker
+func (ew *EmailQueueWorker) Name() string {
+	return "Email Queue"
+}
+
+// Interval retorna o intervalo de execução (10 segundos)
+func (ew *EmailQueueWorker) Interval() time.Duration {
+	return 10 * time.Second
+}
+
+// Run expira os emails de alerta vencidos e envia os que estão prontos
+func (ew *EmailQueueWorker) Run(ctx context.Context) error {
+	for {
+		items, err := ew.queue.Claim(ctx, emailQueueBatchSize)
+		if err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+
+		if err := ew.email.DeliverQueued(ctx, ew.queue, items); err != nil {
+			log.Printf("❌ %v", err)
+			return nil
+		}
+
+		if len(items) < emailQueueBatchSize {
+			return nil
+		}
+	}
+}
@@ -223,27 +223,28 @@ func (pw *PatternWorker) detectMoodPattern(ctx context.Context, idosoID int) (*B
 	return nil, nil
 }
 
-// detectMedicationPattern detecta padrões de adesão à medicação
+// detectMedicationPattern detecta padrões de adesão à medicação a partir da
+// métrica normalizada de cada ligação analisada (medicamento_tomado)
 func (pw *PatternWorker) detectMedicationPattern(ctx context.Context, idosoID int) (*BehaviorPattern, error) {
 	query := `
 		SELECT 
-			COUNT(*) as total_agendamentos,
+			COUNT(*) as ligacoes_analisadas,
 			COUNT(CASE WHEN medicamento_tomado = true THEN 1 END) as medicamentos_tomados
-		FROM agendamentos
+		FROM historico_ligacoes
 		WHERE idoso_id = $1
-		  AND tipo = 'lembrete_medicamento'
-		  AND data_hora_agendada > NOW() - INTERVAL '30 days'
+		  AND inicio_chamada > NOW() - INTERVAL '30 days'
+		  AND sentimento_geral IS NOT NULL
 	`
 
-	var totalAgendamentos, medicamentosTomados int
+	var ligacoesAnalisadas, medicamentosTomados int
 
-	err := pw.db.QueryRowContext(ctx, query, idosoID).Scan(&totalAgendamentos, &medicamentosTomados)
+	err := pw.db.QueryRowContext(ctx, query, idosoID).Scan(&ligacoesAnalisadas, &medicamentosTomados)
 	if err != nil {
 		return nil, err
 	}
 
-	if totalAgendamentos >= 10 {
-		taxaAdesao := float64(medicamentosTomados) / float64(totalAgendamentos)
+	if ligacoesAnalisadas >= 10 {
+		taxaAdesao := float64(medicamentosTomados) / float64(ligacoesAnalisadas)
 
 		var descricao string
 		if taxaAdesao >= 0.9 {
@@ -263,7 +264,7 @@ func (pw *PatternWorker) detectMedicationPattern(ctx context.Context, idosoID in
 			Frequencia: "diario",
 			Confianca:  0.90,
 			DadosEstatisticos: map[string]interface{}{
-				"total_agendamentos":   totalAgendamentos,
+				"ligacoes_analisadas":  ligacoesAnalisadas,
 				"medicamentos_tomados": medicamentosTomados,
 				"taxa_adesao":          taxaAdesao,
 				"dias_analisados":      30,
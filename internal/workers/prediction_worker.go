@@ -7,16 +7,19 @@ import (
 	"fmt"
 	"log"
 	"time"
+
+	"eva-mind/internal/webhooks"
 )
 
 // PredictionWorker prediz emergências
 type PredictionWorker struct {
-	db *sql.DB
+	db    *sql.DB
+	hooks *webhooks.Service
 }
 
 // NewPredictionWorker cria um novo worker de predições
 func NewPredictionWorker(db *sql.DB) *PredictionWorker {
-	return &PredictionWorker{db: db}
+	return &PredictionWorker{db: db, hooks: webhooks.NewService(db)}
 }
 
 // Name retorna o nome do worker
@@ -108,16 +111,18 @@ func (pw *PredictionWorker) predictDepression(ctx context.Context, idosoID int)
 		SELECT 
 			COUNT(CASE WHEN sentimento_geral IN ('triste', 'apatico') THEN 1 END) as sentimentos_negativos,
 			COUNT(*) as total_ligacoes,
-			AVG(CASE WHEN sentimento_geral IN ('triste', 'apatico') THEN sentimento_intensidade ELSE 0 END) as intensidade_media
+			COALESCE(AVG(CASE WHEN sentimento_geral IN ('triste', 'apatico') THEN sentimento_intensidade ELSE 0 END), 0) as intensidade_media,
+			COUNT(CASE WHEN solidao = true THEN 1 END) as episodios_solidao
 		FROM historico_ligacoes
 		WHERE idoso_id = $1
 		  AND inicio_chamada > NOW() - INTERVAL '14 days'
+		  AND sentimento_geral IS NOT NULL
 	`
 
-	var negativos, total int
+	var negativos, total, solidao int
 	var intensidade float64
 
-	err := pw.db.QueryRowContext(ctx, query, idosoID).Scan(&negativos, &total, &intensidade)
+	err := pw.db.QueryRowContext(ctx, query, idosoID).Scan(&negativos, &total, &intensidade, &solidao)
 	if err != nil {
 		return nil, err
 	}
@@ -145,21 +150,27 @@ func (pw *PredictionWorker) predictDepression(ctx context.Context, idosoID int)
 
 	// Só salvar se risco for médio ou superior
 	if probabilidade >= 0.30 {
+		fatores := []string{
+			fmt.Sprintf("%.0f%% de sentimentos negativos nos últimos 14 dias", percentualNegativo*100),
+			fmt.Sprintf("Intensidade média de tristeza: %.1f/10", intensidade),
+			fmt.Sprintf("Total de %d ligações analisadas", total),
+		}
+		if solidao > 0 {
+			fatores = append(fatores, fmt.Sprintf("Solidão relatada em %d ligação(ões)", solidao))
+		}
+
 		prediction := &EmergencyPrediction{
-			IdosoID:        idosoID,
-			TipoEmergencia: "depressao_severa",
-			Probabilidade:  probabilidade,
-			NivelRisco:     nivelRisco,
-			FatoresContribuintes: []string{
-				fmt.Sprintf("%.0f%% de sentimentos negativos nos últimos 14 dias", percentualNegativo*100),
-				fmt.Sprintf("Intensidade média de tristeza: %.1f/10", intensidade),
-				fmt.Sprintf("Total de %d ligações analisadas", total),
-			},
+			IdosoID:              idosoID,
+			TipoEmergencia:       "depressao_severa",
+			Probabilidade:        probabilidade,
+			NivelRisco:           nivelRisco,
+			FatoresContribuintes: fatores,
 			SinaisDetectados: map[string]interface{}{
 				"sentimentos_negativos": negativos,
 				"total_ligacoes":        total,
 				"percentual_negativo":   percentualNegativo,
 				"intensidade_media":     intensidade,
+				"episodios_solidao":     solidao,
 			},
 			Recomendacoes: []string{
 				"Agendar consulta com psicólogo ou psiquiatra",
@@ -179,12 +190,13 @@ func (pw *PredictionWorker) predictDepression(ctx context.Context, idosoID int)
 func (pw *PredictionWorker) predictConfusion(ctx context.Context, idosoID int) (*EmergencyPrediction, error) {
 	query := `
 		SELECT 
-			COUNT(CASE WHEN sentimento_geral = 'confuso' THEN 1 END) as episodios_confusao,
+			COUNT(CASE WHEN confusao = true THEN 1 END) as episodios_confusao,
 			COUNT(*) as total_ligacoes,
-			AVG(CASE WHEN sentimento_geral = 'confuso' THEN sentimento_intensidade ELSE 0 END) as intensidade_media
+			COALESCE(AVG(CASE WHEN confusao = true THEN sentimento_intensidade ELSE 0 END), 0) as intensidade_media
 		FROM historico_ligacoes
 		WHERE idoso_id = $1
 		  AND inicio_chamada > NOW() - INTERVAL '7 days'
+		  AND sentimento_geral IS NOT NULL
 	`
 
 	var confusao, total int
@@ -350,7 +362,7 @@ func (pw *PredictionWorker) predictFallRisk(ctx context.Context, idosoID int) (*
 	return nil, nil
 }
 
-// savePrediction salva predição no banco
+// savePrediction salva predição no banco e avisa as integrações da entidade
 func (pw *PredictionWorker) savePrediction(ctx context.Context, pred *EmergencyPrediction) error {
 	fatoresJSON, _ := json.Marshal(pred.FatoresContribuintes)
 	sinaisJSON, _ := json.Marshal(pred.SinaisDetectados)
@@ -363,9 +375,11 @@ func (pw *PredictionWorker) savePrediction(ctx context.Context, pred *EmergencyP
 			validade_ate
 		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW() + INTERVAL '7 days')
 		ON CONFLICT DO NOTHING
+		RETURNING id
 	`
 
-	_, err := pw.db.ExecContext(ctx, query,
+	var id int64
+	err := pw.db.QueryRowContext(ctx, query,
 		pred.IdosoID,
 		pred.TipoEmergencia,
 		pred.Probabilidade,
@@ -373,12 +387,29 @@ func (pw *PredictionWorker) savePrediction(ctx context.Context, pred *EmergencyP
 		fatoresJSON,
 		sinaisJSON,
 		recomendacoesJSON,
-	)
+	).Scan(&id)
 
-	if err == nil {
-		log.Printf("⚠️ Predição '%s' salva para idoso %d (risco: %s, prob: %.0f%%)",
-			pred.TipoEmergencia, pred.IdosoID, pred.NivelRisco, pred.Probabilidade*100)
+	// Predição já existente (ON CONFLICT): nada novo para publicar
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("⚠️ Predição '%s' salva para idoso %d (risco: %s, prob: %.0f%%)",
+		pred.TipoEmergencia, pred.IdosoID, pred.NivelRisco, pred.Probabilidade*100)
+
+	if err := pw.hooks.Publish(ctx, int64(pred.IdosoID), webhooks.EventoPredicaoGerada, map[string]interface{}{
+		"predicao_id":           id,
+		"tipo_emergencia":       pred.TipoEmergencia,
+		"probabilidade":         pred.Probabilidade,
+		"nivel_risco":           pred.NivelRisco,
+		"fatores_contribuintes": pred.FatoresContribuintes,
+		"recomendacoes":         pred.Recomendacoes,
+	}); err != nil {
+		log.Printf("⚠️ Webhook da predição %d: %v", id, err)
 	}
 
-	return err
+	return nil
 }
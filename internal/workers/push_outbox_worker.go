@@ -0,0 +1,57 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"eva-mind/internal/push"
+)
+
+// pushOutboxBatchSize envios reservados por execução
+const pushOutboxBatchSize = 100
+
+// PushOutboxWorker reenvia os pushes que falharam por erro transitório do FCM
+type PushOutboxWorker struct {
+	push   *push.FirebaseService
+	outbox *push.Outbox
+}
+
+// NewPushOutboxWorker cria o worker da fila de push
+func NewPushOutboxWorker(db *sql.DB, pushService *push.FirebaseService) *PushOutboxWorker {
+	return &PushOutboxWorker{push: pushService, outbox: push.NewOutbox(db)}
+}
+
+// Name retorna o nome do worker
+func (pw *PushOutboxWorker) Name() string {
+	return "Push Outbox"
+}
+
+// Interval retorna o intervalo de execução (5 segundos, por causa do TTL de 45s das chamadas)
+func (pw *PushOutboxWorker) Interval() time.Duration {
+	return 5 * time.Second
+}
+
+// Run expira os pushes vencidos e reenvia os que venceram o backoff
+func (pw *PushOutboxWorker) Run(ctx context.Context) error {
+	for {
+		items, err := pw.outbox.Claim(ctx, pushOutboxBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range items {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := pw.push.DeliverQueued(ctx, pw.outbox, item); err != nil {
+				log.Printf("❌ %v", err)
+			}
+		}
+
+		if len(items) < pushOutboxBatchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"eva-mind/internal/config"
+	"eva-mind/internal/email"
+	"eva-mind/internal/reports"
+	"eva-mind/internal/subscription"
+)
+
+// ReportWorker gera o resumo clínico semanal de cada idoso
+type ReportWorker struct {
+	db            *sql.DB
+	reports       *reports.Service
+	subscriptions *subscription.SubscriptionService
+	emailService  *email.EmailService
+}
+
+// NewReportWorker cria um novo worker de relatórios. emailService pode ser nil
+// (relatórios ficam disponíveis apenas pela API).
+func NewReportWorker(cfg *config.Config, db *sql.DB, emailService *email.EmailService) *ReportWorker {
+	return &ReportWorker{
+		db:            db,
+		reports:       reports.NewService(cfg, db),
+		subscriptions: subscription.NewSubscriptionService(db),
+		emailService:  emailService,
+	}
+}
+
+// Name retorna o nome do worker
+func (rw *ReportWorker) Name() string {
+	return "Weekly Clinical Report"
+}
+
+// Interval retorna o intervalo de execução (1 hora). A geração é idempotente
+// por semana, então execuções extras apenas completam o que faltou.
+func (rw *ReportWorker) Interval() time.Duration {
+	return 1 * time.Hour
+}
+
+// Run gera os relatórios da última semana encerrada e envia os pendentes
+func (rw *ReportWorker) Run(ctx context.Context) error {
+	weekStart := reports.LastCompleteWeek(time.Now())
+
+	idosos, err := rw.getPendingIdosos(ctx, weekStart)
+	if err != nil {
+		return err
+	}
+
+	if len(idosos) > 0 {
+		log.Printf("📋 Gerando resumo semanal (%s) para %d idoso(s)...", weekStart.Format("02/01/2006"), len(idosos))
+	}
+
+	generated := 0
+	for _, idosoID := range idosos {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		if _, err := rw.reports.Generate(ctx, idosoID, weekStart); err != nil {
+			log.Printf("❌ Erro ao gerar relatório do idoso %d: %v", idosoID, err)
+			continue
+		}
+		generated++
+	}
+
+	if generated > 0 {
+		log.Printf("✅ %d resumo(s) semanal(is) gerado(s)", generated)
+	}
+
+	return rw.sendPending(ctx)
+}
+
+// getPendingIdosos retorna idosos ativos sem relatório da semana e com a
+// feature relatorios_detalhados (idosos sem entidade não passam pela verificação de plano)
+func (rw *ReportWorker) getPendingIdosos(ctx context.Context, weekStart time.Time) ([]int64, error) {
+	rows, err := rw.db.QueryContext(ctx, `
+		SELECT i.id, COALESCE(i.entidade_nome, '')
+		FROM idosos i
+		WHERE i.ativo = true
+		  AND NOT EXISTS (
+			SELECT 1 FROM relatorios_semanais r
+			WHERE r.idoso_id = i.id AND r.semana_inicio = $1
+		  )
+	`, weekStart)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	features := make(map[string]bool)
+	var idosos []int64
+
+	for rows.Next() {
+		var id int64
+		var entidade string
+		if err := rows.Scan(&id, &entidade); err != nil {
+			continue
+		}
+
+		if entidade != "" {
+			allowed, checked := features[entidade]
+			if !checked {
+				allowed, _ = rw.subscriptions.CheckFeature(entidade, "relatorios_detalhados")
+				features[entidade] = allowed
+			}
+			if !allowed {
+				continue
+			}
+		}
+
+		idosos = append(idosos, id)
+	}
+
+	return idosos, rows.Err()
+}
+
+// sendPending envia por email os relatórios recentes ainda não enviados
+func (rw *ReportWorker) sendPending(ctx context.Context) error {
+	if rw.emailService == nil {
+		return nil
+	}
+
+	rows, err := rw.db.QueryContext(ctx, `
+		SELECT id FROM relatorios_semanais
+		WHERE enviado_em IS NULL AND criado_em > NOW() - INTERVAL '14 days'
+	`)
+	if err != nil {
+		return err
+	}
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		report, err := rw.reports.Get(ctx, id)
+		if err != nil {
+			log.Printf("⚠️ Relatório %d não encontrado: %v", id, err)
+			continue
+		}
+		if err := rw.reports.Send(ctx, rw.emailService, report); err != nil {
+			log.Printf("❌ %v", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package workers
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	"eva-mind/internal/webhooks"
+)
+
+// webhookBatchSize entregas reservadas por execução
+const webhookBatchSize = 50
+
+// WebhookWorker envia as entregas de webhooks pendentes e as que venceram o backoff
+type WebhookWorker struct {
+	service *webhooks.Service
+}
+
+// NewWebhookWorker cria o worker de webhooks
+func NewWebhookWorker(db *sql.DB) *WebhookWorker {
+	return &WebhookWorker{service: webhooks.NewService(db)}
+}
+
+// Name retorna o nome do worker
+func (ww *WebhookWorker) Name() string {
+	return "Webhook Delivery"
+}
+
+// Interval retorna o intervalo de execução (30 segundos)
+func (ww *WebhookWorker) Interval() time.Duration {
+	return 30 * time.Second
+}
+
+// Run envia um lote de entregas; lotes cheios são seguidos de outro na mesma execução
+func (ww *WebhookWorker) Run(ctx context.Context) error {
+	for {
+		deliveries, err := ww.service.Claim(ctx, webhookBatchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, d := range deliveries {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := ww.service.Deliver(ctx, d); err != nil {
+				log.Printf("❌ %v", err)
+			}
+		}
+
+		if len(deliveries) < webhookBatchSize {
+			return nil
+		}
+	}
+}
@@ -4,18 +4,37 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 
+	"eva-mind/internal/alerts"
+	"eva-mind/internal/analysis"
+	"eva-mind/internal/calls"
 	"eva-mind/internal/config"
 	"eva-mind/internal/database"
+	"eva-mind/internal/devices"
+	"eva-mind/internal/email"
 	"eva-mind/internal/gemini"
+	"eva-mind/internal/handlers"
+	"eva-mind/internal/metrics"
+	"eva-mind/internal/middleware"
+	"eva-mind/internal/notify"
+	"eva-mind/internal/preferences"
 	"eva-mind/internal/push"
+	"eva-mind/internal/reports"
 	"eva-mind/internal/scheduler"
+	"eva-mind/internal/sms"
+	"eva-mind/internal/subscription"
+	"eva-mind/internal/voice"
+	"eva-mind/internal/webhooks"
+	"eva-mind/internal/whatsapp"
+	"eva-mind/internal/workers"
 
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -29,12 +48,15 @@ type SignalingServer struct {
 	cfg         *config.Config
 	pushService *push.FirebaseService
 	db          *database.DB
+	calls       *calls.Service
+	analysis    *analysis.Queue
 }
 
 type PCMClient struct {
 	Conn         *websocket.Conn
 	CPF          string
 	IdosoID      int64
+	SessionID    string // chamada agendada que esta conexão atendeu (vazio = iniciada pelo idoso)
 	GeminiClient *gemini.Client
 	SendCh       chan []byte
 	mu           sync.Mutex
@@ -49,6 +71,9 @@ var (
 	db              *database.DB
 	pushService     *push.FirebaseService
 	signalingServer *SignalingServer
+	analysisQueue   *analysis.Queue
+	pushOutbox      *push.Outbox
+	emailQueue      *email.Queue
 	startTime       time.Time
 )
 
@@ -63,6 +88,8 @@ func NewSignalingServer(cfg *config.Config, db *database.DB, pushService *push.F
 		cfg:         cfg,
 		pushService: pushService,
 		db:          db,
+		calls:       calls.NewService(db.GetConnection()),
+		analysis:    analysis.NewQueue(db.GetConnection(), cfg.AnalysisMaxAttempts),
 	}
 }
 
@@ -85,12 +112,25 @@ func main() {
 	if err != nil {
 		log.Printf("⚠️ Firebase warning: %v", err)
 	} else {
+		pushService.EnableTokenCleanup(db.GetConnection()).EnableOutbox(db.GetConnection())
 		log.Printf("✅ Firebase initialized")
 	}
 
+	emailService, err := email.NewEmailService(cfg)
+	if err != nil {
+		log.Printf("⚠️ Email warning: %v", err)
+		emailService = nil
+	} else {
+		emailService.EnableLocalization(db.GetConnection()).EnableQueue(db.GetConnection())
+	}
+
 	signalingServer = NewSignalingServer(cfg, db, pushService)
 
-	sch, err := scheduler.NewScheduler(cfg, db.GetConnection())
+	// Um único dispatcher (canais, preferências e fallback) para alertas da IA,
+	// análises, scheduler e escalonamento. O email entra pela fila, se configurado.
+	dispatcher := notify.New(cfg, db.GetConnection(), pushService)
+
+	sch, err := scheduler.NewScheduler(cfg, db.GetConnection(), pushService, dispatcher)
 	if err != nil {
 		log.Printf("⚠️ Scheduler error: %v", err)
 	} else {
@@ -98,13 +138,143 @@ func main() {
 		log.Printf("✅ Scheduler started")
 	}
 
+	analysisQueue = analysis.NewQueue(db.GetConnection(), cfg.AnalysisMaxAttempts)
+	pushOutbox = push.NewOutbox(db.GetConnection())
+	emailQueue = email.NewQueue(db.GetConnection())
+
+	workerManager := workers.NewWorkerManager(db.GetConnection())
+	workerManager.RegisterWorker(workers.NewAnalysisWorker(cfg, db.GetConnection(), dispatcher))
+	workerManager.RegisterWorker(workers.NewPatternWorker(db.GetConnection()))
+	workerManager.RegisterWorker(workers.NewPredictionWorker(db.GetConnection()))
+	workerManager.RegisterWorker(workers.NewReportWorker(cfg, db.GetConnection(), emailService))
+	workerManager.RegisterWorker(workers.NewDigestWorker(cfg, db.GetConnection(), pushService, emailService))
+	workerManager.RegisterWorker(workers.NewWebhookWorker(db.GetConnection()))
+	if pushService != nil {
+		workerManager.RegisterWorker(workers.NewPushOutboxWorker(db.GetConnection(), pushService))
+	}
+	if emailService != nil {
+		workerManager.RegisterWorker(workers.NewEmailQueueWorker(db.GetConnection(), emailService))
+		workerManager.RegisterWorker(workers.NewCaregiverWeeklyWorker(cfg, db.GetConnection(), emailService))
+	}
+	workerManager.Start()
+	defer workerManager.Stop()
+
 	router := mux.NewRouter()
 	router.HandleFunc("/wss", signalingServer.HandleWebSocket)
 	router.HandleFunc("/ws/pcm", signalingServer.HandleWebSocket)
 
 	api := router.PathPrefix("/api").Subrouter()
+
+	// Rotas de operação (monitoramento, reprocessamento, políticas e webhooks da
+	// entidade) não têm uma pessoa por trás: a credencial dos apps não se aplica.
+	// Elas ficam atrás do gateway/rede interna; as que alteram dados exigem o
+	// token de operação e webhooks ainda exigem a feature do plano.
+	requireAdmin := middleware.RequireAdmin(cfg.AdminAPIToken)
 	api.HandleFunc("/stats", statsHandler).Methods("GET")
 	api.HandleFunc("/health", healthCheckHandler).Methods("GET")
+	api.Handle("/analises/{historico_id}/reprocessar", requireAdmin(http.HandlerFunc(reprocessAnalysisHandler))).Methods("POST")
+
+	var tokenChecker devices.TokenChecker
+	if pushService != nil {
+		tokenChecker = pushService
+	}
+	devicesService := devices.NewService(db.GetConnection(), tokenChecker)
+	appAuth := middleware.NewAppAuthMiddleware(devicesService)
+
+	alertService := alerts.NewService(cfg, db.GetConnection())
+	alertsHandler := handlers.NewAlertsHandler(alertService)
+	escalationHandler := handlers.NewEscalationHandler(alertService, alerts.NewEscalator(cfg, db.GetConnection(), dispatcher))
+	api.Handle("/politicas-escalonamento", requireAdmin(http.HandlerFunc(escalationHandler.SavePolicy))).Methods("POST")
+
+	// Dados do idoso: o próprio idoso ou um cuidador ativo dele (credencial do app)
+	metricsHandler := handlers.NewMetricsHandler(metrics.NewService(db.GetConnection()))
+	reportsHandler := handlers.NewReportsHandler(reports.NewService(cfg, db.GetConnection()), devicesService)
+	elderAPI := api.PathPrefix("/idosos/{id}").Subrouter()
+	elderAPI.Use(appAuth.RequireApp, appAuth.RequireElder)
+	elderAPI.HandleFunc("/metrics", metricsHandler.GetElderMetrics).Methods("GET")
+	elderAPI.HandleFunc("/relatorios", reportsHandler.ListElderReports).Methods("GET")
+	elderAPI.HandleFunc("/politicas-escalonamento", escalationHandler.GetElderPolicies).Methods("GET")
+	elderAPI.HandleFunc("/politicas-escalonamento", escalationHandler.SaveElderPolicy).Methods("POST")
+
+	reportsAPI := api.PathPrefix("/relatorios").Subrouter()
+	reportsAPI.Use(appAuth.RequireApp)
+	reportsAPI.HandleFunc("/{id}", reportsHandler.GetReport).Methods("GET")
+
+	// Rotas do app do cuidador: o cuidador vem da credencial, nunca do corpo
+	alertsAPI := api.PathPrefix("/alerts").Subrouter()
+	alertsAPI.Use(appAuth.RequireApp)
+	alertsAPI.HandleFunc("/pending", alertsHandler.GetPending).Methods("GET")
+	alertsAPI.HandleFunc("/{id}", alertsHandler.GetAlert).Methods("GET")
+	alertsAPI.HandleFunc("/{id}/acknowledge", alertsHandler.Acknowledge).Methods("POST")
+	alertsAPI.HandleFunc("/{id}/resolve", alertsHandler.Resolve).Methods("POST")
+	alertsAPI.HandleFunc("/{id}/notes", alertsHandler.AddNote).Methods("POST")
+
+	preferencesHandler := handlers.NewPreferencesHandler(preferences.NewService(db.GetConnection()))
+	caregiversAPI := api.PathPrefix("/cuidadores").Subrouter()
+	caregiversAPI.Use(appAuth.RequireApp)
+	caregiversAPI.HandleFunc("/{id}/alertas", alertsHandler.ListCaregiverAlerts).Methods("GET")
+	caregiversAPI.HandleFunc("/{id}/preferencias", preferencesHandler.Get).Methods("GET")
+	caregiversAPI.HandleFunc("/{id}/preferencias", preferencesHandler.Save).Methods("PUT")
+
+	var vapidPublica string
+	if webPush, err := notify.NewWebPush(cfg, db.GetConnection()); err != nil {
+		log.Printf("⚠️ Web Push indisponível: %v", err)
+	} else if webPush != nil {
+		vapidPublica = webPush.PublicKey()
+	}
+	devicesHandler := handlers.NewDevicesHandler(devicesService, vapidPublica)
+	appAPI := api.PathPrefix("/dispositivos").Subrouter()
+	appAPI.Use(appAuth.RequireApp)
+	appAPI.HandleFunc("", devicesHandler.Register).Methods("POST")
+	appAPI.HandleFunc("", devicesHandler.List).Methods("GET")
+	appAPI.HandleFunc("/webpush", devicesHandler.WebPushKey).Methods("GET")
+	appAPI.HandleFunc("/{id}", devicesHandler.Remove).Methods("DELETE")
+
+	alertsAppAPI := api.PathPrefix("/alertas").Subrouter()
+	alertsAppAPI.Use(appAuth.RequireApp)
+	alertsAppAPI.HandleFunc("/{id}/resposta", alertsHandler.Respond).Methods("POST")
+
+	callsHandler := handlers.NewCallsHandler(calls.NewService(db.GetConnection()))
+	callsAPI := api.PathPrefix("/chamadas").Subrouter()
+	callsAPI.Use(appAuth.RequireApp)
+	callsAPI.HandleFunc("/{sessao}", callsHandler.Get).Methods("GET")
+	callsAPI.HandleFunc("/{sessao}/entregue", callsHandler.Delivered).Methods("POST")
+	callsAPI.HandleFunc("/{sessao}/recusada", callsHandler.Declined).Methods("POST")
+
+	subscriptionMiddleware := middleware.NewSubscriptionMiddleware(subscription.NewSubscriptionService(db.GetConnection()))
+	webhooksHandler := handlers.NewWebhooksHandler(webhooks.NewService(db.GetConnection()))
+	hooks := api.PathPrefix("/webhooks").Subrouter()
+	hooks.Use(subscriptionMiddleware.RequireFeature(webhooks.Feature))
+	hooks.HandleFunc("", webhooksHandler.List).Methods("GET")
+	hooks.HandleFunc("", webhooksHandler.Create).Methods("POST")
+	hooks.HandleFunc("/entregas/{id}", webhooksHandler.GetDelivery).Methods("GET")
+	hooks.HandleFunc("/entregas/{id}/reenviar", webhooksHandler.Redeliver).Methods("POST")
+	hooks.HandleFunc("/{id}", webhooksHandler.Delete).Methods("DELETE")
+	hooks.HandleFunc("/{id}/ping", webhooksHandler.Ping).Methods("POST")
+	hooks.HandleFunc("/{id}/entregas", webhooksHandler.Deliveries).Methods("GET")
+
+	if cfg.EnableSMSFallback {
+		if smsService, err := sms.NewService(cfg, db.GetConnection()); err == nil {
+			api.HandleFunc("/sms/status", handlers.NewSMSHandler(smsService).Status).Methods("POST")
+		}
+	}
+
+	if cfg.EnableCallFallback {
+		if voiceService, err := voice.NewService(cfg, db.GetConnection()); err == nil {
+			voiceHandler := handlers.NewVoiceHandler(voiceService)
+			api.HandleFunc("/voice/chamadas/{id}/twiml", voiceHandler.TwiML).Methods("POST")
+			api.HandleFunc("/voice/chamadas/{id}/gather", voiceHandler.Gather).Methods("POST")
+			api.HandleFunc("/voice/chamadas/{id}/status", voiceHandler.Status).Methods("POST")
+		}
+	}
+
+	if cfg.EnableWhatsApp {
+		if whatsappService, err := whatsapp.NewService(cfg, db.GetConnection()); err == nil {
+			whatsappHandler := handlers.NewWhatsAppHandler(whatsappService)
+			api.HandleFunc("/whatsapp/webhook", whatsappHandler.Verify).Methods("GET")
+			api.HandleFunc("/whatsapp/webhook", whatsappHandler.Webhook).Methods("POST")
+		}
+	}
 
 	router.PathPrefix("/").Handler(http.FileServer(http.Dir("./web")))
 
@@ -169,7 +339,9 @@ func (s *SignalingServer) handleClientMessages(client *PCMClient) {
 					s.sendJSON(client, map[string]string{"type": "error", "message": "Register first"})
 					continue
 				}
-				s.startGeminiSession(client)
+				// session_id chega no push da chamada; apps antigos não o enviam
+				sessionID, _ := data["session_id"].(string)
+				s.startGeminiSession(client, sessionID)
 			case "hangup":
 				log.Printf("📴 Hangup from %s", client.CPF)
 				return
@@ -213,12 +385,29 @@ func (s *SignalingServer) registerClient(client *PCMClient, data map[string]inte
 	log.Printf("✅ Cliente registrado: %s", cpf)
 }
 
-func (s *SignalingServer) startGeminiSession(client *PCMClient) {
+func (s *SignalingServer) startGeminiSession(client *PCMClient, sessionID string) {
 	log.Printf("🤖 Iniciando Gemini para %s", client.CPF)
 
+	// Liga a conexão à chamada agendada, para ela não ser dada como perdida
+	call, err := s.calls.Answer(client.ctx, client.IdosoID, sessionID)
+	switch {
+	case err == nil:
+		client.SessionID = call.SessaoID
+	case errors.Is(err, calls.ErrNotFound):
+		log.Printf("ℹ️ Conversa iniciada pelo idoso %s, sem chamada agendada", client.CPF)
+	default:
+		log.Printf("⚠️ Erro ao vincular chamada %q: %v", sessionID, err)
+	}
+
 	gemClient, err := gemini.NewClient(client.ctx, s.cfg)
 	if err != nil {
 		log.Printf("❌ Gemini error: %v", err)
+		if client.SessionID != "" {
+			if err := s.calls.Fail(context.Background(), client.IdosoID, client.SessionID, "erro ao iniciar a IA"); err != nil {
+				log.Printf("⚠️ Erro ao encerrar chamada %s: %v", client.SessionID, err)
+			}
+			client.SessionID = ""
+		}
 		s.sendJSON(client, map[string]string{"type": "error", "message": "IA error"})
 		return
 	}
@@ -232,7 +421,7 @@ func (s *SignalingServer) startGeminiSession(client *PCMClient) {
 	go s.listenGemini(client)
 
 	client.active = true
-	s.sendJSON(client, map[string]string{"type": "session_created", "status": "ready"})
+	s.sendJSON(client, map[string]string{"type": "session_created", "status": "ready", "session_id": client.SessionID})
 	log.Printf("✅ Sessão criada: %s", client.CPF)
 }
 
@@ -360,6 +549,19 @@ func (s *SignalingServer) cleanupClient(client *PCMClient) {
 		client.GeminiClient.Close()
 	}
 
+	if client.SessionID != "" {
+		if err := s.calls.Complete(context.Background(), client.IdosoID, client.SessionID); err != nil {
+			log.Printf("⚠️ Erro ao concluir chamada %s: %v", client.SessionID, err)
+		}
+	}
+
+	// Conversa encerrada: a análise entra na fila (o AnalysisWorker processa)
+	if historicoID, err := s.analysis.EnqueueClosed(context.Background(), client.IdosoID); err != nil {
+		log.Printf("❌ [ANÁLISE] %v", err)
+	} else if historicoID != 0 {
+		log.Printf("📥 [ANÁLISE] Histórico %d enfileirado para análise", historicoID)
+	}
+
 	log.Printf("✅ Desconectado: %s", client.CPF)
 }
 
@@ -380,8 +582,8 @@ func (s *SignalingServer) GetActiveClientsCount() int {
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Entity-Name")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusNoContent)
@@ -404,13 +606,48 @@ func statsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	var filaAnalise map[string]int
+	var filaPush *push.OutboxStats
+	var filaEmail *email.QueueStats
+	if dbStatus {
+		filaAnalise, _ = analysisQueue.Stats(r.Context())
+		filaPush, _ = pushOutbox.Stats(r.Context())
+		filaEmail, _ = emailQueue.Stats(r.Context())
+	}
+
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"active_clients": signalingServer.GetActiveClientsCount(),
 		"uptime":         time.Since(startTime).String(),
 		"db_status":      dbStatus,
+		"fila_analise":   filaAnalise,
+		"fila_push":      filaPush,
+		"fila_email":     filaEmail,
 	})
 }
 
+// reprocessAnalysisHandler devolve uma ligação à fila de análise (ex: após falha
+// definitiva). Gasta cota do modelo e sobrescreve a análise: exige o token de operação.
+func reprocessAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	historicoID, err := strconv.ParseInt(mux.Vars(r)["historico_id"], 10, 64)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "historico_id inválido"})
+		return
+	}
+
+	if err := analysisQueue.Requeue(r.Context(), historicoID); err != nil {
+		log.Printf("❌ Erro ao reprocessar análise %d: %v", historicoID, err)
+		w.WriteHeader(http.StatusInternalServerError)
+		json.NewEncoder(w).Encode(map[string]string{"error": "falha ao reenfileirar análise"})
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]interface{}{"historico_id": historicoID, "status": analysis.StatusPendente})
+}
+
 func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 
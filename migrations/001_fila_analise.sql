@@ -0,0 +1,25 @@
+-- Fila persistente de análise pós-chamada
+-- Cada registro de historico_ligacoes possui no máximo um job de análise.
+
+CREATE TABLE IF NOT EXISTS fila_analise (
+    id SERIAL PRIMARY KEY,
+    historico_id INTEGER NOT NULL UNIQUE REFERENCES historico_ligacoes(id) ON DELETE CASCADE,
+
+    -- Estado do job
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+        CHECK (status IN ('pendente', 'processando', 'concluido', 'erro', 'falha_definitiva')),
+    tentativas INTEGER NOT NULL DEFAULT 0,
+    max_tentativas INTEGER NOT NULL DEFAULT 5,
+    proxima_tentativa TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    ultimo_erro TEXT,
+
+    -- Evita alertar a família duas vezes pela mesma chamada
+    alerta_disparado BOOLEAN NOT NULL DEFAULT FALSE,
+
+    iniciado_em TIMESTAMP,
+    concluido_em TIMESTAMP,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_fila_analise_pronta ON fila_analise(status, proxima_tentativa);
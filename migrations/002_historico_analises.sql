@@ -0,0 +1,31 @@
+-- Versões de análise por ligação (reanálise em massa)
+-- Os resultados ficam aqui até serem promovidos para historico_ligacoes.
+
+CREATE TABLE IF NOT EXISTS historico_analises (
+    id SERIAL PRIMARY KEY,
+    historico_id INTEGER NOT NULL REFERENCES historico_ligacoes(id) ON DELETE CASCADE,
+    versao VARCHAR(64) NOT NULL,
+
+    analise JSONB NOT NULL,
+    urgencia VARCHAR(20),
+    sentimento VARCHAR(50),
+
+    -- Valores vigentes no momento da reanálise (base do relatório de diferenças)
+    urgencia_anterior VARCHAR(20),
+    sentimento_anterior VARCHAR(50),
+
+    aplicada BOOLEAN NOT NULL DEFAULT FALSE,
+    aplicada_em TIMESTAMP,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_historico_versao UNIQUE (historico_id, versao)
+);
+
+CREATE INDEX IF NOT EXISTS idx_historico_analises_versao ON historico_analises(versao);
+
+-- Versão da análise atualmente vigente em cada ligação
+ALTER TABLE historico_ligacoes ADD COLUMN IF NOT EXISTS analise_versao VARCHAR(64);
+
+-- Entidade (casa de repouso, clínica) à qual o idoso pertence
+ALTER TABLE idosos ADD COLUMN IF NOT EXISTS entidade_nome VARCHAR(255);
+CREATE INDEX IF NOT EXISTS idx_idosos_entidade ON idosos(entidade_nome);
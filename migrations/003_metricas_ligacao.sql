@@ -0,0 +1,18 @@
+-- Métricas normalizadas por ligação, derivadas de analise_gemini.
+-- Consumidas pelos workers de padrões e predições.
+-- Ligações antigas são preenchidas automaticamente pelo AnalysisWorker.
+
+ALTER TABLE historico_ligacoes ADD COLUMN IF NOT EXISTS sentimento_geral VARCHAR(20);
+ALTER TABLE historico_ligacoes ADD COLUMN IF NOT EXISTS sentimento_intensidade INTEGER;
+ALTER TABLE historico_ligacoes ADD COLUMN IF NOT EXISTS dor_intensidade INTEGER;
+ALTER TABLE historico_ligacoes ADD COLUMN IF NOT EXISTS confusao BOOLEAN;
+ALTER TABLE historico_ligacoes ADD COLUMN IF NOT EXISTS solidao BOOLEAN;
+ALTER TABLE historico_ligacoes ADD COLUMN IF NOT EXISTS medicamento_tomado BOOLEAN;
+
+CREATE INDEX IF NOT EXISTS idx_historico_ligacoes_metricas
+    ON historico_ligacoes(idoso_id, inicio_chamada)
+    WHERE sentimento_geral IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_historico_ligacoes_sem_metricas
+    ON historico_ligacoes(id)
+    WHERE analise_gemini IS NOT NULL AND sentimento_geral IS NULL;
@@ -0,0 +1,35 @@
+-- Resumo clínico semanal por idoso (feature relatorios_detalhados)
+
+CREATE TABLE IF NOT EXISTS relatorios_semanais (
+    id SERIAL PRIMARY KEY,
+    idoso_id INTEGER NOT NULL REFERENCES idosos(id) ON DELETE CASCADE,
+    semana_inicio DATE NOT NULL,
+    semana_fim DATE NOT NULL,
+
+    -- Tabelas determinísticas (ligações, alertas, medicação, predições)
+    estatisticas JSONB NOT NULL,
+    -- Texto redigido pelo modelo de análise a partir das estatísticas
+    narrativa TEXT NOT NULL,
+    modelo VARCHAR(100),
+
+    enviado_em TIMESTAMP,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_relatorio_semana UNIQUE (idoso_id, semana_inicio)
+);
+
+CREATE INDEX IF NOT EXISTS idx_relatorios_semanais_idoso ON relatorios_semanais(idoso_id, semana_inicio DESC);
+
+-- Quem recebe o relatório por email (cuidadores e médicos que optaram por receber)
+CREATE TABLE IF NOT EXISTS relatorio_assinantes (
+    id SERIAL PRIMARY KEY,
+    idoso_id INTEGER NOT NULL REFERENCES idosos(id) ON DELETE CASCADE,
+    cuidador_id INTEGER REFERENCES cuidadores(id) ON DELETE CASCADE,
+    nome VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    papel VARCHAR(20) NOT NULL DEFAULT 'cuidador' CHECK (papel IN ('cuidador', 'medico')),
+    ativo BOOLEAN NOT NULL DEFAULT TRUE,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_assinante_email UNIQUE (idoso_id, email)
+);
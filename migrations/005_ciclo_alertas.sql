@@ -0,0 +1,62 @@
+-- Ciclo de vida dos alertas e trilha de auditoria
+-- Estados: criado → despachado → entregue → reconhecido → resolvido
+--          (escalado e expirado a partir de qualquer estado aberto)
+
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS enviado BOOLEAN DEFAULT FALSE;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS data_envio TIMESTAMP;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS visualizado BOOLEAN DEFAULT FALSE;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS data_visualizacao TIMESTAMP;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS necessita_escalamento BOOLEAN DEFAULT FALSE;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS tempo_escalamento TIMESTAMP;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS tentativas_envio INTEGER DEFAULT 0;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS ultima_tentativa TIMESTAMP;
+
+-- Estado explícito (alertas antigos são derivados das flags legadas)
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS status VARCHAR(20);
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS status_atualizado_em TIMESTAMP;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS resolvido_em TIMESTAMP;
+
+UPDATE alertas SET
+    status = CASE
+        WHEN visualizado = true THEN 'reconhecido'
+        WHEN enviado = true THEN 'despachado'
+        ELSE 'criado'
+    END,
+    status_atualizado_em = COALESCE(data_visualizacao, data_envio, criado_em)
+WHERE status IS NULL;
+
+ALTER TABLE alertas ALTER COLUMN status SET DEFAULT 'criado';
+ALTER TABLE alertas ALTER COLUMN status SET NOT NULL;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'alertas_status_check') THEN
+        ALTER TABLE alertas ADD CONSTRAINT alertas_status_check CHECK (status IN (
+            'criado', 'despachado', 'entregue', 'reconhecido', 'escalado', 'resolvido', 'expirado'
+        ));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_alertas_abertos ON alertas(idoso_id, criado_em DESC)
+    WHERE status NOT IN ('resolvido', 'expirado');
+
+CREATE INDEX IF NOT EXISTS idx_alertas_escalamento ON alertas(tempo_escalamento)
+    WHERE necessita_escalamento = true;
+
+-- Uma linha por transição ou tentativa de envio
+CREATE TABLE IF NOT EXISTS historico_alertas (
+    id SERIAL PRIMARY KEY,
+    alerta_id INTEGER NOT NULL REFERENCES alertas(id) ON DELETE CASCADE,
+    acao VARCHAR(30) NOT NULL,
+    metodo VARCHAR(20),
+    detalhes TEXT,
+    sucesso BOOLEAN NOT NULL DEFAULT TRUE,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE historico_alertas ADD COLUMN IF NOT EXISTS estado_anterior VARCHAR(20);
+ALTER TABLE historico_alertas ADD COLUMN IF NOT EXISTS estado_novo VARCHAR(20);
+ALTER TABLE historico_alertas ADD COLUMN IF NOT EXISTS destinatario VARCHAR(255);
+ALTER TABLE historico_alertas ADD COLUMN IF NOT EXISTS erro TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_historico_alertas_alerta ON historico_alertas(alerta_id, criado_em);
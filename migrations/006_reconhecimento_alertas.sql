@@ -0,0 +1,7 @@
+-- Reconhecimento de alertas pelos cuidadores (API do app)
+
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS reconhecido_por INTEGER REFERENCES cuidadores(id) ON DELETE SET NULL;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS resolvido_por INTEGER REFERENCES cuidadores(id) ON DELETE SET NULL;
+
+-- Cuidador que executou a ação (reconhecimento, resolução, nota)
+ALTER TABLE historico_alertas ADD COLUMN IF NOT EXISTS cuidador_id INTEGER REFERENCES cuidadores(id) ON DELETE SET NULL;
@@ -0,0 +1,55 @@
+-- Políticas de escalonamento de alertas
+-- Uma política vale para um idoso, para uma entidade (entidade_nome) ou para
+-- todos (ambos nulos). A mais específica vence. Sem política cadastrada, o
+-- escalonamento usa ALERT_ESCALATION_TIME / CRITICAL_ALERT_TIMEOUT.
+
+CREATE TABLE IF NOT EXISTS contatos_emergencia (
+    id SERIAL PRIMARY KEY,
+    idoso_id INTEGER REFERENCES idosos(id) ON DELETE CASCADE,
+    nome VARCHAR(255),
+    telefone VARCHAR(20),
+    email VARCHAR(255),
+    device_token TEXT,
+    prioridade INTEGER DEFAULT 1,
+    metodo_preferido VARCHAR(20) DEFAULT 'sms'
+);
+
+CREATE TABLE IF NOT EXISTS politicas_escalonamento (
+    id SERIAL PRIMARY KEY,
+    nome VARCHAR(100) NOT NULL,
+    idoso_id INTEGER REFERENCES idosos(id) ON DELETE CASCADE,
+    entidade_nome VARCHAR(255),
+    severidade VARCHAR(20) NOT NULL,
+    ativa BOOLEAN NOT NULL DEFAULT TRUE,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (idoso_id IS NULL OR entidade_nome IS NULL)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_politicas_escalonamento_escopo
+    ON politicas_escalonamento(COALESCE(idoso_id, 0), COALESCE(entidade_nome, ''), severidade)
+    WHERE ativa = true;
+
+CREATE TABLE IF NOT EXISTS politica_etapas (
+    id SERIAL PRIMARY KEY,
+    politica_id INTEGER NOT NULL REFERENCES politicas_escalonamento(id) ON DELETE CASCADE,
+    ordem INTEGER NOT NULL,
+
+    -- Quem recebe a etapa
+    alvo VARCHAR(30) NOT NULL
+        CHECK (alvo IN ('cuidador_prioridade', 'todos_cuidadores', 'contato_emergencia', 'central_monitoramento')),
+    prioridade INTEGER,          -- alvo cuidador_prioridade
+    destino VARCHAR(255),        -- alvo central_monitoramento (telefone, email ou URL)
+
+    canal VARCHAR(20) NOT NULL
+        CHECK (canal IN ('push', 'sms', 'email', 'ligacao', 'whatsapp', 'webhook')),
+
+    -- Espera desde a etapa anterior (ou do envio do alerta) antes de executar
+    espera_minutos INTEGER NOT NULL DEFAULT 5,
+
+    CONSTRAINT unique_politica_ordem UNIQUE (politica_id, ordem)
+);
+
+-- Progresso do alerta na política
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS etapa_escalamento INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS politica_id INTEGER REFERENCES politicas_escalonamento(id) ON DELETE SET NULL;
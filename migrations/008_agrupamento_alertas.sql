@@ -0,0 +1,14 @@
+-- Deduplicação e agrupamento de alertas repetidos
+-- chave_dedup = hash(idoso, tipo, motivo normalizado); repetições dentro da
+-- janela (ALERT_DEDUP_WINDOW) incrementam ocorrencias no alerta aberto.
+
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS chave_dedup VARCHAR(64);
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS ocorrencias INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE alertas ADD COLUMN IF NOT EXISTS ultima_ocorrencia TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_alertas_dedup ON alertas(chave_dedup, criado_em DESC)
+    WHERE status NOT IN ('resolvido', 'expirado');
+
+-- Limite de notificações por cuidador (contagem das tentativas da última hora)
+CREATE INDEX IF NOT EXISTS idx_historico_alertas_destinatario ON historico_alertas(destinatario, criado_em)
+    WHERE acao = 'tentativa_envio' AND sucesso = true;
@@ -0,0 +1,17 @@
+-- Mensagens SMS enviadas via Twilio
+-- O status é atualizado pelos callbacks de entrega (/api/sms/status):
+-- queued → sent → delivered | undelivered | failed
+
+CREATE TABLE IF NOT EXISTS mensagens_sms (
+    id SERIAL PRIMARY KEY,
+    sid VARCHAR(64) NOT NULL UNIQUE,
+    alerta_id INTEGER REFERENCES alertas(id) ON DELETE SET NULL,
+    telefone VARCHAR(20) NOT NULL,
+    corpo TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'queued',
+    erro_codigo VARCHAR(10),
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mensagens_sms_alerta ON mensagens_sms(alerta_id);
@@ -0,0 +1,21 @@
+-- Ligações telefônicas de escalonamento (Twilio Voice)
+-- Cada linha é uma tentativa para um cuidador ou contato de emergência. Quem
+-- atende e pressiona 1 confirma o alerta; sem confirmação a ligação é repetida
+-- (CALL_MAX_ATTEMPTS) e depois passa ao próximo da lista.
+
+CREATE TABLE IF NOT EXISTS chamadas_alerta (
+    id SERIAL PRIMARY KEY,
+    alerta_id INTEGER NOT NULL REFERENCES alertas(id) ON DELETE CASCADE,
+    cuidador_id INTEGER REFERENCES cuidadores(id) ON DELETE SET NULL,
+    contato_id INTEGER REFERENCES contatos_emergencia(id) ON DELETE SET NULL,
+    telefone VARCHAR(20) NOT NULL,
+    tentativa INTEGER NOT NULL DEFAULT 1,
+    sid VARCHAR(64) UNIQUE,
+    status VARCHAR(20) NOT NULL DEFAULT 'iniciada',
+    digitos VARCHAR(10),
+    confirmada BOOLEAN NOT NULL DEFAULT FALSE,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chamadas_alerta_alerta ON chamadas_alerta(alerta_id);
@@ -0,0 +1,19 @@
+-- Mensagens de WhatsApp (Cloud API) enviadas aos cuidadores
+-- wamid liga os webhooks de status (sent/delivered/read/failed) e as respostas
+-- dos botões "Vi" / "Estou indo" ao alerta e ao destinatário.
+
+CREATE TABLE IF NOT EXISTS mensagens_whatsapp (
+    id SERIAL PRIMARY KEY,
+    wamid VARCHAR(128) NOT NULL UNIQUE,
+    alerta_id INTEGER REFERENCES alertas(id) ON DELETE SET NULL,
+    cuidador_id INTEGER REFERENCES cuidadores(id) ON DELETE SET NULL,
+    contato_id INTEGER REFERENCES contatos_emergencia(id) ON DELETE SET NULL,
+    telefone VARCHAR(20) NOT NULL,
+    template VARCHAR(100) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'accepted',
+    resposta VARCHAR(30),
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_mensagens_whatsapp_alerta ON mensagens_whatsapp(alerta_id);
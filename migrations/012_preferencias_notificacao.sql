@@ -0,0 +1,44 @@
+-- Preferências de notificação por cuidador
+-- Sem linha aqui o cuidador recebe tudo, imediatamente, a qualquer hora.
+-- Alertas críticos ignoram horário de silêncio, modo resumo e filtros.
+
+CREATE TABLE IF NOT EXISTS preferencias_notificacao (
+    cuidador_id INTEGER PRIMARY KEY REFERENCES cuidadores(id) ON DELETE CASCADE,
+
+    -- Tipos de evento recebidos: alerta, chamada_perdida, medicamento, relatorio
+    eventos TEXT[] NOT NULL DEFAULT ARRAY['alerta', 'chamada_perdida', 'medicamento', 'relatorio'],
+
+    -- Alertas abaixo desta severidade não são enviados
+    severidade_minima VARCHAR(20) NOT NULL DEFAULT 'aviso'
+        CHECK (severidade_minima IN ('aviso', 'baixa', 'media', 'alta', 'critica')),
+
+    -- Horário de silêncio no fuso do cuidador (pode cruzar a meia-noite)
+    silencio_inicio TIME,
+    silencio_fim TIME,
+    fuso_horario VARCHAR(50) NOT NULL DEFAULT 'America/Sao_Paulo',
+
+    -- imediato: envia na hora; resumo: agrupa e envia a cada intervalo_resumo_horas
+    modo_entrega VARCHAR(10) NOT NULL DEFAULT 'imediato'
+        CHECK (modo_entrega IN ('imediato', 'resumo')),
+    intervalo_resumo_horas INTEGER NOT NULL DEFAULT 4 CHECK (intervalo_resumo_horas > 0),
+    ultimo_resumo_em TIMESTAMP,
+
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK ((silencio_inicio IS NULL) = (silencio_fim IS NULL))
+);
+
+-- Notificações adiadas (horário de silêncio ou modo resumo), entregues em lote
+CREATE TABLE IF NOT EXISTS notificacoes_adiadas (
+    id SERIAL PRIMARY KEY,
+    cuidador_id INTEGER NOT NULL REFERENCES cuidadores(id) ON DELETE CASCADE,
+    alerta_id INTEGER REFERENCES alertas(id) ON DELETE SET NULL,
+    evento VARCHAR(20) NOT NULL,
+    severidade VARCHAR(20) NOT NULL,
+    mensagem TEXT NOT NULL,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    enviado_em TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notificacoes_adiadas_pendentes ON notificacoes_adiadas(cuidador_id, criado_em)
+    WHERE enviado_em IS NULL;
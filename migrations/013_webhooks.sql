@@ -0,0 +1,56 @@
+-- Webhooks de saída para integrações institucionais (plano profissional, feature api_integracao)
+-- Cada entrega é assinada com HMAC-SHA256 usando o segredo da assinatura e
+-- reenviada com backoff exponencial até max_tentativas.
+
+CREATE TABLE IF NOT EXISTS webhook_assinaturas (
+    id SERIAL PRIMARY KEY,
+    entidade_nome VARCHAR(255) NOT NULL,
+    url TEXT NOT NULL,
+    segredo VARCHAR(100) NOT NULL,
+    -- alerta.criado, alerta.reconhecido, chamada.finalizada, chamada.perdida,
+    -- medicamento.confirmado, predicao.gerada
+    eventos TEXT[] NOT NULL,
+    descricao TEXT,
+    ativo BOOLEAN NOT NULL DEFAULT true,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_assinaturas_entidade ON webhook_assinaturas(entidade_nome) WHERE ativo;
+
+CREATE TABLE IF NOT EXISTS webhook_entregas (
+    id SERIAL PRIMARY KEY,
+    assinatura_id INTEGER NOT NULL REFERENCES webhook_assinaturas(id) ON DELETE CASCADE,
+    evento_id VARCHAR(64) NOT NULL,
+    evento VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+        CHECK (status IN ('pendente', 'enviando', 'entregue', 'erro', 'falha_definitiva')),
+    tentativas INTEGER NOT NULL DEFAULT 0,
+    max_tentativas INTEGER NOT NULL DEFAULT 10,
+    proxima_tentativa TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    iniciado_em TIMESTAMP,
+    ultimo_status_http INTEGER,
+    ultimo_erro TEXT,
+    entregue_em TIMESTAMP,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_entregas_fila ON webhook_entregas(proxima_tentativa)
+    WHERE status IN ('pendente', 'erro', 'enviando');
+CREATE INDEX IF NOT EXISTS idx_webhook_entregas_assinatura ON webhook_entregas(assinatura_id, criado_em DESC);
+
+-- Log de cada tentativa de entrega (automática ou manual)
+CREATE TABLE IF NOT EXISTS webhook_tentativas (
+    id SERIAL PRIMARY KEY,
+    entrega_id INTEGER NOT NULL REFERENCES webhook_entregas(id) ON DELETE CASCADE,
+    tentativa INTEGER NOT NULL,
+    status_http INTEGER,
+    erro TEXT,
+    resposta TEXT,
+    duracao_ms INTEGER,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_tentativas_entrega ON webhook_tentativas(entrega_id);
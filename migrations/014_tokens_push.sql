@@ -0,0 +1,23 @@
+-- Validade dos device tokens (FCM) e resumo de cada envio multicast
+-- Tokens que o FCM reporta como não registrados ou de outro sender são marcados
+-- como inválidos automaticamente e deixam de ser usados até o app registrar outro.
+
+ALTER TABLE idosos ADD COLUMN IF NOT EXISTS device_token_valido BOOLEAN DEFAULT true;
+ALTER TABLE idosos ADD COLUMN IF NOT EXISTS device_token_atualizado_em TIMESTAMP;
+
+ALTER TABLE cuidadores ADD COLUMN IF NOT EXISTS device_token_valido BOOLEAN DEFAULT true;
+ALTER TABLE cuidadores ADD COLUMN IF NOT EXISTS device_token_atualizado_em TIMESTAMP;
+
+CREATE TABLE IF NOT EXISTS envios_push (
+    id SERIAL PRIMARY KEY,
+    alerta_id INTEGER REFERENCES alertas(id) ON DELETE SET NULL,
+    tipo VARCHAR(50) NOT NULL,
+    total INTEGER NOT NULL,
+    sucesso INTEGER NOT NULL,
+    falha INTEGER NOT NULL,
+    invalidos INTEGER NOT NULL DEFAULT 0,
+    duracao_ms INTEGER,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_envios_push_alerta ON envios_push(alerta_id);
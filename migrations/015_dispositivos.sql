@@ -0,0 +1,44 @@
+-- Dispositivos dos apps (idoso e cuidador) e credenciais de acesso dos apps
+-- Uma pessoa pode ter vários dispositivos. O mais recente e válido é espelhado em
+-- idosos.device_token / cuidadores.device_token para quem ainda lê só a coluna.
+
+CREATE TABLE IF NOT EXISTS dispositivos (
+    id SERIAL PRIMARY KEY,
+    tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('idoso', 'cuidador')),
+    pessoa_id INTEGER NOT NULL,
+    token TEXT NOT NULL UNIQUE,
+    plataforma VARCHAR(20) NOT NULL CHECK (plataforma IN ('android', 'ios', 'web')),
+    versao_app VARCHAR(30),
+    locale VARCHAR(20),
+    valido BOOLEAN NOT NULL DEFAULT true,
+    invalidado_em TIMESTAMP,
+    ultimo_registro_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispositivos_pessoa ON dispositivos(tipo, pessoa_id) WHERE valido;
+
+-- Credenciais dos apps: apenas o SHA-256 do token é guardado
+CREATE TABLE IF NOT EXISTS credenciais_app (
+    id SERIAL PRIMARY KEY,
+    tipo VARCHAR(20) NOT NULL CHECK (tipo IN ('idoso', 'cuidador')),
+    pessoa_id INTEGER NOT NULL,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    descricao TEXT,
+    ultimo_uso_em TIMESTAMP,
+    revogado_em TIMESTAMP,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_credenciais_app_pessoa ON credenciais_app(tipo, pessoa_id);
+
+-- Tokens já gravados nas colunas antigas viram dispositivos (plataforma presumida)
+INSERT INTO dispositivos (tipo, pessoa_id, token, plataforma, valido)
+SELECT 'idoso', id, device_token, 'android', COALESCE(device_token_valido, true)
+FROM idosos WHERE device_token IS NOT NULL AND device_token <> ''
+ON CONFLICT (token) DO NOTHING;
+
+INSERT INTO dispositivos (tipo, pessoa_id, token, plataforma, valido)
+SELECT 'cuidador', id, device_token, 'android', COALESCE(device_token_valido, true)
+FROM cuidadores WHERE device_token IS NOT NULL AND device_token <> ''
+ON CONFLICT (token) DO NOTHING;
@@ -0,0 +1,30 @@
+-- Fila de push (outbox): envios que falharam por erro transitório do FCM
+-- (indisponível, interno, cota, rede) são reenviados com backoff exponencial
+-- pelo PushOutboxWorker até expira_em (TTL do tipo: 45s para chamadas, 1h para alertas).
+
+CREATE TABLE IF NOT EXISTS push_outbox (
+    id SERIAL PRIMARY KEY,
+    -- incoming_call, emergency_alert, missed_call_alert, medication_confirmed, notification_digest
+    tipo VARCHAR(40) NOT NULL,
+    token TEXT NOT NULL,
+    -- argumentos para recriar a mensagem
+    dados JSONB NOT NULL DEFAULT '{}',
+    alerta_id INTEGER REFERENCES alertas(id) ON DELETE CASCADE,
+    agendamento_id INTEGER REFERENCES agendamentos(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+        CHECK (status IN ('pendente', 'enviando', 'enviado', 'expirado', 'falha_definitiva')),
+    tentativas INTEGER NOT NULL DEFAULT 0,
+    max_tentativas INTEGER NOT NULL DEFAULT 8,
+    proxima_tentativa TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expira_em TIMESTAMP NOT NULL,
+    iniciado_em TIMESTAMP,
+    ultimo_erro TEXT,
+    message_id TEXT,
+    enviado_em TIMESTAMP,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_push_outbox_fila ON push_outbox(proxima_tentativa)
+    WHERE status IN ('pendente', 'enviando');
+CREATE INDEX IF NOT EXISTS idx_push_outbox_criado ON push_outbox(criado_em DESC);
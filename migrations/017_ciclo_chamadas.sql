@@ -0,0 +1,28 @@
+-- Ciclo de vida das chamadas da EVA, do push ao fim da conversa.
+-- tocando -> entregue (app recebeu o push) -> atendida (WebSocket com o session_id)
+-- -> concluida; recusada pelo app; perdida sem atendimento em 45s; falhou se o
+-- push não chegar ou a sessão cair antes de começar.
+
+CREATE TABLE IF NOT EXISTS chamadas (
+    id SERIAL PRIMARY KEY,
+    sessao_id VARCHAR(64) NOT NULL UNIQUE,
+    agendamento_id INTEGER REFERENCES agendamentos(id) ON DELETE SET NULL,
+    idoso_id INTEGER NOT NULL REFERENCES idosos(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'tocando'
+        CHECK (status IN ('tocando', 'entregue', 'recusada', 'atendida', 'concluida', 'falhou', 'perdida')),
+    motivo TEXT,
+    tocando_em TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    entregue_em TIMESTAMP,
+    atendida_em TIMESTAMP,
+    encerrada_em TIMESTAMP,
+    -- perdida/recusada já viraram alerta e histórico (checkMissedCalls)
+    processada BOOLEAN NOT NULL DEFAULT false,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_chamadas_abertas ON chamadas(idoso_id, tocando_em DESC)
+    WHERE status IN ('tocando', 'entregue', 'atendida');
+CREATE INDEX IF NOT EXISTS idx_chamadas_pendentes ON chamadas(status)
+    WHERE NOT processada AND status IN ('recusada', 'perdida');
+CREATE INDEX IF NOT EXISTS idx_chamadas_agendamento ON chamadas(agendamento_id);
@@ -0,0 +1,17 @@
+-- Emails localizados: fuso do idoso (datas dos emails) e marca por entidade
+-- O idioma vem do locale do aparelho mais recente do cuidador, depois do idioma
+-- da entidade e, por fim, pt-BR.
+
+ALTER TABLE idosos ADD COLUMN IF NOT EXISTS fuso_horario VARCHAR(50) NOT NULL DEFAULT 'America/Sao_Paulo';
+
+-- Marca da entidade nos emails (templates embutidos; só logo, cores e textos mudam)
+CREATE TABLE IF NOT EXISTS email_marcas (
+    entidade_nome VARCHAR(255) PRIMARY KEY,
+    nome_exibicao VARCHAR(100),
+    logo_url TEXT CHECK (logo_url IS NULL OR logo_url LIKE 'https://%'),
+    cor_primaria VARCHAR(7) CHECK (cor_primaria IS NULL OR cor_primaria ~ '^#[0-9A-Fa-f]{6}$'),
+    cor_alerta VARCHAR(7) CHECK (cor_alerta IS NULL OR cor_alerta ~ '^#[0-9A-Fa-f]{6}$'),
+    idioma VARCHAR(20),
+    rodape TEXT,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
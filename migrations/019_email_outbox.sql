@@ -0,0 +1,31 @@
+-- Fila de email (outbox): os emails são renderizados na hora do evento e
+-- enviados pelo EmailQueueWorker, reaproveitando a conexão SMTP por lote.
+-- Erros temporários voltam para a fila com backoff; 5xx do servidor é definitivo.
+
+CREATE TABLE IF NOT EXISTS email_outbox (
+    id SERIAL PRIMARY KEY,
+    -- missed_call, emergency_alert, weekly_report, notification_digest
+    template VARCHAR(40) NOT NULL,
+    destinatario VARCHAR(255) NOT NULL,
+    assunto TEXT NOT NULL,
+    texto TEXT NOT NULL,
+    html TEXT NOT NULL,
+    alerta_id INTEGER REFERENCES alertas(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pendente'
+        CHECK (status IN ('pendente', 'enviando', 'enviado', 'expirado', 'falha_definitiva')),
+    tentativas INTEGER NOT NULL DEFAULT 0,
+    max_tentativas INTEGER NOT NULL DEFAULT 6,
+    proxima_tentativa TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    -- alertas perdem o sentido depois de algumas horas; relatórios não expiram
+    expira_em TIMESTAMP,
+    iniciado_em TIMESTAMP,
+    ultimo_erro TEXT,
+    enviado_em TIMESTAMP,
+    criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_email_outbox_fila ON email_outbox(proxima_tentativa)
+    WHERE status IN ('pendente', 'enviando');
+CREATE INDEX IF NOT EXISTS idx_email_outbox_criado ON email_outbox(criado_em DESC);
+CREATE INDEX IF NOT EXISTS idx_email_outbox_alerta ON email_outbox(alerta_id) WHERE alerta_id IS NOT NULL;
@@ -0,0 +1,28 @@
+-- Resumo semanal por cuidador: ligações, adesão à medicação, humor, alertas em
+-- aberto e o que os workers de padrões e predições encontraram de novo na semana.
+-- Sai por email na segunda-feira para quem mantém 'resumo_semanal' nas preferências.
+
+CREATE TABLE IF NOT EXISTS resumos_semanais_cuidador (
+    id SERIAL PRIMARY KEY,
+    cuidador_id INTEGER NOT NULL REFERENCES cuidadores(id) ON DELETE CASCADE,
+    idoso_id INTEGER NOT NULL REFERENCES idosos(id) ON DELETE CASCADE,
+    semana_inicio DATE NOT NULL,
+    -- Conteúdo enviado (o mesmo que foi renderizado no email)
+    conteudo JSONB NOT NULL,
+    enviado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_resumo_cuidador_semana UNIQUE (cuidador_id, semana_inicio)
+);
+
+-- "Novo na semana" depende de quando o padrão/predição foi criado
+ALTER TABLE padroes_comportamento ADD COLUMN IF NOT EXISTS criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+ALTER TABLE predicoes_emergencia ADD COLUMN IF NOT EXISTS criado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP;
+
+-- Novo evento nas preferências. Quem já recebia o relatório passa a receber o
+-- resumo também; quem tirou 'relatorio' continua sem emails semanais.
+ALTER TABLE preferencias_notificacao
+    ALTER COLUMN eventos SET DEFAULT ARRAY['alerta', 'chamada_perdida', 'medicamento', 'relatorio', 'resumo_semanal'];
+
+UPDATE preferencias_notificacao
+SET eventos = array_append(eventos, 'resumo_semanal'), atualizado_em = NOW()
+WHERE 'relatorio' = ANY(eventos) AND NOT ('resumo_semanal' = ANY(eventos));
@@ -0,0 +1,21 @@
+-- Entrega do relatório semanal por assinante. O relatório só fica como enviado
+-- (relatorios_semanais.enviado_em) quando todos os assinantes receberam; o
+-- ReportWorker tenta de novo, a cada hora, só quem falhou, até 5 tentativas
+-- por assinante (reports.maxDeliveryAttempts).
+
+CREATE TABLE IF NOT EXISTS relatorio_envios (
+    id SERIAL PRIMARY KEY,
+    relatorio_id INTEGER NOT NULL REFERENCES relatorios_semanais(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    cuidador_id INTEGER REFERENCES cuidadores(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'falhou' CHECK (status IN ('enviado', 'falhou')),
+    tentativas INTEGER NOT NULL DEFAULT 0,
+    ultimo_erro TEXT,
+    enviado_em TIMESTAMP,
+    atualizado_em TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_relatorio_envio UNIQUE (relatorio_id, email)
+);
+
+CREATE INDEX IF NOT EXISTS idx_relatorio_envios_falhas ON relatorio_envios(relatorio_id)
+    WHERE status = 'falhou';